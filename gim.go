@@ -1,14 +1,17 @@
 package main
 
 import (
+	"bufio"
+	"context"
+	"flag"
 	"fmt"
+	"gim/terminfo"
 	"gim/window"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
-	prompt "github.com/c-bata/go-prompt"
-
 	"golang.org/x/crypto/ssh/terminal"
 )
 
@@ -25,143 +28,84 @@ const (
 	ExitError
 )
 
-var normalState *terminal.State
-
 func main() {
+	config := flag.String("u", "", "use this config file instead of ~/.gimrc, NONE for no config at all")
+	flag.Parse()
 	if !terminal.IsTerminal(syscall.Stdin) {
 		fmt.Println(NotTerminalWarning)
 		os.Exit(ExitError)
 	}
-	switch len(os.Args) {
-	case 1:
+	switch flag.NArg() {
+	case 0:
 		fmt.Println("no arg")
-	case 2:
+	case 1:
 		signalChan := make(chan os.Signal, 1)
-		// catch SIGINT(Ctrl+C), KILL signal, and window size changes
+		// catch SIGINT(Ctrl+C) and KILL signal, window size changes come from the terminal
 		signal.Notify(
 			signalChan,
 			syscall.SIGINT,
 			syscall.SIGTERM,
-			syscall.SIGWINCH,
 		)
 
 		// create window
-		win := window.NewWindow(os.Stdin, os.Stdout)
+		tty := window.NewTTY(os.Stdin, os.Stdout)
+		if ti, err := terminfo.Load(os.Getenv("TERM"), os.Getenv); err == nil {
+			tty.Caps = ti
+		}
+		tty.ColorMode = window.DetectColorMode(tty.Caps, os.Getenv)
+		tty.SyncUpdate = tty.Caps.Has("Sync") || window.SyncUpdateSupported(os.Getenv)
+		win := window.NewWindow(tty)
+		loadConfig(win, *config)
 
-		fileName := os.Args[1]
+		fileName := flag.Arg(0)
 		if err := win.SetFileContents(fileName); err != nil {
 			fmt.Println(err)
 			os.Exit(ExitError)
 		}
+		// the history of the last sessions is not needed to edit
+		historyFile := window.HistoryFile()
+		_ = win.LoadHistory(historyFile)
 
-		err := win.SetSize()
-		if err != nil {
-			fmt.Printf("set window sieze error: %v", err)
+		if err := tty.Start(); err != nil {
+			fmt.Printf("make raw error: %v\n", err)
 			os.Exit(ExitError)
 		}
-		win.PrintFileContents()
 
-		exitChan := make(chan int)
+		ctx, cancel := context.WithCancel(context.Background())
+		signalCode := make(chan int, 1)
 		go func() {
-			for {
-				s := <-signalChan
-				switch s {
-				// SIGINT(Ctrl+C)
-				case syscall.SIGINT:
-					exitChan <- 130
-
-				// kILL signal
-				case syscall.SIGTERM:
-					exitChan <- 143
-
-				case syscall.SIGWINCH:
-					// In raw mode, the file content view will be corrupted,
-					// so return to normal mode.
-					err = terminal.Restore(syscall.Stdin, normalState)
-					if err != nil {
-						fmt.Printf("restore raw error: %v\n", err)
-						os.Exit(ExitError)
-					}
-					err := win.SetSize()
-					if err != nil {
-						fmt.Printf("set window sieze error: %v", err)
-						os.Exit(ExitError)
-					}
-					win.PrintFileContents()
-					normalState, err = terminal.MakeRaw(syscall.Stdin)
-					if err != nil {
-						fmt.Printf("make raw error: %v\n", err)
-						os.Exit(ExitError)
-					}
-				default:
-					exitChan <- 1
-				}
+			switch <-signalChan {
+			// SIGINT(Ctrl+C)
+			case syscall.SIGINT:
+				signalCode <- 130
+
+			// kILL signal
+			case syscall.SIGTERM:
+				signalCode <- 143
+			default:
+				signalCode <- 1
 			}
+			cancel()
 		}()
 
-		bufCh := make(chan []byte, 128)
-		go win.ReadBuffer(bufCh)
-		go func() {
-			for {
-				normalState, err = terminal.MakeRaw(syscall.Stdin)
-				if err != nil {
-					fmt.Printf("make raw error: %v\n", err)
-					exitChan <- 1
-				}
-				b := <-bufCh
-				if win.IsCommandMode() {
-					switch win.GetKey(b) {
-					case prompt.Up, prompt.Down, prompt.Left, prompt.Right:
-					case prompt.ControlC:
-						exitChan <- 130
-					case prompt.Escape:
-						win.SetNormalMode()
-						win.MoveCursorToCurrentPosition()
-					case prompt.Delete, prompt.Backspace:
-						if win.IsCommandNotTyped() {
-							win.SetNormalMode()
-							win.MoveCursorToCurrentPosition()
-						} else {
-							win.RemoveCommand()
-							fmt.Fprintf(win.Output, "\033[2K")
-							fmt.Fprintf(win.Output, "\033[%d;%dH:%s", win.Row, 0, win.TypedCommand())
-						}
-					case prompt.Enter:
-						win.ExecuteCommand()
-						win.ResetCommand()
-						fmt.Fprintf(win.Output, "\033[2K")
-						win.SetNormalMode()
-						win.MoveCursorToCurrentPosition()
-					default:
-						win.AddCommand(b)
-						fmt.Fprintf(win.Output, "\033[%d;%dH:%s", win.Row, 0, win.TypedCommand())
-					}
-				} else {
-					switch win.GetKey(b) {
-					case prompt.Up:
-						win.InputtedUp()
-					case prompt.Down:
-						win.InputtedDown()
-					case prompt.Left:
-						win.InputtedLeft()
-					case prompt.Right:
-						win.InputtedRight()
-					case prompt.ControlC:
-						exitChan <- 130
-					case prompt.Escape:
-						win.SetNormalMode()
-					case prompt.NotDefined:
-						win.InputtedOther(b)
-					}
-				}
-				err = terminal.Restore(syscall.Stdin, normalState)
-				if err != nil {
-					fmt.Printf("restore raw error: %v\n", err)
-					os.Exit(ExitError)
-				}
+		defer func() {
+			// a crash of the editor must not leave the terminal raw, ex) with the mouse still reported
+			if r := recover(); r != nil {
+				tty.Close()
+				panic(r)
 			}
 		}()
-		code := <-exitChan
+		code, err := win.Run(ctx)
+		tty.Close()
+		if err == context.Canceled {
+			code, err = <-signalCode, nil
+		}
+		if err != nil {
+			fmt.Println(err)
+		}
+		if err := win.SaveHistory(historyFile); err != nil {
+			fmt.Println(err)
+		}
 		os.Exit(code)
 
 	default:
@@ -170,3 +114,39 @@ func main() {
 	}
 	os.Exit(ExitOk)
 }
+
+// loadConfig executes the user config file, or the file of -u, then the config of the project
+// once the user trusts it. -u NONE executes none of them.
+func loadConfig(win *window.Window, config string) {
+	if config == "NONE" {
+		return
+	}
+	if config == "" {
+		config = window.ConfigFile()
+	}
+	if config != "" {
+		win.Source(config)
+	}
+	project := window.ProjectConfigFile(config)
+	if project == "" {
+		return
+	}
+	trustFile := window.TrustFile()
+	trusted, err := window.Trusted(trustFile, project)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !trusted {
+		// the config of a project may come with files from anyone, ex) a cloned repository
+		fmt.Printf("%s is not trusted, it may execute any command. Trust it? [y/N] ", project)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if strings.ToLower(strings.TrimSpace(answer)) != "y" {
+			return
+		}
+		if err := window.Trust(trustFile, project); err != nil {
+			fmt.Println(err)
+		}
+	}
+	win.Source(project)
+}
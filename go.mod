@@ -4,7 +4,7 @@ go 1.13
 
 require (
 	github.com/c-bata/go-prompt v0.2.3
-	github.com/mattn/go-runewidth v0.0.7 // indirect
+	github.com/mattn/go-runewidth v0.0.7
 	github.com/mattn/go-tty v0.0.3 // indirect
 	github.com/pkg/term v0.0.0-20190109203006-aa71e9d9e942 // indirect
 	golang.org/x/crypto v0.0.0-20191206172530-e9b2fee46413
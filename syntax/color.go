@@ -0,0 +1,198 @@
+package syntax
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ColorMode is the number of colours the terminal can show.
+type ColorMode int
+
+const (
+	Color16 ColorMode = iota
+	Color256
+	TrueColor
+)
+
+// DetectColorMode guesses the colours the terminal supports from COLORTERM and TERM.
+func DetectColorMode(getenv func(string) string) ColorMode {
+	colorTerm := strings.ToLower(getenv("COLORTERM"))
+	term := getenv("TERM")
+	switch {
+	case colorTerm == "truecolor" || colorTerm == "24bit" || strings.HasSuffix(term, "-direct"):
+		return TrueColor
+	case strings.Contains(term, "256color") || colorTerm != "":
+		return Color256
+	default:
+		return Color16
+	}
+}
+
+// Color is a terminal colour. The zero value is the terminal's default colour.
+type Color uint32
+
+const (
+	colorValid Color = 1 << 25
+	colorRGB   Color = 1 << 24
+)
+
+// Indexed returns the colour n of the 256 colour palette.
+func Indexed(n uint8) Color {
+	return colorValid | Color(n)
+}
+
+// RGB returns a 24 bit colour.
+func RGB(r, g, b uint8) Color {
+	return colorValid | colorRGB | Color(r)<<16 | Color(g)<<8 | Color(b)
+}
+
+func (c Color) IsDefault() bool {
+	return c&colorValid == 0
+}
+
+func (c Color) isRGB() bool {
+	return c&colorRGB != 0
+}
+
+func (c Color) rgb() (r, g, b int) {
+	if c.isRGB() {
+		return int(c >> 16 & 0xff), int(c >> 8 & 0xff), int(c & 0xff)
+	}
+	return paletteRGB(int(c & 0xff))
+}
+
+// paletteRGB returns the RGB value xterm uses for the palette colour n.
+func paletteRGB(n int) (r, g, b int) {
+	switch {
+	case n < 16:
+		c := ansiColors[n]
+		return c[0], c[1], c[2]
+	case n < 232:
+		n -= 16
+		return cubeLevels[n/36], cubeLevels[n/6%6], cubeLevels[n%6]
+	default:
+		v := 8 + (n-232)*10
+		return v, v, v
+	}
+}
+
+var ansiColors = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0},
+	{0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0},
+	{92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// index256 returns the palette colour closest to c.
+func (c Color) index256() int {
+	if !c.isRGB() {
+		return int(c & 0xff)
+	}
+	r, g, b := c.rgb()
+	best, bestDist := 0, -1
+	// colours 0-15 are skipped because terminals redefine them.
+	for n := 16; n < 256; n++ {
+		pr, pg, pb := paletteRGB(n)
+		d := (r-pr)*(r-pr) + (g-pg)*(g-pg) + (b-pb)*(b-pb)
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// index16 returns the colour of the 16 colour palette closest to c.
+func (c Color) index16() int {
+	if !c.isRGB() && c&0xff < 16 {
+		return int(c & 0xff)
+	}
+	r, g, b := c.rgb()
+	best, bestDist := 0, -1
+	for n, p := range ansiColors {
+		d := (r-p[0])*(r-p[0]) + (g-p[1])*(g-p[1]) + (b-p[2])*(b-p[2])
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = n, d
+		}
+	}
+	return best
+}
+
+// Index returns the palette colour closest to c that the terminal can show in mode.
+func (c Color) Index(mode ColorMode) int {
+	if mode == Color16 {
+		return c.index16()
+	}
+	return c.index256()
+}
+
+// Attr is a set of text attributes.
+type Attr uint8
+
+const (
+	Bold Attr = 1 << iota
+	Italic
+	Underline
+	Reverse
+)
+
+// Style is how a highlight group is drawn.
+type Style struct {
+	Fg   Color
+	Bg   Color
+	Attr Attr
+}
+
+// Over returns s drawn on top of base: colours s leaves default are taken from base.
+func (s Style) Over(base Style) Style {
+	s.Fg = pick(s.Fg, base.Fg)
+	s.Bg = pick(s.Bg, base.Bg)
+	return s
+}
+
+// Sequence returns the SGR escape sequence that switches the terminal to s.
+// Attributes from the previous style are always reset.
+func (s Style) Sequence(mode ColorMode) string {
+	buf := []byte("\033[0")
+	for _, a := range []struct {
+		attr Attr
+		code string
+	}{{Bold, "1"}, {Italic, "3"}, {Underline, "4"}, {Reverse, "7"}} {
+		if s.Attr&a.attr != 0 {
+			buf = append(buf, ';')
+			buf = append(buf, a.code...)
+		}
+	}
+	buf = appendColor(buf, s.Fg, 30, mode)
+	buf = appendColor(buf, s.Bg, 40, mode)
+	return string(append(buf, 'm'))
+}
+
+// appendColor appends the SGR parameters of c, base is 30 for the foreground and 40 for the background.
+func appendColor(buf []byte, c Color, base int, mode ColorMode) []byte {
+	if c.IsDefault() {
+		return buf
+	}
+	buf = append(buf, ';')
+	if mode == Color16 {
+		n := c.index16()
+		if n >= 8 {
+			// bright colours, ex) 90 for bright black
+			n += 60 - 8
+		}
+		return strconv.AppendInt(buf, int64(base+n), 10)
+	}
+	buf = strconv.AppendInt(buf, int64(base+8), 10)
+	if mode == TrueColor && c.isRGB() {
+		r, g, b := c.rgb()
+		buf = append(buf, ";2;"...)
+		buf = strconv.AppendInt(buf, int64(r), 10)
+		buf = append(buf, ';')
+		buf = strconv.AppendInt(buf, int64(g), 10)
+		buf = append(buf, ';')
+		return strconv.AppendInt(buf, int64(b), 10)
+	}
+	buf = append(buf, ";5;"...)
+	return strconv.AppendInt(buf, int64(c.index256()), 10)
+}
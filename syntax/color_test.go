@@ -0,0 +1,54 @@
+package syntax
+
+import "testing"
+
+func TestStyle_Sequence(t *testing.T) {
+	tests := []struct {
+		name  string
+		style Style
+		mode  ColorMode
+		want  string
+	}{
+		{name: "default", style: Style{}, mode: TrueColor, want: "\033[0m"},
+		{name: "true colour", style: Style{Fg: RGB(1, 2, 3)}, mode: TrueColor, want: "\033[0;38;2;1;2;3m"},
+		{name: "rgb in 256 colours", style: Style{Fg: RGB(0x87, 0xaf, 0x5f)}, mode: Color256, want: "\033[0;38;5;107m"},
+		{name: "indexed in true colour", style: Style{Fg: Indexed(244)}, mode: TrueColor, want: "\033[0;38;5;244m"},
+		{name: "background and attributes", style: Style{Bg: Indexed(4), Attr: Bold | Underline}, mode: Color256, want: "\033[0;1;4;48;5;4m"},
+		{name: "gray", style: Style{Fg: RGB(0x80, 0x80, 0x80)}, mode: Color256, want: "\033[0;38;5;244m"},
+		{name: "16 colours", style: Style{Fg: Indexed(1), Bg: Indexed(12)}, mode: Color16, want: "\033[0;31;104m"},
+		{name: "rgb in 16 colours", style: Style{Fg: RGB(250, 250, 250)}, mode: Color16, want: "\033[0;97m"},
+		{name: "256 colours in 16 colours", style: Style{Fg: Indexed(160)}, mode: Color16, want: "\033[0;31m"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.style.Sequence(tt.mode); got != tt.want {
+				t.Errorf("Sequence() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectColorMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorTerm string
+		term      string
+		want      ColorMode
+	}{
+		{name: "truecolor", colorTerm: "truecolor", term: "xterm-256color", want: TrueColor},
+		{name: "24bit", colorTerm: "24bit", term: "xterm", want: TrueColor},
+		{name: "direct colour terminfo", term: "xterm-direct", want: TrueColor},
+		{name: "256 colours", term: "screen-256color", want: Color256},
+		{name: "colorterm without depth", colorTerm: "rxvt-xpm", term: "rxvt", want: Color256},
+		{name: "xterm", term: "xterm", want: Color16},
+		{name: "linux console", term: "linux", want: Color16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"COLORTERM": tt.colorTerm, "TERM": tt.term}
+			if got := DetectColorMode(func(k string) string { return env[k] }); got != tt.want {
+				t.Errorf("DetectColorMode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package syntax
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+var extensions = map[string]string{
+	".go":       "go",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".json":     "json",
+	".yaml":     "yaml",
+	".yml":      "yaml",
+	".sh":       "sh",
+	".bash":     "sh",
+	".zsh":      "sh",
+	".ksh":      "sh",
+	".mk":       "make",
+	".mak":      "make",
+}
+
+var fileNames = map[string]string{
+	"Makefile":      "make",
+	"makefile":      "make",
+	"GNUmakefile":   "make",
+	".bashrc":       "sh",
+	".bash_profile": "sh",
+	".profile":      "sh",
+	".zshrc":        "sh",
+}
+
+var interpreters = map[string]string{
+	"sh":   "sh",
+	"bash": "sh",
+	"dash": "sh",
+	"ksh":  "sh",
+	"zsh":  "sh",
+	"make": "make",
+}
+
+// Detect returns the file type of fileName, ex) go, markdown.
+// When the name says nothing, the shebang in firstLine is used.
+// It returns an empty string for unknown files.
+func Detect(fileName string, firstLine []byte) string {
+	base := filepath.Base(fileName)
+	if ft, ok := fileNames[base]; ok {
+		return ft
+	}
+	if ft, ok := extensions[strings.ToLower(filepath.Ext(base))]; ok {
+		return ft
+	}
+	return detectShebang(string(firstLine))
+}
+
+func detectShebang(line string) string {
+	if !strings.HasPrefix(line, "#!") {
+		return ""
+	}
+	fields := strings.Fields(line[2:])
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		for _, f := range fields[1:] {
+			// skip env options, ex) #!/usr/bin/env -S bash -e
+			if !strings.HasPrefix(f, "-") {
+				interpreter = filepath.Base(f)
+				break
+			}
+		}
+	}
+	return interpreters[interpreter]
+}
@@ -0,0 +1,36 @@
+package syntax
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileName  string
+		firstLine string
+		want      string
+	}{
+		{name: "go", fileName: "window/window.go", want: "go"},
+		{name: "markdown", fileName: "README.md", want: "markdown"},
+		{name: "markdown upper case extension", fileName: "NOTES.MARKDOWN", want: "markdown"},
+		{name: "json", fileName: "package.json", want: "json"},
+		{name: "yaml", fileName: ".github/workflows/ci.yml", want: "yaml"},
+		{name: "shell extension", fileName: "build.sh", want: "sh"},
+		{name: "makefile", fileName: "/src/Makefile", want: "make"},
+		{name: "make include", fileName: "rules.mk", want: "make"},
+		{name: "bashrc", fileName: "/home/bob/.bashrc", want: "sh"},
+		{name: "shebang", fileName: "configure", firstLine: "#!/bin/bash", want: "sh"},
+		{name: "shebang with env", fileName: "run", firstLine: "#!/usr/bin/env zsh", want: "sh"},
+		{name: "shebang with env options", fileName: "run", firstLine: "#!/usr/bin/env -S bash -e", want: "sh"},
+		{name: "make shebang", fileName: "build", firstLine: "#!/usr/bin/make -f", want: "make"},
+		{name: "extension wins over shebang", fileName: "main.go", firstLine: "#!/bin/sh", want: "go"},
+		{name: "unknown shebang", fileName: "script", firstLine: "#!/usr/bin/python3", want: ""},
+		{name: "unknown", fileName: "test.txt", firstLine: "11111", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.fileName, []byte(tt.firstLine)); got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package syntax
+
+type lineCache struct {
+	valid  bool
+	start  State
+	end    State
+	tokens []Token
+}
+
+// Highlighter tokenizes the lines of a buffer with a grammar and caches the result per line.
+// Each line remembers the state it started in, so after an edit only the changed lines,
+// and the lines whose start state changed because of them, are tokenized again.
+type Highlighter struct {
+	grammar *Grammar
+	cache   []lineCache
+	// cache[:clean] is known to be consistent with the lines.
+	clean int
+	// tokenized counts the lines tokenized so far.
+	tokenized int
+}
+
+func NewHighlighter(g *Grammar) *Highlighter {
+	return &Highlighter{grammar: g}
+}
+
+// Invalidate marks line i (0-indexed) as changed.
+func (h *Highlighter) Invalidate(i int) {
+	if i < len(h.cache) {
+		h.cache[i].valid = false
+	}
+	if i < h.clean {
+		h.clean = i
+	}
+}
+
+// Reset forgets all lines, ex) when the whole buffer was replaced.
+func (h *Highlighter) Reset() {
+	h.cache = nil
+	h.clean = 0
+}
+
+// InsertLines tells the highlighter that n lines were inserted before line i.
+func (h *Highlighter) InsertLines(i, n int) {
+	if i > len(h.cache) {
+		i = len(h.cache)
+	}
+	h.cache = append(h.cache[:i], append(make([]lineCache, n), h.cache[i:]...)...)
+	if i < h.clean {
+		h.clean = i
+	}
+}
+
+// DeleteLines tells the highlighter that n lines starting at line i were deleted.
+func (h *Highlighter) DeleteLines(i, n int) {
+	if i >= len(h.cache) {
+		return
+	}
+	if i+n > len(h.cache) {
+		n = len(h.cache) - i
+	}
+	// The line after the deleted ones is unchanged, it is only re-checked against its new start state.
+	h.cache = append(h.cache[:i], h.cache[i+n:]...)
+	if i < h.clean {
+		h.clean = i
+	}
+}
+
+// Tokens returns the tokens of lines[i], bringing the cache up to date as far as line i.
+func (h *Highlighter) Tokens(lines [][]byte, i int) []Token {
+	if i < 0 || i >= len(lines) {
+		return nil
+	}
+	h.update(lines, i)
+	return h.cache[i].tokens
+}
+
+// EndState returns the lexer state at the end of lines[i].
+func (h *Highlighter) EndState(lines [][]byte, i int) State {
+	if i < 0 || i >= len(lines) {
+		return rootState
+	}
+	h.update(lines, i)
+	return h.cache[i].end
+}
+
+func (h *Highlighter) update(lines [][]byte, i int) {
+	if len(h.cache) != len(lines) {
+		// The caller did not report the change precisely, start over.
+		h.cache = make([]lineCache, len(lines))
+		h.clean = 0
+	}
+	for ; h.clean <= i; h.clean++ {
+		start := rootState
+		if h.clean > 0 {
+			start = h.cache[h.clean-1].end
+		}
+		c := &h.cache[h.clean]
+		if c.valid && c.start == start {
+			continue
+		}
+		c.tokens, c.end = h.grammar.Tokenize(lines[h.clean], start)
+		c.start = start
+		c.valid = true
+		h.tokenized++
+	}
+}
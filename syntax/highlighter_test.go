@@ -0,0 +1,138 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func goLines(lines ...string) [][]byte {
+	var bs [][]byte
+	for _, l := range lines {
+		bs = append(bs, []byte(l))
+	}
+	return bs
+}
+
+func TestHighlighter_Tokens(t *testing.T) {
+	lines := goLines("/* a", "b", "c */ x", "var y")
+	tests := []struct {
+		name string
+		line int
+		want []string
+	}{
+		{name: "comment start", line: 0, want: []string{"Comment:/* a"}},
+		{name: "inside comment", line: 1, want: []string{"Comment:b"}},
+		{name: "comment end", line: 2, want: []string{"Comment:c */"}},
+		{name: "after comment", line: 3, want: []string{"Keyword:var"}},
+		{name: "out of range", line: 4, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHighlighter(goGrammar)
+			got := h.Tokens(lines, tt.line)
+			var text string
+			if tt.line < len(lines) {
+				text = string(lines[tt.line])
+			}
+			if s := spans(text, got); !reflect.DeepEqual(s, tt.want) {
+				t.Errorf("Tokens() = %q, want %q", s, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlighter_Incremental(t *testing.T) {
+	tests := []struct {
+		name          string
+		edit          func(lines [][]byte, h *Highlighter) [][]byte
+		wantTokenized int
+		wantLast      []string
+	}{
+		{
+			name: "edit without state change re-highlights one line",
+			edit: func(lines [][]byte, h *Highlighter) [][]byte {
+				lines[1] = []byte("x := 2")
+				h.Invalidate(1)
+				return lines
+			},
+			wantTokenized: 1,
+			wantLast:      []string{"Keyword:return"},
+		},
+		{
+			name: "opening a comment re-highlights the following lines",
+			edit: func(lines [][]byte, h *Highlighter) [][]byte {
+				lines[1] = []byte("x := 1 /*")
+				h.Invalidate(1)
+				return lines
+			},
+			wantTokenized: 3,
+			wantLast:      []string{"Comment:return"},
+		},
+		{
+			name: "inserted line is highlighted alone",
+			edit: func(lines [][]byte, h *Highlighter) [][]byte {
+				lines = append(lines[:2], append([][]byte{[]byte("y := 3")}, lines[2:]...)...)
+				h.InsertLines(2, 1)
+				return lines
+			},
+			wantTokenized: 1,
+			wantLast:      []string{"Keyword:return"},
+		},
+		{
+			name: "deleting a line re-checks the next line only",
+			edit: func(lines [][]byte, h *Highlighter) [][]byte {
+				lines = append(lines[:1], lines[2:]...)
+				h.DeleteLines(1, 1)
+				return lines
+			},
+			wantTokenized: 0,
+			wantLast:      []string{"Keyword:return"},
+		},
+		{
+			name: "unreported change starts over",
+			edit: func(lines [][]byte, h *Highlighter) [][]byte {
+				return append(lines, []byte("return"))
+			},
+			wantTokenized: 5,
+			wantLast:      []string{"Keyword:return"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := goLines("func f() {", "x := 1", "y := 2", "return")
+			h := NewHighlighter(goGrammar)
+			h.Tokens(lines, len(lines)-1)
+			before := h.tokenized
+			lines = tt.edit(lines, h)
+			last := len(lines) - 1
+			got := h.Tokens(lines, last)
+			if n := h.tokenized - before; n != tt.wantTokenized {
+				t.Errorf("tokenized %d lines, want %d", n, tt.wantTokenized)
+			}
+			if s := spans(string(lines[last]), got); !reflect.DeepEqual(s, tt.wantLast) {
+				t.Errorf("Tokens() = %q, want %q", s, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestHighlighter_EndState(t *testing.T) {
+	lines := goLines("a := `x", "y")
+	tests := []struct {
+		name string
+		line int
+		want State
+	}{
+		{name: "raw string open", line: 0, want: "rawstring"},
+		{name: "raw string still open", line: 1, want: "rawstring"},
+		{name: "out of range", line: 2, want: rootState},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHighlighter(goGrammar)
+			if got := h.EndState(lines, tt.line); got != tt.want {
+				t.Errorf("EndState() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package syntax
+
+// IndentOptions are the options an Indenter works with.
+type IndentOptions struct {
+	ShiftWidth int // the columns of a level of indent
+	TabStop    int // the columns of a tab
+}
+
+// Indenter computes the indent of the lines of a file type.
+type Indenter interface {
+	// Start returns an IndentScanner at the first line of a file.
+	Start(o IndentOptions) IndentScanner
+	// Reindents reports whether line, as typed so far, is indented again, ex) a Go line starting with }
+	Reindents(line []byte) bool
+}
+
+// IndentScanner computes the indents of the lines of a file from the top, it keeps what it needs
+// of the lines above, ex) the brackets open, so indenting many lines reads each of them once.
+type IndentScanner interface {
+	// Indent returns the indent in columns of line, the one after the lines scanned.
+	// It returns -1 when the line keeps its indent, ex) inside a raw string.
+	Indent(line []byte) int
+	// Scan moves past line, with the indent it has now.
+	Scan(line []byte)
+	// Copy returns a scanner at the same line, which scans on without changing this one.
+	Copy() IndentScanner
+}
+
+// Indent returns the indent of lines[i] by in, from the lines above it.
+func Indent(in Indenter, lines [][]byte, i int, o IndentOptions) int {
+	s := in.Start(o)
+	for _, l := range lines[:i] {
+		s.Scan(l)
+	}
+	return s.Indent(lines[i])
+}
+
+// IndentCache keeps the IndentScanner of a buffer after each of its lines, so the indent of a line,
+// ex) one opened by o, is computed from the scanner of the line above instead of from the top.
+// After an edit the scanners from the changed line on are scanned again when they are needed.
+type IndentCache struct {
+	indenter Indenter
+	options  IndentOptions
+	// scanners[i] has scanned the lines up to line i (0-indexed)
+	scanners []IndentScanner
+	// scanned counts the lines scanned so far.
+	scanned int
+}
+
+func NewIndentCache(in Indenter) *IndentCache {
+	return &IndentCache{indenter: in}
+}
+
+// Invalidate marks line i (0-indexed) as changed, with the lines after it.
+func (c *IndentCache) Invalidate(i int) {
+	if i < len(c.scanners) {
+		c.scanners = c.scanners[:i]
+	}
+}
+
+// Reset forgets all lines, ex) when the whole buffer was replaced.
+func (c *IndentCache) Reset() {
+	c.scanners = nil
+}
+
+// Indent returns the indent of lines[i], scanning the lines above it that were not scanned yet.
+// It returns -1 when the line keeps its indent, as IndentScanner does.
+func (c *IndentCache) Indent(lines [][]byte, i int, o IndentOptions) int {
+	if o != c.options || len(c.scanners) > len(lines) {
+		// the lines scanned are indented with other options, or the change was not reported
+		c.options = o
+		c.scanners = nil
+	}
+	for len(c.scanners) < i {
+		var s IndentScanner
+		if n := len(c.scanners); n > 0 {
+			s = c.scanners[n-1].Copy()
+		} else {
+			s = c.indenter.Start(o)
+		}
+		s.Scan(lines[len(c.scanners)])
+		c.scanners = append(c.scanners, s)
+		c.scanned++
+	}
+	if i == 0 {
+		return c.indenter.Start(o).Indent(lines[0])
+	}
+	return c.scanners[i-1].Indent(lines[i])
+}
+
+var indenters = map[string]Indenter{
+	"go": goIndenter{},
+}
+
+// LookupIndenter returns the Indenter for fileType, or nil if there is none.
+func LookupIndenter(fileType string) Indenter {
+	return indenters[fileType]
+}
+
+// IndentWidth returns the columns the blanks at the start of line take.
+func IndentWidth(line []byte, tabStop int) int {
+	col := 0
+	for _, c := range line {
+		switch c {
+		case ' ':
+			col++
+		case '\t':
+			col += tabStop - col%tabStop
+		default:
+			return col
+		}
+	}
+	return col
+}
+
+// code returns line with the text of the comments and the strings among tokens blanked out,
+// so only the code is left to look at, ex) a { in a string does not open a block.
+func code(line []byte, tokens []Token) []byte {
+	c := append([]byte(nil), line...)
+	for _, t := range tokens {
+		switch t.Group {
+		case "Comment", "String", "Character":
+			for i := t.Start; i < t.End; i++ {
+				c[i] = ' '
+			}
+		}
+	}
+	return c
+}
@@ -0,0 +1,176 @@
+package syntax
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGoIndenter_Indent(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines string // the line to indent is the last one
+		want  int
+	}{
+		{name: "top level", lines: "package main\nfunc main() {}\n", want: 0},
+		{name: "after {", lines: "func main() {\n", want: 4},
+		{name: "nested", lines: "func main() {\n    if ok {\n", want: 8},
+		{name: "statement", lines: "func main() {\n    a := 1\n", want: 4},
+		{name: "closing", lines: "func main() {\n    a := 1\n}", want: 0},
+		{name: "closing a nested block", lines: "func main() {\n    if ok {\n        a()\n    }\n", want: 4},
+		{name: "arguments", lines: "func main() {\n    f(a,\n", want: 8},
+		{name: "closing paren", lines: "func main() {\n    f(a,\n        b,\n)", want: 4},
+		{name: "brackets on one line", lines: "func main() {\n    go func() {\n        a()\n    }()\n", want: 4},
+		{name: "case", lines: "switch a {\ncase 1:\n", want: 4},
+		{name: "next case", lines: "switch a {\ncase 1:\n    f()\ndefault:", want: 0},
+		{name: "statement of a case", lines: "switch a {\ncase 1:\n    f()\n", want: 4},
+		{name: "closing a switch", lines: "switch a {\ncase 1:\n    f()\n}", want: 0},
+		{name: "bracket in a string", lines: "func main() {\n    s := \"{\"\n", want: 4},
+		{name: "bracket in a comment", lines: "func main() {\n    // }\n", want: 4},
+		{name: "tabs", lines: "func main() {\n\tif ok {\n", want: 12},
+		{name: "raw string", lines: "s := `\n  text\n", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var lines [][]byte
+			for _, l := range strings.Split(tt.lines, "\n") {
+				lines = append(lines, []byte(l))
+			}
+			got := Indent(LookupIndenter("go"), lines, len(lines)-1, IndentOptions{ShiftWidth: 4, TabStop: 8})
+			if got != tt.want {
+				t.Errorf("got: %d, want: %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGoIndenter_Start(t *testing.T) {
+	// the lines are indented one after the other, each from the ones above as they are indented
+	lines := strings.Split("func main() {\nif ok {\ns := `\n  raw\n`\n}\nswitch a {\ncase 1:\nf(a,\nb)\n}\n}", "\n")
+	want := []string{"func main() {", "\tif ok {", "\t\ts := `", "  raw", "`", "\t}", "\tswitch a {", "\tcase 1:", "\t\tf(a,", "\t\t\tb)", "\t}", "}"}
+	s := LookupIndenter("go").Start(IndentOptions{ShiftWidth: 8, TabStop: 8})
+	var got []string
+	for _, l := range lines {
+		line := strings.TrimLeft(l, " \t")
+		if n := s.Indent([]byte(line)); n >= 0 {
+			line = strings.Repeat("\t", n/8) + line
+		} else {
+			line = l
+		}
+		s.Scan([]byte(line))
+		got = append(got, line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestIndentCache_Indent(t *testing.T) {
+	tests := []struct {
+		name        string
+		edit        func(lines [][]byte, c *IndentCache) [][]byte
+		line        int
+		wantScanned int
+		want        int
+	}{
+		{
+			name:        "line below the ones scanned",
+			edit:        func(lines [][]byte, c *IndentCache) [][]byte { return lines },
+			line:        4,
+			wantScanned: 1,
+			want:        0,
+		},
+		{
+			name: "edit scans again from the changed line",
+			edit: func(lines [][]byte, c *IndentCache) [][]byte {
+				lines[2] = []byte("\tif ok {")
+				c.Invalidate(2)
+				return lines
+			},
+			line:        3,
+			wantScanned: 1,
+			want:        16,
+		},
+		{
+			name: "edit below the line scans nothing",
+			edit: func(lines [][]byte, c *IndentCache) [][]byte {
+				c.Invalidate(4)
+				return lines
+			},
+			line:        3,
+			wantScanned: 0,
+			want:        8,
+		},
+		{
+			name: "unreported change starts over",
+			edit: func(lines [][]byte, c *IndentCache) [][]byte {
+				return lines[:2]
+			},
+			line:        1,
+			wantScanned: 1,
+			want:        8,
+		},
+	}
+	o := IndentOptions{ShiftWidth: 8, TabStop: 8}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := goLines("func f() {", "\tx := 1", "\ty := 2", "", "}")
+			c := NewIndentCache(LookupIndenter("go"))
+			c.Indent(lines, 3, o)
+			before := c.scanned
+			lines = tt.edit(lines, c)
+			got := c.Indent(lines, tt.line, o)
+			if n := c.scanned - before; n != tt.wantScanned {
+				t.Errorf("got: %d lines scanned, want: %d", n, tt.wantScanned)
+			}
+			if got != tt.want {
+				t.Errorf("got: %d, want: %d", got, tt.want)
+			}
+			if want := Indent(LookupIndenter("go"), lines, tt.line, o); got != want {
+				t.Errorf("got: %d, want: %d as from the top", got, want)
+			}
+		})
+	}
+}
+
+func TestGoIndenter_Reindents(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{line: "\t}", want: true},
+		{line: "\t)", want: true},
+		{line: "\tcase 1:", want: true},
+		{line: "\tdefault:", want: true},
+		{line: "\tcase 1", want: false},
+		{line: "\t}()", want: false},
+		{line: "\ta", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := (goIndenter{}).Reindents([]byte(tt.line)); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndentWidth(t *testing.T) {
+	tests := []struct {
+		line string
+		want int
+	}{
+		{line: "a", want: 0},
+		{line: "  a", want: 2},
+		{line: "\ta", want: 8},
+		{line: "  \ta", want: 8},
+		{line: "\t  ", want: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := IndentWidth([]byte(tt.line), 8); got != tt.want {
+				t.Errorf("got: %d, want: %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package syntax
+
+import (
+	"bytes"
+	"strings"
+)
+
+var goGrammar = &Grammar{
+	Name: "go",
+	States: map[State][]Rule{
+		rootState: {
+			rule(`//.*`, "Comment"),
+			next(rule(`/\*`, "Comment"), "comment"),
+			next(rule("`", "String"), "rawstring"),
+			rule(`"(?:[^"\\]|\\.)*"?`, "String"),
+			rule(`'(?:[^'\\]|\\.)*'?`, "Character"),
+			rule(`(?:0[xX][0-9a-fA-F_]+|0[bB][01_]+|0[oO][0-7_]+|(?:\d[\d_]*(?:\.[\d_]*)?|\.\d[\d_]*)(?:[eE][+-]?\d+)?)i?`, "Number"),
+			rule(`(func)(\s+)([\p{L}_][\p{L}\p{N}_]*)`, "", "Keyword", "", "Function"),
+			rule(`(?:break|case|chan|const|continue|default|defer|else|fallthrough|for|func|go|goto|if|import|interface|map|package|range|return|select|struct|switch|type|var)\b`, "Keyword"),
+			rule(`(?:bool|byte|complex64|complex128|error|float32|float64|int|int8|int16|int32|int64|rune|string|uint|uint8|uint16|uint32|uint64|uintptr)\b`, "Type"),
+			rule(`(?:true|false|nil|iota)\b`, "Constant"),
+			rule(`(?:append|cap|close|complex|copy|delete|imag|len|make|new|panic|print|println|real|recover)\b`, "Function"),
+			rule(`[\p{L}_][\p{L}\p{N}_]*`, ""),
+			rule(`\s+`, ""),
+		},
+		"comment": {
+			next(rule(`.*?\*/`, "Comment"), rootState),
+			rule(`.+`, "Comment"),
+		},
+		"rawstring": {
+			next(rule("[^`]*`", "String"), rootState),
+			rule(".+", "String"),
+		},
+	},
+}
+
+// goIndenter indents Go as gofmt does: a level inside each bracket, and a closing bracket
+// or a case at the level of the line that opened the block.
+type goIndenter struct{}
+
+func (goIndenter) Start(o IndentOptions) IndentScanner {
+	return &goIndentScanner{options: o, state: rootState}
+}
+
+// goIndentScanner is where goIndenter is in a file.
+type goIndentScanner struct {
+	options IndentOptions
+	blocks  []int // the indent of the lines of the brackets open
+	state   State // the state of the lexer at the end of the lines scanned
+}
+
+func (s *goIndentScanner) Indent(line []byte) int {
+	if s.state != rootState {
+		return -1
+	}
+	if len(s.blocks) == 0 {
+		return 0
+	}
+	tokens, _ := goGrammar.Tokenize(line, s.state)
+	c := bytes.TrimLeft(code(line, tokens), " \t")
+	if len(c) > 0 && strings.IndexByte("})]", c[0]) >= 0 || isGoCase(c) {
+		return s.blocks[len(s.blocks)-1]
+	}
+	return s.blocks[len(s.blocks)-1] + s.options.ShiftWidth
+}
+
+func (s *goIndentScanner) Scan(line []byte) {
+	tokens, end := goGrammar.Tokenize(line, s.state)
+	indent := IndentWidth(line, s.options.TabStop)
+	for _, b := range code(line, tokens) {
+		switch b {
+		case '{', '(', '[':
+			s.blocks = append(s.blocks, indent)
+		case '}', ')', ']':
+			if len(s.blocks) > 0 {
+				s.blocks = s.blocks[:len(s.blocks)-1]
+			}
+		}
+	}
+	s.state = end
+}
+
+func (s *goIndentScanner) Copy() IndentScanner {
+	c := *s
+	c.blocks = append([]int(nil), s.blocks...)
+	return &c
+}
+
+func (goIndenter) Reindents(line []byte) bool {
+	c := bytes.TrimSpace(line)
+	return len(c) == 1 && strings.IndexByte("})]", c[0]) >= 0 || isGoCase(c) && bytes.HasSuffix(c, []byte(":"))
+}
+
+// isGoCase reports whether the code of a line starts a case of a switch or a select.
+func isGoCase(c []byte) bool {
+	c = bytes.TrimLeft(c, " \t")
+	return bytes.HasPrefix(c, []byte("case ")) || bytes.HasPrefix(c, []byte("default:")) || bytes.HasPrefix(c, []byte("default "))
+}
@@ -0,0 +1,15 @@
+package syntax
+
+var jsonGrammar = &Grammar{
+	Name: "json",
+	States: map[State][]Rule{
+		rootState: {
+			rule(`("(?:[^"\\]|\\.)*")(\s*:)`, "", "Identifier", "Delimiter"),
+			rule(`"(?:[^"\\]|\\.)*"?`, "String"),
+			rule(`-?(?:0|[1-9]\d*)(?:\.\d+)?(?:[eE][+-]?\d+)?`, "Number"),
+			rule(`(?:true|false|null)\b`, "Constant"),
+			rule(`[{}\[\],:]`, "Delimiter"),
+			rule(`\s+`, ""),
+		},
+	},
+}
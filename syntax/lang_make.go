@@ -0,0 +1,17 @@
+package syntax
+
+var makeGrammar = &Grammar{
+	Name: "make",
+	States: map[State][]Rule{
+		rootState: {
+			rule(`#.*`, "Comment"),
+			bol(rule(`\s*(?:-?include|sinclude|define|endef|ifdef|ifndef|ifeq|ifneq|else|endif|export|unexport|override|vpath)\b`, "PreProc")),
+			bol(rule(`([A-Za-z_][\w.\-]*)(\s*(?:[:+?!]?=|::=))`, "", "Identifier", "Operator")),
+			bol(rule(`([^\s:=#][^:=#]*?)(\s*::?)(?:\s|$)`, "", "Function", "Operator")),
+			rule(`\$\([^)]*\)|\$\{[^}]*\}|\$[@<^+?*%$]|\$\w`, "Identifier"),
+			rule(`\\$`, "Special"),
+			rule(`[^$#\s\\]+|\\`, ""),
+			rule(`\s+`, ""),
+		},
+	},
+}
@@ -0,0 +1,28 @@
+package syntax
+
+var markdownGrammar = &Grammar{
+	Name: "markdown",
+	States: map[State][]Rule{
+		rootState: {
+			next(bol(rule("\\s*(?:```|~~~).*", "Special")), "fence"),
+			bol(rule(`#{1,6}(?:\s.*)?$`, "Title")),
+			bol(rule(`(?:=+|-{2,}|\*{3,}|_{3,})\s*$`, "Title")),
+			bol(rule(`\s*>.*`, "Comment")),
+			bol(rule(`\s*(?:[-*+]|\d+[.)])\s`, "Special")),
+			next(rule(`<!--`, "Comment"), "htmlcomment"),
+			rule("`[^`]+`", "String"),
+			rule(`\*\*[^*]+\*\*|__[^_]+__`, "Bold"),
+			rule(`\*[^*\s][^*]*\*|_[^_\s][^_]*_`, "Italic"),
+			rule(`!?\[[^\]]*\]\([^)]*\)`, "Underlined"),
+			rule("[^`*_\\[!<]+", ""),
+		},
+		"fence": {
+			next(bol(rule("\\s*(?:```|~~~)\\s*$", "Special")), rootState),
+			rule(`.+`, "String"),
+		},
+		"htmlcomment": {
+			next(rule(`.*?-->`, "Comment"), rootState),
+			rule(`.+`, "Comment"),
+		},
+	},
+}
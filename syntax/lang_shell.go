@@ -0,0 +1,29 @@
+package syntax
+
+var shellVariable = `\$\{[^}]*\}|\$[A-Za-z_]\w*|\$[#?@*$!0-9-]`
+
+var shellGrammar = &Grammar{
+	Name: "sh",
+	States: map[State][]Rule{
+		rootState: {
+			bol(rule(`#!.*`, "PreProc")),
+			rule(`#.*`, "Comment"),
+			rule(`'[^']*'?`, "String"),
+			next(rule(`"`, "String"), "dquote"),
+			rule(shellVariable, "Identifier"),
+			rule(`\$\(|`+"`[^`]*`?", "Special"),
+			rule(`\\.`, "Special"),
+			rule(`(?:if|then|else|elif|fi|case|esac|for|while|until|do|done|in|function|select|time)\b`, "Keyword"),
+			rule(`(?:alias|cd|declare|echo|eval|exec|exit|export|local|printf|read|readonly|return|set|shift|source|test|trap|unset)\b`, "Function"),
+			rule(`&&|\|\||[|;&<>]`, "Operator"),
+			rule("[^\\s'\"$#;&|<>()`\\\\][^\\s'\"$;&|<>()`\\\\]*", ""),
+			rule(`\s+`, ""),
+		},
+		"dquote": {
+			next(rule(`"`, "String"), rootState),
+			rule(`\\.`, "Special"),
+			rule(shellVariable, "Identifier"),
+			rule(`[^"\\$]+|\$`, "String"),
+		},
+	},
+}
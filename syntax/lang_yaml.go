@@ -0,0 +1,23 @@
+package syntax
+
+var yamlGrammar = &Grammar{
+	Name: "yaml",
+	States: map[State][]Rule{
+		rootState: {
+			bol(rule(`(?:---|\.\.\.)\s*$`, "PreProc")),
+			rule(`#.*`, "Comment"),
+			rule(`("(?:[^"\\]|\\.)*"|'(?:[^']|'')*'|[^\s#'"\[\]{},:&*!|>-][^#:]*?|-[^\s#:][^#:]*?)(\s*:)(?:\s|$)`, "", "Identifier", "Delimiter"),
+			rule(`-(?:\s|$)`, "Delimiter"),
+			rule(`[&*][\w\-]+`, "Special"),
+			rule(`!!?[\w\-]*`, "Type"),
+			rule(`"(?:[^"\\]|\\.)*"?`, "String"),
+			rule(`'(?:[^']|'')*'?`, "String"),
+			rule(`(?:true|false|null|yes|no|on|off|~)\s*$`, "Constant"),
+			rule(`[-+]?(?:\d[\d_]*(?:\.\d*)?(?:[eE][-+]?\d+)?|0x[0-9a-fA-F]+|\.inf|\.nan)\s*$`, "Number"),
+			rule(`[|>][-+]?\d*\s*$`, "Special"),
+			rule(`[\[\]{},]`, "Delimiter"),
+			rule(`[^\s\[\]{},]+`, ""),
+			rule(`\s+`, ""),
+		},
+	},
+}
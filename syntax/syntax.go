@@ -0,0 +1,129 @@
+package syntax
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// State is the lexer state carried from the end of one line to the start of the next,
+// ex) inside a block comment.
+type State string
+
+const rootState State = "root"
+
+// Token is a highlighted span [Start, End) of a line.
+// Group is the name of the highlight group, ex) Comment, String.
+type Token struct {
+	Start int
+	End   int
+	Group string
+}
+
+// Rule matches a token at the current position of a line.
+type Rule struct {
+	Pattern *regexp.Regexp
+	Group   string
+	// Groups colours the submatches of Pattern, the rest of the match uses Group.
+	Groups []string
+	// BOL restricts the rule to the beginning of a line.
+	BOL bool
+	// Next is the state entered after the match. Empty means stay in the current state.
+	Next State
+}
+
+// Grammar is a set of rules for each lexer state. Lines start in the "root" state.
+type Grammar struct {
+	Name   string
+	States map[State][]Rule
+}
+
+// rule builds a Rule whose pattern only matches at the current position.
+func rule(pattern, group string, groups ...string) Rule {
+	return Rule{Pattern: regexp.MustCompile(`^(?:` + pattern + `)`), Group: group, Groups: groups}
+}
+
+func bol(r Rule) Rule {
+	r.BOL = true
+	return r
+}
+
+func next(r Rule, s State) Rule {
+	r.Next = s
+	return r
+}
+
+// Tokenize splits line into tokens starting from state and returns the state at the end of the line.
+func (g *Grammar) Tokenize(line []byte, state State) ([]Token, State) {
+	if state == "" {
+		state = rootState
+	}
+	var tokens []Token
+	for pos := 0; pos < len(line); {
+		matched := false
+		for _, r := range g.States[state] {
+			if r.BOL && pos != 0 {
+				continue
+			}
+			loc := r.Pattern.FindSubmatchIndex(line[pos:])
+			if loc == nil || (loc[1] == 0 && (r.Next == "" || r.Next == state)) {
+				continue
+			}
+			tokens = appendToken(tokens, Token{Start: pos, End: pos + loc[1], Group: r.Group})
+			for i, group := range r.Groups {
+				if 2*i+3 >= len(loc) || loc[2*i+2] < 0 || group == r.Group {
+					continue
+				}
+				tokens = appendToken(tokens, Token{Start: pos + loc[2*i+2], End: pos + loc[2*i+3], Group: group})
+			}
+			if r.Next != "" {
+				state = r.Next
+			}
+			pos += loc[1]
+			matched = true
+			break
+		}
+		if !matched {
+			_, size := utf8.DecodeRune(line[pos:])
+			pos += size
+		}
+	}
+	return tokens, state
+}
+
+// appendToken adds t to tokens. A token overlapping the tail of the last one splits it,
+// which is how submatch groups are laid over the whole match.
+func appendToken(tokens []Token, t Token) []Token {
+	if t.Start >= t.End {
+		return tokens
+	}
+	n := len(tokens)
+	if n > 0 && tokens[n-1].End > t.Start {
+		last := tokens[n-1]
+		tokens = tokens[:n-1]
+		tokens = appendToken(tokens, Token{Start: last.Start, End: t.Start, Group: last.Group})
+		tokens = appendToken(tokens, t)
+		return appendToken(tokens, Token{Start: t.End, End: last.End, Group: last.Group})
+	}
+	if t.Group == "" {
+		return tokens
+	}
+	if n > 0 && tokens[n-1].End == t.Start && tokens[n-1].Group == t.Group {
+		tokens[n-1].End = t.End
+		return tokens
+	}
+	return append(tokens, t)
+}
+
+var grammars = map[string]*Grammar{
+	"go":       goGrammar,
+	"markdown": markdownGrammar,
+	"json":     jsonGrammar,
+	"yaml":     yamlGrammar,
+	"sh":       shellGrammar,
+	"make":     makeGrammar,
+}
+
+// Lookup returns the grammar for fileType, or nil if there is none.
+func Lookup(fileType string) *Grammar {
+	return grammars[fileType]
+}
@@ -0,0 +1,208 @@
+package syntax
+
+import (
+	"reflect"
+	"testing"
+)
+
+// spans returns the tokens of line as "Group:text" for readable comparisons.
+func spans(line string, tokens []Token) []string {
+	var got []string
+	for _, t := range tokens {
+		got = append(got, t.Group+":"+line[t.Start:t.End])
+	}
+	return got
+}
+
+func TestGrammar_Tokenize(t *testing.T) {
+	tests := []struct {
+		name      string
+		fileType  string
+		line      string
+		state     State
+		want      []string
+		wantState State
+	}{
+		{
+			name:      "go func declaration",
+			fileType:  "go",
+			line:      "func main() {",
+			want:      []string{"Keyword:func", "Function:main"},
+			wantState: rootState,
+		},
+		{
+			name:      "go keywords, types and strings",
+			fileType:  "go",
+			line:      `var s string = "a\"b" // note`,
+			want:      []string{"Keyword:var", "Type:string", `String:"a\"b"`, "Comment:// note"},
+			wantState: rootState,
+		},
+		{
+			name:      "go identifier containing a keyword",
+			fileType:  "go",
+			line:      "iffy := 0x1F",
+			want:      []string{"Number:0x1F"},
+			wantState: rootState,
+		},
+		{
+			name:      "go block comment opens",
+			fileType:  "go",
+			line:      "x := 1 /* start",
+			want:      []string{"Number:1", "Comment:/* start"},
+			wantState: "comment",
+		},
+		{
+			name:      "go block comment closes",
+			fileType:  "go",
+			line:      "end */ return nil",
+			state:     "comment",
+			want:      []string{"Comment:end */", "Keyword:return", "Constant:nil"},
+			wantState: rootState,
+		},
+		{
+			name:      "go raw string spans lines",
+			fileType:  "go",
+			line:      "s := `raw",
+			want:      []string{"String:`raw"},
+			wantState: "rawstring",
+		},
+		{
+			name:      "markdown heading",
+			fileType:  "markdown",
+			line:      "## Usage",
+			want:      []string{"Title:## Usage"},
+			wantState: rootState,
+		},
+		{
+			name:      "markdown inline",
+			fileType:  "markdown",
+			line:      "use `gim` **now** or [docs](http://x)",
+			want:      []string{"String:`gim`", "Bold:**now**", "Underlined:[docs](http://x)"},
+			wantState: rootState,
+		},
+		{
+			name:      "markdown fence opens",
+			fileType:  "markdown",
+			line:      "```go",
+			want:      []string{"Special:```go"},
+			wantState: "fence",
+		},
+		{
+			name:      "markdown inside fence",
+			fileType:  "markdown",
+			line:      "# not a title",
+			state:     "fence",
+			want:      []string{"String:# not a title"},
+			wantState: "fence",
+		},
+		{
+			name:      "json object",
+			fileType:  "json",
+			line:      `{"a": [1.5, true, "x"]}`,
+			want:      []string{"Delimiter:{", `Identifier:"a"`, "Delimiter::", "Delimiter:[", "Number:1.5", "Delimiter:,", "Constant:true", "Delimiter:,", `String:"x"`, "Delimiter:]}"},
+			wantState: rootState,
+		},
+		{
+			name:      "yaml mapping",
+			fileType:  "yaml",
+			line:      "name: gim # editor",
+			want:      []string{"Identifier:name", "Delimiter::", "Comment:# editor"},
+			wantState: rootState,
+		},
+		{
+			name:      "yaml list of constants",
+			fileType:  "yaml",
+			line:      "- true",
+			want:      []string{"Delimiter:- ", "Constant:true"},
+			wantState: rootState,
+		},
+		{
+			name:      "yaml number value",
+			fileType:  "yaml",
+			line:      "port: 8080",
+			want:      []string{"Identifier:port", "Delimiter::", "Number:8080"},
+			wantState: rootState,
+		},
+		{
+			name:      "shell",
+			fileType:  "sh",
+			line:      `if [ -n "$HOME" ]; then echo a#b # c`,
+			want:      []string{"Keyword:if", `String:"`, "Identifier:$HOME", `String:"`, "Operator:;", "Keyword:then", "Function:echo", "Comment:# c"},
+			wantState: rootState,
+		},
+		{
+			name:      "shell shebang",
+			fileType:  "sh",
+			line:      "#!/bin/sh",
+			want:      []string{"PreProc:#!/bin/sh"},
+			wantState: rootState,
+		},
+		{
+			name:      "shell unterminated double quote",
+			fileType:  "sh",
+			line:      `msg="hello`,
+			want:      []string{`String:"hello`},
+			wantState: "dquote",
+		},
+		{
+			name:      "makefile target",
+			fileType:  "make",
+			line:      "build: $(SRC)",
+			want:      []string{"Function:build", "Operator::", "Identifier:$(SRC)"},
+			wantState: rootState,
+		},
+		{
+			name:      "makefile assignment",
+			fileType:  "make",
+			line:      "CC ?= gcc # compiler",
+			want:      []string{"Identifier:CC", "Operator: ?=", "Comment:# compiler"},
+			wantState: rootState,
+		},
+		{
+			name:      "makefile recipe",
+			fileType:  "make",
+			line:      "\tgo build -o $@",
+			want:      []string{"Identifier:$@"},
+			wantState: rootState,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := Lookup(tt.fileType)
+			if g == nil {
+				t.Fatalf("no grammar for %s", tt.fileType)
+			}
+			tokens, state := g.Tokenize([]byte(tt.line), tt.state)
+			if got := spans(tt.line, tokens); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize() = %q, want %q", got, tt.want)
+			}
+			if state != tt.wantState {
+				t.Errorf("state = %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileType string
+		wantNil  bool
+	}{
+		{name: "go", fileType: "go", wantNil: false},
+		{name: "markdown", fileType: "markdown", wantNil: false},
+		{name: "json", fileType: "json", wantNil: false},
+		{name: "yaml", fileType: "yaml", wantNil: false},
+		{name: "shell", fileType: "sh", wantNil: false},
+		{name: "makefile", fileType: "make", wantNil: false},
+		{name: "unknown", fileType: "cobol", wantNil: true},
+		{name: "empty", fileType: "", wantNil: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Lookup(tt.fileType); (got == nil) != tt.wantNil {
+				t.Errorf("Lookup(%q) = %v, want nil: %v", tt.fileType, got, tt.wantNil)
+			}
+		})
+	}
+}
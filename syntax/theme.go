@@ -0,0 +1,403 @@
+package syntax
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// group is a highlight group. Terminals with 256 colours or less use the cterm
+// settings, true colour terminals use the gui settings, and each falls back to the other.
+type group struct {
+	ctermFg, ctermBg Color
+	guiFg, guiBg     Color
+	cterm, gui       Attr
+	hasCterm, hasGui bool
+	link             string
+}
+
+// Theme holds the highlight groups used to draw the screen, ex) Comment, StatusLine.
+type Theme struct {
+	// Name is the name of the loaded colour scheme.
+	Name   string
+	groups map[string]*group
+}
+
+// NewTheme returns a theme with the groups of the default colour scheme.
+func NewTheme() *Theme {
+	t := &Theme{}
+	t.Clear()
+	return t
+}
+
+// Clear resets every group to the default colour scheme.
+func (t *Theme) Clear() {
+	t.Name = "default"
+	t.groups = map[string]*group{}
+	sc := bufio.NewScanner(strings.NewReader(schemes["default"]))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "highlight ") {
+			if _, err := t.Highlight(strings.TrimPrefix(line, "highlight ")); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// Groups returns the names of the defined groups in alphabetical order.
+func (t *Theme) Groups() []string {
+	names := make([]string, 0, len(t.groups))
+	for name := range t.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Style returns how the group name is drawn in mode, following links.
+func (t *Theme) Style(name string, mode ColorMode) Style {
+	g := t.lookup(name)
+	for i := 0; g != nil && g.link != "" && i < 100; i++ {
+		g = t.lookup(g.link)
+	}
+	if g == nil {
+		return Style{}
+	}
+	var s Style
+	if mode == TrueColor {
+		s.Fg, s.Bg = pick(g.guiFg, g.ctermFg), pick(g.guiBg, g.ctermBg)
+	} else {
+		s.Fg, s.Bg = pick(g.ctermFg, g.guiFg), pick(g.ctermBg, g.guiBg)
+	}
+	if (mode == TrueColor && g.hasGui) || !g.hasCterm {
+		s.Attr = g.gui
+	} else {
+		s.Attr = g.cterm
+	}
+	return s
+}
+
+func pick(c, fallback Color) Color {
+	if c.IsDefault() {
+		return fallback
+	}
+	return c
+}
+
+// lookup finds a group ignoring case, as :highlight does.
+func (t *Theme) lookup(name string) *group {
+	if g, ok := t.groups[name]; ok {
+		return g
+	}
+	for n, g := range t.groups {
+		if strings.EqualFold(n, name) {
+			return g
+		}
+	}
+	return nil
+}
+
+// Highlight executes the arguments of the :highlight command, ex) Comment ctermfg=244 guifg=#808080
+// Listing commands return the text to show.
+func (t *Theme) Highlight(args string) (string, error) {
+	fields := strings.Fields(args)
+	switch {
+	case len(fields) == 0:
+		var lines []string
+		for _, name := range t.Groups() {
+			lines = append(lines, t.describe(name))
+		}
+		return strings.Join(lines, "\n"), nil
+	case fields[0] == "clear":
+		if len(fields) == 1 {
+			t.Clear()
+			return "", nil
+		}
+		for _, name := range fields[1:] {
+			if g := t.lookup(name); g != nil {
+				*g = group{}
+			}
+		}
+		return "", nil
+	case fields[0] == "link" || fields[0] == "default" && len(fields) > 1 && fields[1] == "link":
+		if fields[0] == "default" {
+			fields = fields[1:]
+		}
+		if len(fields) != 3 {
+			return "", fmt.Errorf("E412: Not enough arguments: \":highlight link %s\"", strings.Join(fields[1:], " "))
+		}
+		g := t.define(fields[1])
+		if fields[2] == "NONE" {
+			g.link = ""
+		} else {
+			g.link = fields[2]
+		}
+		return "", nil
+	case len(fields) == 1:
+		if t.lookup(fields[0]) == nil {
+			return "", fmt.Errorf("E411: highlight group not found: %s", fields[0])
+		}
+		return t.describe(fields[0]), nil
+	}
+
+	name := fields[0]
+	attrs := group{}
+	if g := t.lookup(name); g != nil {
+		attrs = *g
+	}
+	// setting attributes breaks a link, as in vim
+	attrs.link = ""
+	for _, f := range fields[1:] {
+		if f == "NONE" {
+			attrs = group{}
+			continue
+		}
+		eq := strings.IndexByte(f, '=')
+		if eq < 0 {
+			return "", fmt.Errorf("E416: Missing equal sign: %s", f)
+		}
+		key, value := strings.ToLower(f[:eq]), f[eq+1:]
+		if value == "" {
+			return "", fmt.Errorf("E417: Missing argument: %s", f)
+		}
+		var err error
+		switch key {
+		case "ctermfg":
+			attrs.ctermFg, err = parseCtermColor(value)
+			attrs.hasCterm = true
+		case "ctermbg":
+			attrs.ctermBg, err = parseCtermColor(value)
+			attrs.hasCterm = true
+		case "guifg":
+			attrs.guiFg, err = parseGuiColor(value)
+			attrs.hasGui = true
+		case "guibg":
+			attrs.guiBg, err = parseGuiColor(value)
+			attrs.hasGui = true
+		case "cterm":
+			attrs.cterm, err = parseAttr(value)
+			attrs.hasCterm = true
+		case "gui":
+			attrs.gui, err = parseAttr(value)
+			attrs.hasGui = true
+		case "term", "start", "stop", "guisp", "font":
+			// accepted for compatibility with vim colour schemes
+		default:
+			err = fmt.Errorf("E423: Illegal argument: %s", f)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	*t.define(name) = attrs
+	return "", nil
+}
+
+func (t *Theme) define(name string) *group {
+	if g := t.lookup(name); g != nil {
+		return g
+	}
+	g := &group{}
+	t.groups[name] = g
+	return g
+}
+
+// describe returns a group in the form :highlight lists it.
+func (t *Theme) describe(name string) string {
+	g := t.lookup(name)
+	if g == nil {
+		return ""
+	}
+	desc := []string{fmt.Sprintf("%-15s xxx", name)}
+	if g.link != "" {
+		return desc[0] + " links to " + g.link
+	}
+	if g.hasCterm && g.cterm != 0 {
+		desc = append(desc, "cterm="+formatAttr(g.cterm))
+	}
+	if !g.ctermFg.IsDefault() {
+		desc = append(desc, fmt.Sprintf("ctermfg=%d", g.ctermFg.index256()))
+	}
+	if !g.ctermBg.IsDefault() {
+		desc = append(desc, fmt.Sprintf("ctermbg=%d", g.ctermBg.index256()))
+	}
+	if g.hasGui && g.gui != 0 {
+		desc = append(desc, "gui="+formatAttr(g.gui))
+	}
+	if !g.guiFg.IsDefault() {
+		desc = append(desc, "guifg="+formatRGB(g.guiFg))
+	}
+	if !g.guiBg.IsDefault() {
+		desc = append(desc, "guibg="+formatRGB(g.guiBg))
+	}
+	if len(desc) == 1 {
+		desc = append(desc, "cleared")
+	}
+	return strings.Join(desc, " ")
+}
+
+// colorNames are the colour names vim accepts, as indexes of the 16 colour palette.
+var colorNames = map[string]uint8{
+	"black": 0, "darkred": 1, "darkgreen": 2, "brown": 3, "darkyellow": 3,
+	"darkblue": 4, "darkmagenta": 5, "darkcyan": 6, "lightgray": 7, "lightgrey": 7,
+	"gray": 7, "grey": 7, "darkgray": 8, "darkgrey": 8, "red": 9, "lightred": 9,
+	"green": 10, "lightgreen": 10, "yellow": 11, "lightyellow": 11, "blue": 12,
+	"lightblue": 12, "magenta": 13, "lightmagenta": 13, "cyan": 14, "lightcyan": 14,
+	"white": 15,
+}
+
+func parseCtermColor(value string) (Color, error) {
+	if strings.EqualFold(value, "NONE") {
+		return 0, nil
+	}
+	if n, ok := colorNames[strings.ToLower(value)]; ok {
+		return Indexed(n), nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > 255 {
+		return 0, fmt.Errorf("E421: Color name or number not recognized: %s", value)
+	}
+	return Indexed(uint8(n)), nil
+}
+
+func parseGuiColor(value string) (Color, error) {
+	if strings.EqualFold(value, "NONE") {
+		return 0, nil
+	}
+	if n, ok := colorNames[strings.ToLower(value)]; ok {
+		c := ansiColors[n]
+		return RGB(uint8(c[0]), uint8(c[1]), uint8(c[2])), nil
+	}
+	if len(value) == 7 && value[0] == '#' {
+		if v, err := strconv.ParseUint(value[1:], 16, 32); err == nil {
+			return RGB(uint8(v>>16), uint8(v>>8), uint8(v)), nil
+		}
+	}
+	return 0, fmt.Errorf("E254: Cannot allocate color %s", value)
+}
+
+func formatRGB(c Color) string {
+	r, g, b := c.rgb()
+	return fmt.Sprintf("#%02x%02x%02x", r, g, b)
+}
+
+var attrNames = []struct {
+	attr Attr
+	name string
+}{{Bold, "bold"}, {Italic, "italic"}, {Underline, "underline"}, {Reverse, "reverse"}}
+
+func parseAttr(value string) (Attr, error) {
+	var a Attr
+	for _, name := range strings.Split(strings.ToLower(value), ",") {
+		switch name {
+		case "none":
+		case "inverse":
+			a |= Reverse
+		default:
+			found := false
+			for _, n := range attrNames {
+				if n.name == name {
+					a |= n.attr
+					found = true
+				}
+			}
+			if !found {
+				return 0, fmt.Errorf("E418: Illegal value: %s", value)
+			}
+		}
+	}
+	return a, nil
+}
+
+func formatAttr(a Attr) string {
+	var names []string
+	for _, n := range attrNames {
+		if a&n.attr != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// Scheme returns the source of a built-in colour scheme.
+func Scheme(name string) (string, bool) {
+	s, ok := schemes[name]
+	return s, ok
+}
+
+// SchemeNames returns the names of the built-in colour schemes.
+func SchemeNames() []string {
+	var names []string
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemes are the built-in colour schemes, written as ex commands like colour scheme files.
+var schemes = map[string]string{
+	"default": `" gim default colour scheme
+highlight Normal NONE
+highlight Comment ctermfg=244 guifg=#808080
+highlight String ctermfg=107 guifg=#87af5f
+highlight link Character String
+highlight Number ctermfg=173 guifg=#d7875f
+highlight Constant ctermfg=173 guifg=#d7875f
+highlight Keyword cterm=bold ctermfg=68 gui=bold guifg=#5f87d7
+highlight link Statement Keyword
+highlight Type ctermfg=73 guifg=#5fafaf
+highlight Function ctermfg=179 guifg=#d7af5f
+highlight Identifier ctermfg=140 guifg=#af87d7
+highlight PreProc ctermfg=168 guifg=#d75f87
+highlight Special ctermfg=180 guifg=#d7af87
+highlight Delimiter ctermfg=145 guifg=#afafaf
+highlight link Operator Delimiter
+highlight Title cterm=bold ctermfg=68 gui=bold guifg=#5f87d7
+highlight Bold cterm=bold gui=bold
+highlight Italic cterm=italic gui=italic
+highlight Underlined cterm=underline ctermfg=68 gui=underline guifg=#5f87d7
+highlight Todo cterm=bold ctermfg=0 ctermbg=179 gui=bold guifg=#000000 guibg=#d7af5f
+highlight Error ctermfg=15 ctermbg=160 guifg=#ffffff guibg=#d70000
+highlight StatusLine cterm=reverse,bold gui=reverse,bold
+highlight StatusLineNC cterm=reverse gui=reverse
+highlight Visual ctermbg=238 guibg=#444444
+highlight Search ctermfg=0 ctermbg=179 guifg=#000000 guibg=#d7af5f
+highlight IncSearch cterm=reverse gui=reverse
+highlight LineNr ctermfg=242 guifg=#6c6c6c
+highlight CursorLineNr cterm=bold ctermfg=179 gui=bold guifg=#d7af5f
+highlight NonText ctermfg=68 guifg=#5f87d7
+highlight SpecialKey ctermfg=81 guifg=#5fd7ff
+highlight MatchParen ctermbg=30 guibg=#008787
+highlight ErrorMsg ctermfg=15 ctermbg=160 guifg=#ffffff guibg=#d70000
+highlight WarningMsg ctermfg=160 guifg=#d70000
+highlight ModeMsg cterm=bold gui=bold
+highlight MoreMsg ctermfg=107 guifg=#87af5f
+highlight Pmenu ctermbg=236 guibg=#303030
+highlight PmenuSel ctermfg=0 ctermbg=179 guifg=#000000 guibg=#d7af5f
+highlight link WildMenu PmenuSel
+`,
+	"light": `" gim light colour scheme
+highlight clear
+highlight Normal ctermfg=235 ctermbg=255 guifg=#262626 guibg=#eeeeee
+highlight Comment ctermfg=244 guifg=#808080
+highlight String ctermfg=28 guifg=#008700
+highlight Number ctermfg=130 guifg=#af5f00
+highlight Constant ctermfg=130 guifg=#af5f00
+highlight Keyword cterm=bold ctermfg=25 gui=bold guifg=#005faf
+highlight Type ctermfg=30 guifg=#008787
+highlight Function ctermfg=94 guifg=#875f00
+highlight Identifier ctermfg=91 guifg=#8700af
+highlight PreProc ctermfg=161 guifg=#d7005f
+highlight Special ctermfg=130 guifg=#af5f00
+highlight Delimiter ctermfg=240 guifg=#585858
+highlight Title cterm=bold ctermfg=25 gui=bold guifg=#005faf
+highlight Visual ctermbg=252 guibg=#d0d0d0
+highlight Search ctermfg=235 ctermbg=222 guifg=#262626 guibg=#ffd787
+highlight LineNr ctermfg=246 guifg=#949494
+highlight Pmenu ctermbg=252 guibg=#d0d0d0
+`,
+}
@@ -0,0 +1,172 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTheme_Highlight(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		group   string
+		mode    ColorMode
+		want    Style
+		wantOut string
+		wantErr string
+	}{
+		{
+			name:  "default Comment",
+			group: "Comment",
+			mode:  Color256,
+			want:  Style{Fg: Indexed(244)},
+		},
+		{
+			name:  "set cterm colours",
+			args:  []string{"Comment ctermfg=1 ctermbg=darkblue cterm=bold,underline"},
+			group: "Comment",
+			mode:  Color256,
+			want:  Style{Fg: Indexed(1), Bg: Indexed(4), Attr: Bold | Underline},
+		},
+		{
+			name:  "gui colours in true colour",
+			args:  []string{"Comment guifg=#102030 gui=italic"},
+			group: "comment",
+			mode:  TrueColor,
+			want:  Style{Fg: RGB(0x10, 0x20, 0x30), Attr: Italic},
+		},
+		{
+			name:  "gui colours fall back to cterm colours",
+			args:  []string{"Foo ctermfg=3"},
+			group: "Foo",
+			mode:  TrueColor,
+			want:  Style{Fg: Indexed(3)},
+		},
+		{
+			name:  "cterm colours fall back to gui colours",
+			args:  []string{"Foo guifg=Red"},
+			group: "Foo",
+			mode:  Color256,
+			want:  Style{Fg: RGB(255, 0, 0)},
+		},
+		{
+			name:  "link",
+			args:  []string{"Foo ctermfg=5", "link Bar Foo"},
+			group: "Bar",
+			mode:  Color256,
+			want:  Style{Fg: Indexed(5)},
+		},
+		{
+			name:  "attributes break a link",
+			args:  []string{"link Character Comment", "Character ctermfg=6"},
+			group: "Character",
+			mode:  Color256,
+			want:  Style{Fg: Indexed(6)},
+		},
+		{
+			name:  "NONE",
+			args:  []string{"Comment NONE"},
+			group: "Comment",
+			mode:  Color256,
+			want:  Style{},
+		},
+		{
+			name:  "clear group",
+			args:  []string{"clear Comment"},
+			group: "Comment",
+			mode:  Color256,
+			want:  Style{},
+		},
+		{
+			name:  "clear everything",
+			args:  []string{"Comment ctermfg=1", "clear"},
+			group: "Comment",
+			mode:  Color256,
+			want:  Style{Fg: Indexed(244)},
+		},
+		{
+			name:    "describe",
+			args:    []string{"Comment ctermfg=1 guifg=#ff0000 gui=bold", "Comment"},
+			group:   "Comment",
+			mode:    Color256,
+			want:    Style{Fg: Indexed(1)},
+			wantOut: "Comment         xxx ctermfg=1 gui=bold guifg=#ff0000",
+		},
+		{
+			name:    "describe link",
+			args:    []string{"Character"},
+			group:   "Character",
+			mode:    Color256,
+			want:    Style{Fg: Indexed(107)},
+			wantOut: "Character       xxx links to String",
+		},
+		{name: "unknown group", args: []string{"Nothing"}, wantErr: "E411: highlight group not found: Nothing"},
+		{name: "illegal argument", args: []string{"Comment fg=1"}, wantErr: "E423: Illegal argument: fg=1"},
+		{name: "missing equal sign", args: []string{"Comment bold"}, wantErr: "E416: Missing equal sign: bold"},
+		{name: "bad colour", args: []string{"Comment ctermfg=300"}, wantErr: "E421: Color name or number not recognized: 300"},
+		{name: "bad gui colour", args: []string{"Comment guifg=#zz0000"}, wantErr: "E254: Cannot allocate color #zz0000"},
+		{name: "bad attribute", args: []string{"Comment cterm=blink"}, wantErr: "E418: Illegal value: blink"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			theme := NewTheme()
+			var out string
+			var err error
+			for _, a := range tt.args {
+				if out, err = theme.Highlight(a); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out != tt.wantOut {
+				t.Errorf("out = %q, want %q", out, tt.wantOut)
+			}
+			if got := theme.Style(tt.group, tt.mode); got != tt.want {
+				t.Errorf("Style() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTheme_HighlightList(t *testing.T) {
+	out, err := NewTheme().Highlight("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) != len(NewTheme().Groups()) {
+		t.Errorf("listed %d groups, want %d", len(lines), len(NewTheme().Groups()))
+	}
+	for _, group := range []string{"Normal", "Comment", "String", "Keyword", "StatusLine", "Visual", "Search", "LineNr"} {
+		if !strings.Contains(out, group+" ") {
+			t.Errorf("group %s is not listed", group)
+		}
+	}
+}
+
+func TestScheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		wantOk bool
+	}{
+		{name: "default", scheme: "default", wantOk: true},
+		{name: "light", scheme: "light", wantOk: true},
+		{name: "unknown", scheme: "solarized", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Scheme(tt.scheme); ok != tt.wantOk {
+				t.Errorf("Scheme(%q) ok = %v, want %v", tt.scheme, ok, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Package terminfo reads the compiled terminfo database, which describes
+// the escape sequences of terminals, see term(5) and terminfo(5).
+package terminfo
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	magicLegacy = 0432  // numbers are 16 bit
+	magic32bit  = 01036 // numbers are 32 bit
+)
+
+// Terminfo is the description of a terminal. Extended capabilities,
+// ex) Sync, are in the same maps as the standard ones.
+type Terminfo struct {
+	Names   []string // ex) xterm-256color, xterm with 256 colors
+	Bools   map[string]bool
+	Numbers map[string]int
+	Strings map[string]string
+}
+
+// ErrNotFound is returned when the terminal is not in the database.
+var ErrNotFound = errors.New("terminfo: terminal not found")
+
+// Dirs returns the directories searched for compiled entries, in order.
+func Dirs(getenv func(string) string) []string {
+	var dirs []string
+	if dir := getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home := getenv("HOME"); home != "" {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	if list := getenv("TERMINFO_DIRS"); list != "" {
+		for _, dir := range strings.Split(list, ":") {
+			if dir == "" {
+				// an empty entry means the system directory
+				dir = "/usr/share/terminfo"
+			}
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo")
+}
+
+// Load reads the entry of the terminal name, ex) $TERM, from the database.
+func Load(name string, getenv func(string) string) (*Terminfo, error) {
+	if name == "" || strings.Contains(name, "/") || name == "." || name == ".." {
+		return nil, ErrNotFound
+	}
+	for _, dir := range Dirs(getenv) {
+		// Linux uses the first letter, macOS its hexadecimal code.
+		for _, sub := range []string{name[:1], fmt.Sprintf("%x", name[0])} {
+			data, err := ioutil.ReadFile(filepath.Join(dir, sub, name))
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			return Parse(data)
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Parse decodes a compiled entry.
+func Parse(data []byte) (*Terminfo, error) {
+	r := &reader{data: data}
+	header := r.shorts(6)
+	if r.err != nil {
+		return nil, r.err
+	}
+	numberSize := 2
+	switch header[0] {
+	case magicLegacy:
+	case magic32bit:
+		numberSize = 4
+	default:
+		return nil, fmt.Errorf("terminfo: bad magic number %#o", header[0])
+	}
+	if err := checkHeader(header[1:]); err != nil {
+		return nil, err
+	}
+	namesSize, boolCount, numberCount, stringCount, tableSize := header[1], header[2], header[3], header[4], header[5]
+	if boolCount > len(boolNames) || numberCount > len(numberNames) || stringCount > len(stringNames) {
+		return nil, errors.New("terminfo: too many capabilities")
+	}
+	t := &Terminfo{
+		Bools:   make(map[string]bool),
+		Numbers: make(map[string]int),
+		Strings: make(map[string]string),
+	}
+	names := strings.TrimRight(string(r.bytes(namesSize)), "\x00")
+	t.Names = strings.Split(names, "|")
+	for i, b := range r.bytes(boolCount) {
+		if b == 1 {
+			t.Bools[boolNames[i]] = true
+		}
+	}
+	r.align()
+	for i, n := range r.numbers(numberCount, numberSize) {
+		if n >= 0 {
+			t.Numbers[numberNames[i]] = n
+		}
+	}
+	offsets := r.shorts(stringCount)
+	table := r.bytes(tableSize)
+	if r.err != nil {
+		return nil, r.err
+	}
+	for i, off := range offsets {
+		if s, ok := cString(table, off); ok {
+			t.Strings[stringNames[i]] = s
+		}
+	}
+	if r.pos < len(data) {
+		r.align()
+		if err := t.parseExtended(r, numberSize); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// parseExtended decodes the capabilities that are not in the standard list,
+// they are stored with their names after the standard ones.
+func (t *Terminfo) parseExtended(r *reader, numberSize int) error {
+	header := r.shorts(5)
+	if r.err != nil {
+		// some entries are padded at the end, there is nothing more
+		return nil
+	}
+	if err := checkHeader(header); err != nil {
+		return err
+	}
+	boolCount, numberCount, stringCount, tableSize := header[0], header[1], header[2], header[4]
+	bools := r.bytes(boolCount)
+	r.align()
+	numbers := r.numbers(numberCount, numberSize)
+	offsets := r.shorts(stringCount)
+	nameOffsets := r.shorts(boolCount + numberCount + stringCount)
+	table := r.bytes(tableSize)
+	if r.err != nil {
+		return r.err
+	}
+	// The names follow the values in the table.
+	base := 0
+	for _, off := range offsets {
+		if s, ok := cString(table, off); ok && off+len(s)+1 > base {
+			base = off + len(s) + 1
+		}
+	}
+	name := func(i int) string {
+		s, _ := cString(table, base+nameOffsets[i])
+		return s
+	}
+	for i, b := range bools {
+		if b == 1 {
+			t.Bools[name(i)] = true
+		}
+	}
+	for i, n := range numbers {
+		if n >= 0 {
+			t.Numbers[name(boolCount+i)] = n
+		}
+	}
+	for i, off := range offsets {
+		if s, ok := cString(table, off); ok {
+			t.Strings[name(boolCount+numberCount+i)] = s
+		}
+	}
+	return nil
+}
+
+// checkHeader returns an error when one of the counts and sizes of a header is negative,
+// ex) a corrupt entry
+func checkHeader(header []int) error {
+	for _, n := range header {
+		if n < 0 {
+			return errors.New("terminfo: bad header")
+		}
+	}
+	return nil
+}
+
+// cString returns the NUL terminated string at off of the string table.
+// Negative offsets mark absent or cancelled capabilities.
+func cString(table []byte, off int) (string, bool) {
+	if off < 0 || off >= len(table) {
+		return "", false
+	}
+	end := off
+	for end < len(table) && table[end] != 0 {
+		end++
+	}
+	return string(table[off:end]), true
+}
+
+// reader reads the little endian fields of a compiled entry.
+// After an error every read returns zero values and err is kept.
+type reader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+var errShort = errors.New("terminfo: entry is truncated")
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil || n < 0 || r.pos+n > len(r.data) {
+		r.err = errShort
+		return nil
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b
+}
+
+// shorts reads signed 16 bit values.
+func (r *reader) shorts(n int) []int {
+	return r.numbers(n, 2)
+}
+
+func (r *reader) numbers(n, size int) []int {
+	b := r.bytes(n * size)
+	if b == nil {
+		return nil
+	}
+	values := make([]int, n)
+	for i := range values {
+		if size == 2 {
+			values[i] = int(int16(binary.LittleEndian.Uint16(b[i*2:])))
+		} else {
+			values[i] = int(int32(binary.LittleEndian.Uint32(b[i*4:])))
+		}
+	}
+	return values
+}
+
+// align skips the padding byte that keeps the following fields on an even offset.
+func (r *reader) align() {
+	if r.pos%2 == 1 && r.pos < len(r.data) {
+		r.pos++
+	}
+}
+
+// Has reports whether the terminal has the string capability name.
+func (t *Terminfo) Has(name string) bool {
+	_, ok := t.Strings[name]
+	return ok
+}
+
+// Number returns the numeric capability name, or -1 when it is absent.
+func (t *Terminfo) Number(name string) int {
+	if n, ok := t.Numbers[name]; ok {
+		return n
+	}
+	return -1
+}
+
+// String returns the string capability name with params substituted, see Tparm.
+// Padding, ex) $<5>, is removed: terminals that need it are not supported.
+func (t *Terminfo) String(name string, params ...int) string {
+	s := stripPadding(t.Strings[name])
+	if len(params) == 0 && !strings.Contains(s, "%") {
+		return s
+	}
+	return Tparm(s, params...)
+}
+
+func stripPadding(s string) string {
+	for {
+		start := strings.Index(s, "$<")
+		if start < 0 {
+			return s
+		}
+		end := strings.IndexByte(s[start:], '>')
+		if end < 0 {
+			return s
+		}
+		s = s[:start] + s[start+end+1:]
+	}
+}
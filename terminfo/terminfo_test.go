@@ -0,0 +1,153 @@
+package terminfo
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		wantNames   []string
+		wantBools   map[string]bool
+		wantNumbers map[string]int
+		wantStrings map[string]string
+	}{
+		{
+			name:        "legacy format with extended capabilities",
+			file:        "testdata/g/gimterm",
+			wantNames:   []string{"gimterm", "terminal for the gim tests"},
+			wantBools:   map[string]bool{"am": true, "Tc": true, "bw": false},
+			wantNumbers: map[string]int{"colors": 8, "cols": 80, "lines": 24},
+			wantStrings: map[string]string{
+				"clear": "\033[H\033[J$<50>",
+				"kf1":   "\033[[A",
+				"sgr0":  "\033[m\017",
+				"Sync":  "\033[?2026%?%p1%{1}%-%tl%eh%;",
+				"XM":    "\033[?1000%?%p1%{1}%=%th%el%;",
+				"kDC3":  "\033[3;3~",
+			},
+		},
+		{
+			name:        "32 bit numbers",
+			file:        "testdata/x/xterm-256color",
+			wantNames:   []string{"xterm-256color", "xterm with 256 colors"},
+			wantBools:   map[string]bool{"am": true, "xenl": true, "XT": true},
+			wantNumbers: map[string]int{"colors": 256, "pairs": 65536},
+			wantStrings: map[string]string{
+				"cup":   "\033[%i%p1%d;%p2%dH",
+				"khome": "\033OH",
+				"kf12":  "\033[24~",
+				"kDC3":  "\033[3;3~",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ioutil.ReadFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ti, err := Parse(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ti.Names, tt.wantNames) {
+				t.Errorf("got: %q, want: %q", ti.Names, tt.wantNames)
+			}
+			for name, want := range tt.wantBools {
+				if got := ti.Bools[name]; got != want {
+					t.Errorf("got: %s = %v, want: %v", name, got, want)
+				}
+			}
+			for name, want := range tt.wantNumbers {
+				if got := ti.Number(name); got != want {
+					t.Errorf("got: %s = %d, want: %d", name, got, want)
+				}
+			}
+			for name, want := range tt.wantStrings {
+				if got := ti.Strings[name]; got != want {
+					t.Errorf("got: %s = %q, want: %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseError(t *testing.T) {
+	data, err := ioutil.ReadFile("testdata/g/gimterm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "bad magic number", data: append([]byte{0x1a, 0x02}, data[2:]...)},
+		{name: "truncated", data: data[:100]},
+		{name: "negative count", data: append(append(append([]byte(nil), data[:6]...), 0xff, 0xff), data[8:]...)},
+		{name: "negative size", data: append([]byte{0x1a, 0x01, 0xfe, 0xff}, data[4:]...)},
+		// an entry of the name x and a header of extended capabilities of -1 bools
+		{name: "negative extended count", data: []byte{0x1a, 0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 'x', 0x00, 0xff, 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.data); err == nil {
+				t.Errorf("got: no error, want: an error")
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		term    string
+		env     map[string]string
+		wantErr error
+	}{
+		{name: "TERMINFO", term: "gimterm", env: map[string]string{"TERMINFO": "testdata"}},
+		{name: "TERMINFO_DIRS", term: "gimterm", env: map[string]string{"TERMINFO_DIRS": "/nonexistent:testdata"}},
+		{name: "HOME", term: "gimterm", env: map[string]string{"HOME": "/nonexistent", "TERMINFO": "testdata"}},
+		{name: "not found", term: "gimterm", env: map[string]string{}, wantErr: ErrNotFound},
+		{name: "no TERM", term: "", env: map[string]string{"TERMINFO": "testdata"}, wantErr: ErrNotFound},
+		{name: "path in TERM", term: "../testdata/g/gimterm", env: map[string]string{"TERMINFO": "testdata"}, wantErr: ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ti, err := Load(tt.term, func(k string) string { return tt.env[k] })
+			if err != tt.wantErr {
+				t.Fatalf("got: %v, want: %v", err, tt.wantErr)
+			}
+			if err == nil && ti.Names[0] != tt.term {
+				t.Errorf("got: %q, want: %q", ti.Names[0], tt.term)
+			}
+		})
+	}
+}
+
+func TestTerminfo_String(t *testing.T) {
+	ti := Xterm()
+	ti.Strings["flash"] = "\033[?5h$<100/>\033[?5l"
+	tests := []struct {
+		name   string
+		cap    string
+		params []int
+		want   string
+	}{
+		{name: "plain", cap: "clear", want: "\033[H\033[2J"},
+		{name: "parameters", cap: "cup", params: []int{4, 9}, want: "\033[5;10H"},
+		{name: "padding removed", cap: "flash", want: "\033[?5h\033[?5l"},
+		{name: "absent", cap: "nope", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ti.String(tt.cap, tt.params...); got != tt.want {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
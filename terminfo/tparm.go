@@ -0,0 +1,255 @@
+package terminfo
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Tparm substitutes params into a parameterized capability, ex) cup.
+// It runs the stack language described in terminfo(5); string parameters are not supported.
+func Tparm(s string, params ...int) string {
+	var p [9]int
+	copy(p[:], params)
+	var (
+		out     strings.Builder
+		stack   []int
+		dynamic [26]int
+		static  [26]int
+	)
+	push := func(v int) { stack = append(stack, v) }
+	pop := func() int {
+		if len(stack) == 0 {
+			return 0
+		}
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		return v
+	}
+	boolInt := func(b bool) int {
+		if b {
+			return 1
+		}
+		return 0
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+1 >= len(s) {
+			out.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case '%':
+			out.WriteByte('%')
+		case 'c':
+			out.WriteByte(byte(pop()))
+		case 'p':
+			if i+1 < len(s) && s[i+1] >= '1' && s[i+1] <= '9' {
+				i++
+				push(p[s[i]-'1'])
+			}
+		case 'P', 'g':
+			if i+1 >= len(s) {
+				break
+			}
+			i++
+			v := s[i]
+			var vars *[26]int
+			switch {
+			case v >= 'a' && v <= 'z':
+				vars, v = &dynamic, v-'a'
+			case v >= 'A' && v <= 'Z':
+				vars, v = &static, v-'A'
+			default:
+				continue
+			}
+			if c == 'P' {
+				vars[v] = pop()
+			} else {
+				push(vars[v])
+			}
+		case '\'':
+			// a character constant, ex) %'A'
+			if i+2 < len(s) && s[i+2] == '\'' {
+				push(int(s[i+1]))
+				i += 2
+			}
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				break
+			}
+			n, _ := strconv.Atoi(s[i+1 : i+end])
+			push(n)
+			i += end
+		case 'l':
+			// the length of a string parameter, there are none
+			pop()
+			push(0)
+		case '+', '-', '*', '/', 'm', '&', '|', '^', '=', '>', '<', 'A', 'O':
+			b, a := pop(), pop()
+			switch c {
+			case '+':
+				push(a + b)
+			case '-':
+				push(a - b)
+			case '*':
+				push(a * b)
+			case '/':
+				if b == 0 {
+					push(0)
+				} else {
+					push(a / b)
+				}
+			case 'm':
+				if b == 0 {
+					push(0)
+				} else {
+					push(a % b)
+				}
+			case '&':
+				push(a & b)
+			case '|':
+				push(a | b)
+			case '^':
+				push(a ^ b)
+			case '=':
+				push(boolInt(a == b))
+			case '>':
+				push(boolInt(a > b))
+			case '<':
+				push(boolInt(a < b))
+			case 'A':
+				push(boolInt(a != 0 && b != 0))
+			case 'O':
+				push(boolInt(a != 0 || b != 0))
+			}
+		case '!':
+			push(boolInt(pop() == 0))
+		case '~':
+			push(^pop())
+		case 'i':
+			p[0]++
+			p[1]++
+		case '?', ';':
+		case 't':
+			if pop() == 0 {
+				i = skipTo(s, i+1, true)
+			}
+		case 'e':
+			i = skipTo(s, i+1, false)
+		default:
+			// a printf like format, ex) %d, %02x, %:-3d
+			end := i
+			for end < len(s) && strings.IndexByte(":-+# .0123456789", s[end]) >= 0 {
+				end++
+			}
+			if end >= len(s) || strings.IndexByte("doxXs", s[end]) < 0 {
+				break
+			}
+			out.WriteString(format(s[i:end], s[end], pop()))
+			i = end
+		}
+	}
+	return out.String()
+}
+
+// skipTo returns the index before the part run next when a condition is skipped from i:
+// after the matching %e (if elseToo) or %;.
+func skipTo(s string, i int, elseToo bool) int {
+	depth := 0
+	for ; i+1 < len(s); i++ {
+		if s[i] != '%' {
+			continue
+		}
+		i++
+		switch s[i] {
+		case '?':
+			depth++
+		case ';':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		case 'e':
+			if depth == 0 && elseToo {
+				return i
+			}
+		}
+	}
+	return len(s)
+}
+
+// format prints v as printf("%" + flags + verb) would.
+func format(flags string, verb byte, v int) string {
+	flags = strings.TrimPrefix(flags, ":")
+	left, zero, plus, space, alt := false, false, false, false, false
+	for len(flags) > 0 && strings.IndexByte("-+# 0", flags[0]) >= 0 {
+		switch flags[0] {
+		case '-':
+			left = true
+		case '+':
+			plus = true
+		case ' ':
+			space = true
+		case '#':
+			alt = true
+		case '0':
+			zero = true
+		}
+		flags = flags[1:]
+	}
+	width, precision := 0, -1
+	if dot := strings.IndexByte(flags, '.'); dot >= 0 {
+		precision, _ = strconv.Atoi(flags[dot+1:])
+		flags = flags[:dot]
+	}
+	width, _ = strconv.Atoi(flags)
+
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+	var digits string
+	prefix := ""
+	switch verb {
+	case 'o':
+		digits = strconv.FormatInt(int64(v), 8)
+		if alt {
+			prefix = "0"
+		}
+	case 'x':
+		digits = strconv.FormatInt(int64(v), 16)
+		if alt {
+			prefix = "0x"
+		}
+	case 'X':
+		digits = strings.ToUpper(strconv.FormatInt(int64(v), 16))
+		if alt {
+			prefix = "0X"
+		}
+	default:
+		digits = strconv.Itoa(v)
+	}
+	for len(digits) < precision {
+		digits = "0" + digits
+	}
+	switch {
+	case neg:
+		prefix = "-" + prefix
+	case plus && verb == 'd':
+		prefix = "+" + prefix
+	case space && verb == 'd':
+		prefix = " " + prefix
+	}
+	pad := width - len(prefix) - len(digits)
+	switch {
+	case pad <= 0:
+		return prefix + digits
+	case left:
+		return prefix + digits + strings.Repeat(" ", pad)
+	case zero && precision < 0:
+		return prefix + strings.Repeat("0", pad) + digits
+	default:
+		return strings.Repeat(" ", pad) + prefix + digits
+	}
+}
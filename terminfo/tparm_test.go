@@ -0,0 +1,35 @@
+package terminfo
+
+import "testing"
+
+func TestTparm(t *testing.T) {
+	setaf := Xterm().Strings["setaf"]
+	tests := []struct {
+		name   string
+		s      string
+		params []int
+		want   string
+	}{
+		{name: "no parameters", s: "\033[H", want: "\033[H"},
+		{name: "percent", s: "100%%", want: "100%"},
+		{name: "increment", s: "\033[%i%p1%d;%p2%dH", params: []int{0, 0}, want: "\033[1;1H"},
+		{name: "setaf normal", s: setaf, params: []int{1}, want: "\033[31m"},
+		{name: "setaf bright", s: setaf, params: []int{9}, want: "\033[91m"},
+		{name: "setaf 256", s: setaf, params: []int{200}, want: "\033[38;5;200m"},
+		{name: "else if", s: "%?%p1%{1}%=%ta%e%p1%{2}%=%tb%ec%;", params: []int{2}, want: "b"},
+		{name: "nested condition", s: "%?%p1%t%?%p2%tx%ey%;%ez%;", params: []int{1, 0}, want: "y"},
+		{name: "character", s: "%p1%c%'A'%c", params: []int{'x'}, want: "xA"},
+		{name: "variables", s: "%p1%Pa%p2%PZ%gZ%d%ga%d", params: []int{3, 4}, want: "43"},
+		{name: "arithmetic", s: "%p1%p2%+%d %p1%p2%*%d %p1%p2%m%d %p2%{0}%/%d", params: []int{7, 3}, want: "10 21 1 0"},
+		{name: "logic", s: "%p1%!%d%p1%~%d%p1%p2%A%d%p1%p2%O%d", params: []int{1, 0}, want: "0-201"},
+		{name: "format", s: "%p1%03d|%p1%:-4d|%p1%x|%p1%#o|%p1%X", params: []int{42}, want: "042|42  |2a|052|2A"},
+		{name: "synchronized update", s: "\033[?2026%?%p1%{1}%-%tl%eh%;", params: []int{1}, want: "\033[?2026h"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tparm(tt.s, tt.params...); got != tt.want {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
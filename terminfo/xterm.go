@@ -0,0 +1,63 @@
+package terminfo
+
+// Xterm returns a built-in copy of the xterm-256color entry, the capabilities
+// gim uses, for terminals that are not in the database.
+func Xterm() *Terminfo {
+	return &Terminfo{
+		Names: []string{"xterm-256color", "xterm with 256 colors"},
+		Bools: map[string]bool{"am": true, "bce": true, "km": true, "mir": true, "msgr": true, "xenl": true},
+		Numbers: map[string]int{
+			"colors": 256,
+			"cols":   80,
+			"it":     8,
+			"lines":  24,
+			"pairs":  65536,
+		},
+		Strings: map[string]string{
+			"clear": "\033[H\033[2J",
+			"cup":   "\033[%i%p1%d;%p2%dH",
+			"el":    "\033[K",
+			"civis": "\033[?25l",
+			"cnorm": "\033[?12l\033[?25h",
+			"smcup": "\033[?1049h\033[22;0;0t",
+			"rmcup": "\033[?1049l\033[23;0;0t",
+			"smkx":  "\033[?1h\033=",
+			"rmkx":  "\033[?1l\033>",
+			"sgr0":  "\033(B\033[m",
+			"bold":  "\033[1m",
+			"sitm":  "\033[3m",
+			"smul":  "\033[4m",
+			"rev":   "\033[7m",
+			"setaf": "\033[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m",
+			"setab": "\033[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m",
+			"kcuu1": "\033OA",
+			"kcud1": "\033OB",
+			"kcuf1": "\033OC",
+			"kcub1": "\033OD",
+			"khome": "\033OH",
+			"kend":  "\033OF",
+			"kpp":   "\033[5~",
+			"knp":   "\033[6~",
+			"kich1": "\033[2~",
+			"kdch1": "\033[3~",
+			"kbs":   "\177",
+			"kcbt":  "\033[Z",
+			"kLFT":  "\033[1;2D",
+			"kRIT":  "\033[1;2C",
+			"kri":   "\033[1;2A",
+			"kind":  "\033[1;2B",
+			"kf1":   "\033OP",
+			"kf2":   "\033OQ",
+			"kf3":   "\033OR",
+			"kf4":   "\033OS",
+			"kf5":   "\033[15~",
+			"kf6":   "\033[17~",
+			"kf7":   "\033[18~",
+			"kf8":   "\033[19~",
+			"kf9":   "\033[20~",
+			"kf10":  "\033[21~",
+			"kf11":  "\033[23~",
+			"kf12":  "\033[24~",
+		},
+	}
+}
@@ -0,0 +1,4 @@
+package main
+
+// main says hello
+func main() {}
@@ -0,0 +1,106 @@
+package window
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// Abbreviations replace a word typed in insert or command-line mode when the key after it is not
+// a keyword character, ex) :iabbrev teh the
+// The word is deleted with Backspace and the keys of the abbreviation are handled as if typed,
+// so the insert they are part of is still undone at once and repeated with the expansion.
+// They are kept as mappings of the modes i and c.
+
+// abbrevCommand returns an ex command defining or listing the abbreviations of modes, ex) :iabbrev
+func abbrevCommand(modes string, noremap bool) func(*Window, exArgs) error {
+	return func(w *Window, c exArgs) error {
+		return w.defineMapping(&w.abbreviations, modes, noremap, c.args, "No abbreviation found")
+	}
+}
+
+// unabbrevCommand returns an ex command deleting an abbreviation of modes, ex) :iunabbrev
+func unabbrevCommand(modes string) func(*Window, exArgs) error {
+	return func(w *Window, c exArgs) error {
+		if c.args == "" {
+			return errors.New("E471: Argument required")
+		}
+		if !removeMappings(&w.abbreviations, modes, w.parseKeys(c.args)) {
+			return errors.New("E24: No such abbreviation")
+		}
+		return nil
+	}
+}
+
+// endsAbbreviation reports whether k ends the word before the cursor: a character that is not
+// a keyword character, Enter, or Tab and Escape in insert mode. It returns the mode it is typed in.
+// The keys of mappings and abbreviations do not end words.
+func (w *Window) endsAbbreviation(k KeyEvent) (byte, bool) {
+	mode := w.mapMode()
+	if mode != 'i' && mode != 'c' || w.mapping != nil {
+		return 0, false
+	}
+	switch k.Key {
+	case prompt.Enter:
+		return mode, true
+	case prompt.Tab, prompt.Escape:
+		return mode, mode == 'i'
+	case prompt.NotDefined:
+		r, _ := utf8.DecodeRune(k.Data)
+		return mode, len(k.Data) > 0 && charClass(r, false) != 2
+	}
+	return 0, false
+}
+
+// findAbbreviation returns the abbreviation of mode that text ends with. An abbreviation of
+// keyword characters is a whole word, any other is after a blank or at the start.
+func (w *Window) findAbbreviation(mode byte, text string) *mapping {
+	for _, a := range w.abbreviations {
+		if strings.IndexByte(a.modes, mode) < 0 || !strings.HasSuffix(text, a.lhs) {
+			continue
+		}
+		before := text[:len(text)-len(a.lhs)]
+		if before == "" {
+			return a
+		}
+		r, _ := utf8.DecodeLastRuneInString(before)
+		last, _ := utf8.DecodeLastRuneInString(a.lhs)
+		if charClass(last, false) == 2 && charClass(r, false) != 2 || charClass(r, false) == 0 {
+			return a
+		}
+	}
+	return nil
+}
+
+// expandAbbreviation replaces the abbreviation before the cursor when k ends it.
+func (w *Window) expandAbbreviation(k KeyEvent) {
+	mode, ok := w.endsAbbreviation(k)
+	if !ok {
+		return
+	}
+	var text []byte
+	if mode == 'i' {
+		if w.position.Y > len(w.FileContents) {
+			return
+		}
+		text = w.FileContents[w.position.Y-1][:w.position.X-1]
+	} else {
+		text = w.command[:len(w.command)-w.commandRight]
+	}
+	a := w.findAbbreviation(mode, string(text))
+	if a == nil {
+		return
+	}
+	outer := w.mapping
+	w.mapping = a
+	w.startExecuting()
+	defer func() {
+		w.stopExecuting()
+		w.mapping = outer
+	}()
+	if w.feed([]byte(strings.Repeat("\x7f", utf8.RuneCountInString(a.lhs))), false) {
+		w.feed([]byte(a.rhs), !a.noremap)
+	}
+}
@@ -0,0 +1,81 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_abbreviations(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "space", input: ":iab teh the\rA teh \x1b", wantLines: []string{"x the ", "y"}, wantPosition: Position{X: 6, Y: 1}},
+		{name: "escape", input: ":iab teh the\rA teh\x1b", wantLines: []string{"x the", "y"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "enter", input: ":iab teh the\rA teh\rz\x1b", wantLines: []string{"x the", "z", "y"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "punctuation", input: ":iab teh the\rA teh.\x1b", wantLines: []string{"x the.", "y"}, wantPosition: Position{X: 6, Y: 1}},
+		{name: "at the start of the line", input: ":iab teh the\rIteh \x1b", wantLines: []string{"the x", "y"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "not a whole word", input: ":iab teh the\rA ateh \x1b", wantLines: []string{"x ateh ", "y"}, wantPosition: Position{X: 7, Y: 1}},
+		{name: "keyword character", input: ":iab teh the\rA tehs\x1b", wantLines: []string{"x tehs", "y"}, wantPosition: Position{X: 6, Y: 1}},
+		{name: "not a keyword", input: ":iab #i #include\rA #i \x1b", wantLines: []string{"x #include ", "y"}, wantPosition: Position{X: 11, Y: 1}},
+		{name: "undo", input: ":iab teh the\rA teh \x1bu", wantLines: []string{"x", "y"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "repeat", input: ":iab teh the\rA teh \x1bj.", wantLines: []string{"x the ", "y the "}, wantPosition: Position{X: 6, Y: 2}},
+		{name: "mapped keys", input: ":imap d D\r:iab ab cd\rA ab \x1b", wantLines: []string{"x cD ", "y"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "keys not mapped", input: ":imap d D\r:inoreab ab cd\rA ab \x1b", wantLines: []string{"x cd ", "y"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "command-line abbreviation in insert mode", input: ":cab teh the\rA teh \x1b", wantLines: []string{"x teh ", "y"}, wantPosition: Position{X: 6, Y: 1}},
+		{name: "unabbreviate", input: ":iab teh the\r:iuna teh\rA teh \x1b", wantLines: []string{"x teh ", "y"}, wantPosition: Position{X: 6, Y: 1}},
+		{name: "no such abbreviation", input: ":una teh\r", wantLines: []string{"x", "y"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E24: No such abbreviation"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, []string{"x", "y"}, Position{X: 1, Y: 1}, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_listAbbreviations(t *testing.T) {
+	tests := []struct {
+		name        string
+		commands    []string
+		wantMessage string
+	}{
+		{name: "all", commands: []string{"iab teh the", "cnorea W w", "ab"}, wantMessage: "c  W           * w\ni  teh           the"},
+		{name: "insert", commands: []string{"iab teh the", "cnorea W w", "iab"}, wantMessage: "i  teh           the"},
+		{name: "none", commands: []string{"ab"}, wantMessage: "No abbreviation found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			for _, c := range tt.commands {
+				if err := w.ExecuteLine(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_commandLineAbbreviation(t *testing.T) {
+	w := typeKeys(t, []string{"x"}, Position{X: 1, Y: 1}, ":cabbrev ts2 ts=2\r:set ts2\r")
+	if got := w.numberOption("tabstop"); got != 2 {
+		t.Errorf("got: %d, want: 2", got)
+	}
+	if got := w.history[':']; !reflect.DeepEqual(got, []string{"cabbrev ts2 ts=2", "set ts=2"}) {
+		t.Errorf("got: %q, want: the line expanded in the history", got)
+	}
+}
@@ -0,0 +1,65 @@
+package window
+
+import "gim/syntax"
+
+// buffer is a text and what belongs to it: its file, the cursor in it, its undo history and its marks.
+// The window edits one buffer in its own fields, a buffer it does not show is kept in this struct,
+// ex) the file being edited while the command-line window shows the history.
+type buffer struct {
+	number      int // ex) 1 for the file opened first, see :ls
+	lines       [][]byte
+	fileName    string
+	fileType    string
+	highlighter *syntax.Highlighter
+	position    Position
+	top         int
+	undoStack   []undoState
+	redoStack   []undoState
+	marks       map[byte]Position
+	modified    bool
+	fileStamp   fileStamp
+	options     optionValues // the values of the buffer options set with :setlocal or :set
+}
+
+// takeBuffer takes the buffer out of the window, which is left with an empty one.
+func (w *Window) takeBuffer() *buffer {
+	b := &buffer{
+		number:      w.bufferNumber,
+		lines:       w.FileContents,
+		fileName:    w.fileName,
+		fileType:    w.fileType,
+		highlighter: w.highlighter,
+		position:    w.position,
+		top:         w.top,
+		undoStack:   w.undoStack,
+		redoStack:   w.redoStack,
+		marks:       w.marks,
+		modified:    w.modified,
+		fileStamp:   w.fileStamp,
+		options:     w.bufferOptions,
+	}
+	w.setBuffer(&buffer{lines: [][]byte{{}}, position: Position{X: 1, Y: 1}})
+	return b
+}
+
+// setBuffer makes the window edit b.
+func (w *Window) setBuffer(b *buffer) {
+	w.bufferNumber = b.number
+	w.FileContents = b.lines
+	w.fileName = b.fileName
+	w.fileType = b.fileType
+	w.highlighter = b.highlighter
+	w.position = b.position
+	w.top = b.top
+	w.undoStack = b.undoStack
+	w.redoStack = b.redoStack
+	w.marks = b.marks
+	w.modified = b.modified
+	w.fileStamp = b.fileStamp
+	w.bufferOptions = b.options
+	w.changing = false
+	w.indentCache = nil
+	if w.highlighter != nil {
+		w.highlighter.Reset()
+	}
+}
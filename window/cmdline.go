@@ -0,0 +1,367 @@
+package window
+
+import (
+	"bufio"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// The command line is edited like a line of text: the cursor moves in it, and Up and Down
+// bring back the lines typed before that start with the text typed, from the history of
+// ex commands for : and of search patterns for / and ?.
+
+// maxHistory is how many lines each history keeps.
+const maxHistory = 100
+
+// cmdwin is the command-line window, ex) q: It shows the history as the buffer to edit,
+// Enter executes the line of the cursor.
+type cmdwin struct {
+	typ   byte    // :, / or ?
+	saved *buffer // the buffer it is shown instead of
+}
+
+var errCmdwin = errors.New("E11: Invalid in command-line window; <CR> executes, CTRL-C quits")
+
+// startCommandLine starts typing a command line of typ, ex) / for a search
+func (w *Window) startCommandLine(typ byte) {
+	w.SetCommandMode()
+	w.commandType = typ
+	w.commandRight = 0
+	w.historyIndex = len(w.history[historyKey(typ)])
+}
+
+// commandPrompt returns the character shown before the command line, ex) :
+func (w *Window) commandPrompt() byte {
+	if w.commandType == 0 {
+		return ':'
+	}
+	return w.commandType
+}
+
+// historyKey returns the history the command lines of typ are kept in, searches share one.
+func historyKey(typ byte) byte {
+	if typ == '?' {
+		return '/'
+	}
+	if typ == 0 {
+		return ':'
+	}
+	return typ
+}
+
+// commandKey handles a key typed in command mode.
+func (w *Window) commandKey(k KeyEvent) {
+	if w.commandRegister {
+		// the register after Ctrl-R
+		w.commandRegister = false
+		w.insertRegister(k)
+		return
+	}
+	if k.Key != prompt.Tab && k.Key != prompt.BackTab {
+		w.completion = nil
+	}
+	before := len(w.command) - w.commandRight
+	switch k.Key {
+	case prompt.Escape, prompt.ControlC:
+		w.SetNormalMode()
+		w.ResetCommand()
+	case prompt.Backspace, prompt.ControlH:
+		if w.IsCommandNotTyped() {
+			w.SetNormalMode()
+			return
+		}
+		w.RemoveCommand()
+	case prompt.Delete:
+		if w.IsCommandNotTyped() {
+			w.SetNormalMode()
+			return
+		}
+		if w.commandRight == 0 {
+			w.RemoveCommand()
+			return
+		}
+		_, size := utf8.DecodeRune(w.command[before:])
+		w.command = concat(w.command[:before], w.command[before+size:])
+		w.commandRight -= size
+		w.editedCommand()
+	case prompt.Enter:
+		w.finishCommandLine()
+	case prompt.Left:
+		w.commandRight = len(w.command) - runesBefore(w.command, before, 1)
+	case prompt.Right:
+		w.commandRight = len(w.command) - runesAfter(w.command, before, 1)
+	case prompt.Home, prompt.ControlB:
+		w.commandRight = len(w.command)
+	case prompt.End, prompt.ControlE:
+		w.commandRight = 0
+	case prompt.ControlW:
+		// the word before the cursor, or the blanks before it
+		start := before
+		for start > 0 && isBlank(w.command[start-1]) {
+			start--
+		}
+		if start > 0 {
+			r, _ := utf8.DecodeLastRune(w.command[:start])
+			class := charClass(r, false)
+			for start > 0 {
+				r, size := utf8.DecodeLastRune(w.command[:start])
+				if charClass(r, false) != class {
+					break
+				}
+				start -= size
+			}
+		}
+		w.command = concat(w.command[:start], w.command[before:])
+		w.editedCommand()
+	case prompt.ControlU:
+		w.command = concat(w.command[before:])
+		w.editedCommand()
+	case prompt.ControlR:
+		w.commandRegister = true
+	case prompt.Up, prompt.ControlP:
+		w.browseHistory(true)
+	case prompt.Down, prompt.ControlN:
+		w.browseHistory(false)
+	case prompt.Tab:
+		w.completeCommand(true)
+	case prompt.BackTab:
+		w.completeCommand(false)
+	case prompt.ControlF:
+		w.openCommandWindow(w.commandPrompt(), string(w.command))
+	case prompt.NotDefined:
+		w.AddCommand(k.Data)
+	}
+}
+
+// editedCommand is called when the text of the command line changes: Up and Down
+// then look for lines that start with the new text.
+func (w *Window) editedCommand() {
+	w.historyIndex = len(w.history[historyKey(w.commandType)])
+}
+
+// insertRegister inserts the register typed after Ctrl-R, or the word under the cursor for Ctrl-R Ctrl-W.
+func (w *Window) insertRegister(k KeyEvent) {
+	if k.Key == prompt.ControlW {
+		if len(w.FileContents) == 0 {
+			return
+		}
+		w.AddCommand(w.wordUnderCursor())
+		return
+	}
+	if k.Key != prompt.NotDefined || len(k.Data) != 1 {
+		return
+	}
+	r, ok := w.getRegister(k.Data[0])
+	if !ok && k.Data[0] == ':' {
+		r, ok = register{text: []byte(w.lastCommandLine)}, true
+	}
+	if !ok && k.Data[0] == '/' {
+		r, ok = register{text: []byte(w.lastSearch)}, true
+	}
+	if !ok {
+		return
+	}
+	w.AddCommand(concat(r.text))
+}
+
+// wordUnderCursor returns the word under or after the cursor, ex) for Ctrl-R Ctrl-W
+func (w *Window) wordUnderCursor() []byte {
+	y := w.position.Y
+	line := w.FileContents[y-1]
+	isWord := func(i int) bool {
+		return w.classAt(Position{X: i + 1, Y: y}, false) == 2
+	}
+	x := w.position.X - 1
+	for x < len(line) && !isWord(x) {
+		x = runesAfter(line, x, 1)
+	}
+	start, end := x, x
+	for start > 0 && isWord(runesBefore(line, start, 1)) {
+		start = runesBefore(line, start, 1)
+	}
+	for end < len(line) && isWord(end) {
+		end = runesAfter(line, end, 1)
+	}
+	return line[start:end]
+}
+
+// finishCommandLine executes the command line typed, ex) when Enter is typed
+func (w *Window) finishCommandLine() {
+	typ := w.commandPrompt()
+	line := string(w.command)
+	w.SetNormalMode()
+	w.ResetCommand()
+	w.runCommandLine(typ, line)
+}
+
+// runCommandLine executes line as a command line of typ: an ex command or a search.
+func (w *Window) runCommandLine(typ byte, line string) {
+	if typ == ':' {
+		w.command = []byte(line)
+		w.ExecuteCommand()
+		w.ResetCommand()
+		return
+	}
+	w.addHistory(typ, line)
+	w.searchCommand(typ, line)
+}
+
+// browseHistory replaces the command line with the older or newer line of the history
+// that starts with the text typed before Up or Down was first typed.
+func (w *Window) browseHistory(older bool) {
+	h := w.history[historyKey(w.commandType)]
+	if w.historyIndex >= len(h) {
+		w.historyIndex = len(h)
+		w.historyPrefix = string(w.command)
+	}
+	i := w.historyIndex
+	for {
+		if older {
+			i--
+		} else {
+			i++
+		}
+		if i < 0 || i > len(h) {
+			return
+		}
+		if i == len(h) || strings.HasPrefix(h[i], w.historyPrefix) {
+			break
+		}
+	}
+	w.historyIndex = i
+	line := w.historyPrefix
+	if i < len(h) {
+		line = h[i]
+	}
+	w.command = []byte(line)
+	w.commandRight = 0
+}
+
+// addHistory adds line to the history of typ, a line already in it moves to the end.
+func (w *Window) addHistory(typ byte, line string) {
+	if line == "" {
+		return
+	}
+	if w.history == nil {
+		w.history = make(map[byte][]string)
+	}
+	key := historyKey(typ)
+	var h []string
+	for _, l := range w.history[key] {
+		if l != line {
+			h = append(h, l)
+		}
+	}
+	h = append(h, line)
+	if len(h) > maxHistory {
+		h = h[len(h)-maxHistory:]
+	}
+	w.history[key] = h
+}
+
+// HistoryFile returns the file the histories are kept in between sessions, ex) ~/.local/state/gim/history
+func HistoryFile() string {
+	state := stateDir()
+	if state == "" {
+		return ""
+	}
+	return filepath.Join(state, "gim", "history")
+}
+
+// LoadHistory reads the histories saved by SaveHistory. Each line is a command line
+// after the character of its history, ex) :set mouse=a or /func
+// A file that does not exist is an empty history.
+func (w *Window) LoadHistory(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	loaded := &Window{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if len(line) < 2 || line[0] != ':' && line[0] != '/' {
+			continue
+		}
+		loaded.addHistory(line[0], line[1:])
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	// the lines typed in this session are newer
+	for _, key := range []byte{':', '/'} {
+		for _, line := range w.history[key] {
+			loaded.addHistory(key, line)
+		}
+	}
+	w.history = loaded.history
+	return nil
+}
+
+// SaveHistory writes the histories to path, oldest first.
+func (w *Window) SaveHistory(path string) error {
+	var b strings.Builder
+	for _, key := range []byte{':', '/'} {
+		for _, line := range w.history[key] {
+			if strings.ContainsAny(line, "\r\n") {
+				continue
+			}
+			b.WriteByte(key)
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// openCommandWindow shows the history of typ as the buffer, with text on the last line
+// and the cursor on it, ex) q:
+func (w *Window) openCommandWindow(typ byte, text string) {
+	if w.cmdwin != nil {
+		w.SetNormalMode()
+		w.ResetCommand()
+		w.showError(errCmdwin)
+		return
+	}
+	w.SetNormalMode()
+	w.ResetCommand()
+	saved := w.takeBuffer()
+	var lines [][]byte
+	for _, l := range w.history[historyKey(typ)] {
+		lines = append(lines, []byte(l))
+	}
+	lines = append(lines, []byte(text))
+	w.setBuffer(&buffer{lines: lines, fileName: "[Command Line]", position: Position{X: 1, Y: len(lines)}})
+	w.clampCursor()
+	w.cmdwin = &cmdwin{typ: typ, saved: saved}
+}
+
+// closeCommandWindow shows the buffer the command-line window was shown instead of.
+func (w *Window) closeCommandWindow() {
+	w.setBuffer(w.cmdwin.saved)
+	w.cmdwin = nil
+}
+
+// executeCommandWindow closes the command-line window and executes the line of the cursor, ex) when Enter is typed
+func (w *Window) executeCommandWindow() {
+	typ := w.cmdwin.typ
+	line := string(w.FileContents[w.position.Y-1])
+	w.closeCommandWindow()
+	if line == "" {
+		return
+	}
+	w.runCommandLine(typ, line)
+}
@@ -0,0 +1,151 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWindow_commandKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		lines       []string
+		position    Position
+		input       string
+		wantCommand string
+	}{
+		{name: "insert at the cursor", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":abc\x1b[D\x1b[DX", wantCommand: "aXbc"},
+		{name: "right", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":abc\x1b[D\x1b[D\x1b[CX", wantCommand: "abXc"},
+		{name: "start and end", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":bc\x02a\x05d", wantCommand: "abcd"},
+		{name: "backspace before the cursor", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":abc\x1b[D\x7f", wantCommand: "ac"},
+		{name: "delete under the cursor", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":abc\x02\x1b[3~", wantCommand: "bc"},
+		{name: "multibyte characters", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":あい\x1b[D\x7fう", wantCommand: "うい"},
+		{name: "delete a word", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":s/foo bar  \x17", wantCommand: "s/foo "},
+		{name: "delete before the cursor", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":abc\x1b[D\x15", wantCommand: "c"},
+		{name: "insert a register", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "yw:e \x12\"", wantCommand: "e foo "},
+		{name: "insert the last command line", lines: []string{"a", "b"}, position: Position{X: 1, Y: 1}, input: ":2\r:\x12:", wantCommand: "2"},
+		{name: "insert the word under the cursor", lines: []string{"foo bar"}, position: Position{X: 4, Y: 1}, input: ":\x12\x17", wantCommand: "bar"},
+		{name: "previous command", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":1\r:2\r:\x1b[A", wantCommand: "2"},
+		{name: "older command", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":1\r:2\r:\x10\x10", wantCommand: "1"},
+		{name: "back to the text typed", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":1\r:2\r:\x1b[A\x1b[B", wantCommand: ""},
+		{name: "commands starting with the text typed", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":marks\r:1\r:ma\x1b[A", wantCommand: "marks"},
+		{name: "no older command", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":1\r:2\r:1\x1b[A\x1b[A", wantCommand: "1"},
+		{name: "searches have their own history", lines: []string{"a", "b"}, position: Position{X: 1, Y: 1}, input: "/b\r:2\r/\x1b[A", wantCommand: "b"},
+		{name: "a count is a range", lines: []string{"a", "b"}, position: Position{X: 1, Y: 1}, input: "3:", wantCommand: ".,.+2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := string(w.command); got != tt.wantCommand {
+				t.Errorf("got: %q, want: %q", got, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestWindow_CommandWindow(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+		wantCommand  string
+	}{
+		{name: "execute a command of the history", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":2\r:3\rq:kk\r", wantLines: []string{"a", "b", "c"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "edit a command", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":2\rq:kr3\r", wantLines: []string{"a", "b", "c"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "open from the command line", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":3\x06\r", wantLines: []string{"a", "b", "c"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "search", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: "q/ic\033\r", wantLines: []string{"a", "b", "c"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "quit", lines: []string{"a", "b"}, position: Position{X: 1, Y: 2}, input: "q::q\r", wantLines: []string{"a", "b"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "back to the command line", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "q:ifoo\033\x03", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantCommand: "foo"},
+		{name: "not in the command-line window", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "q:q:\r", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E11: Invalid in command-line window; <CR> executes, CTRL-C quits"},
+		{name: "undo is kept", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "xq::q\ru", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if w.cmdwin != nil {
+				t.Errorf("got: the command-line window open, want: closed")
+			}
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+			if got := string(w.command); got != tt.wantCommand {
+				t.Errorf("got: command %q, want: %q", got, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestWindow_SaveHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state", "history")
+
+	w := &Window{}
+	w.addHistory(':', "set mouse=a")
+	w.addHistory('/', "func")
+	w.addHistory(':', "marks")
+	if err := w.SaveHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ":set mouse=a\n:marks\n/func\n"; string(b) != want {
+		t.Errorf("got: %q, want: %q", b, want)
+	}
+
+	next := &Window{}
+	next.addHistory(':', "set mouse=a")
+	next.addHistory(':', "q")
+	if err := next.LoadHistory(path); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"marks", "set mouse=a", "q"}; !reflect.DeepEqual(next.history[':'], want) {
+		t.Errorf("got: %q, want: %q", next.history[':'], want)
+	}
+	if want := []string{"func"}; !reflect.DeepEqual(next.history['/'], want) {
+		t.Errorf("got: %q, want: %q", next.history['/'], want)
+	}
+
+	if err := next.LoadHistory(filepath.Join(dir, "none")); err != nil {
+		t.Errorf("got: %v, want: no error for a file that does not exist", err)
+	}
+}
+
+func TestHistoryFile(t *testing.T) {
+	defer setenv("XDG_STATE_HOME", "/state")()
+	if got, want := HistoryFile(), filepath.Join("/state", "gim", "history"); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestWindow_addHistory(t *testing.T) {
+	w := &Window{}
+	for i := 0; i < maxHistory+2; i++ {
+		w.addHistory('?', string(rune('0'+i%10))+string(rune('a'+i/10)))
+	}
+	w.addHistory('/', "5a")
+	h := w.history['/']
+	if len(h) != maxHistory {
+		t.Errorf("got: %d lines, want: %d", len(h), maxHistory)
+	}
+	if h[len(h)-1] != "5a" || h[len(h)-2] != "1k" {
+		t.Errorf("got: %q, want: 5a after 1k", h[len(h)-2:])
+	}
+}
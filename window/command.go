@@ -0,0 +1,253 @@
+package window
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gim/syntax"
+)
+
+// exCommand is a command typed on the command line, ex) :highlight
+type exCommand struct {
+	name string
+	// abbrev is the shortest accepted abbreviation of name, ex) hi for highlight
+	abbrev string
+	// ranged commands work on a range of lines, a range given to the others is an error
+	ranged bool
+	run    func(w *Window, c exArgs) error
+	// complete is what the argument is completed as with Tab, ex) completeFile
+	complete int
+}
+
+// exArgs is a command line as parsed, ex) :1,5normal! x
+type exArgs struct {
+	lineRange
+	bang bool
+	args string
+}
+
+var exCommands []exCommand
+
+// the table is filled in init because commands such as :colorscheme execute other commands.
+func init() {
+	exCommands = []exCommand{
+		{name: "abbreviate", abbrev: "ab", run: abbrevCommand("ic", false)},
+		{name: "buffer", abbrev: "b", run: (*Window).bufferCommand, complete: completeBuffer},
+		{name: "buffers", abbrev: "buffers", run: (*Window).lsCommand},
+		{name: "cabbrev", abbrev: "ca", run: abbrevCommand("c", false)},
+		{name: "cmap", abbrev: "cm", run: mapCommand("c", false)},
+		{name: "cmapclear", abbrev: "cmapc", run: mapclearCommand("c")},
+		{name: "cnoreabbrev", abbrev: "cnorea", run: abbrevCommand("c", true)},
+		{name: "cnoremap", abbrev: "cno", run: mapCommand("c", true)},
+		{name: "colorscheme", abbrev: "colo", run: (*Window).colorschemeCommand, complete: completeColorScheme},
+		{name: "cunabbrev", abbrev: "cuna", run: unabbrevCommand("c")},
+		{name: "cunmap", abbrev: "cu", run: unmapCommand("c")},
+		{name: "delmarks", abbrev: "delm", run: (*Window).delmarksCommand},
+		{name: "edit", abbrev: "e", run: (*Window).editCommand, complete: completeFile},
+		{name: "help", abbrev: "h", run: (*Window).helpCommand, complete: completeHelp},
+		{name: "highlight", abbrev: "hi", run: (*Window).highlightCommand},
+		{name: "iabbrev", abbrev: "ia", run: abbrevCommand("i", false)},
+		{name: "imap", abbrev: "im", run: mapCommand("i", false)},
+		{name: "imapclear", abbrev: "imapc", run: mapclearCommand("i")},
+		{name: "inoreabbrev", abbrev: "inorea", run: abbrevCommand("i", true)},
+		{name: "inoremap", abbrev: "ino", run: mapCommand("i", true)},
+		{name: "iunabbrev", abbrev: "iuna", run: unabbrevCommand("i")},
+		{name: "iunmap", abbrev: "iu", run: unmapCommand("i")},
+		{name: "ls", abbrev: "ls", run: (*Window).lsCommand},
+		{name: "map", abbrev: "map", run: mapCommand("nvo", false)},
+		{name: "mapclear", abbrev: "mapc", run: mapclearCommand("nvo")},
+		{name: "marks", abbrev: "marks", run: (*Window).marksCommand},
+		{name: "nmap", abbrev: "nm", run: mapCommand("n", false)},
+		{name: "nmapclear", abbrev: "nmapc", run: mapclearCommand("n")},
+		{name: "nnoremap", abbrev: "nn", run: mapCommand("n", true)},
+		{name: "noreabbrev", abbrev: "norea", run: abbrevCommand("ic", true)},
+		{name: "noremap", abbrev: "no", run: mapCommand("nvo", true)},
+		{name: "normal", abbrev: "norm", ranged: true, run: (*Window).normalExCommand},
+		{name: "nunmap", abbrev: "nun", run: unmapCommand("n")},
+		{name: "quit", abbrev: "q", run: (*Window).quitCommand},
+		{name: "read", abbrev: "r", ranged: true, run: (*Window).readCommand, complete: completeFile},
+		{name: "registers", abbrev: "reg", run: (*Window).registersCommand},
+		{name: "set", abbrev: "se", run: (*Window).setCommand, complete: completeOption},
+		{name: "setlocal", abbrev: "setl", run: (*Window).setlocalCommand, complete: completeOption},
+		{name: "source", abbrev: "so", run: (*Window).sourceCommand, complete: completeFile},
+		{name: "unabbreviate", abbrev: "una", run: unabbrevCommand("ic")},
+		{name: "unmap", abbrev: "unm", run: unmapCommand("nvo")},
+		{name: "vmap", abbrev: "vm", run: mapCommand("v", false)},
+		{name: "vmapclear", abbrev: "vmapc", run: mapclearCommand("v")},
+		{name: "vnoremap", abbrev: "vn", run: mapCommand("v", true)},
+		{name: "vunmap", abbrev: "vu", run: unmapCommand("v")},
+		{name: "wq", abbrev: "wq", run: (*Window).wqCommand, complete: completeFile},
+		{name: "write", abbrev: "w", run: (*Window).writeCommand, complete: completeFile},
+	}
+}
+
+func findExCommand(name string) *exCommand {
+	for i, c := range exCommands {
+		if len(name) >= len(c.abbrev) && strings.HasPrefix(c.name, name) {
+			return &exCommands[i]
+		}
+	}
+	return nil
+}
+
+// ExecuteCommand runs the command typed in command mode and shows its error, if any.
+func (w *Window) ExecuteCommand() {
+	w.addHistory(':', string(w.command))
+	w.lastCommandLine = string(w.command)
+	if err := w.ExecuteLine(string(w.command)); err != nil {
+		w.showError(err)
+	} else if w.mapping != nil && !w.mapping.silent && w.message == "" {
+		// the command line of a mapping stays on the screen, unless it is <silent>
+		w.showMessage(":"+w.lastCommandLine, "")
+	}
+}
+
+// ExecuteLine runs one command line, ex) highlight Comment ctermfg=244
+func (w *Window) ExecuteLine(line string) error {
+	line = strings.TrimLeft(line, " \t:")
+	if line == "" || line[0] == '"' {
+		return nil
+	}
+	r, line, err := w.parseRange(line)
+	if err != nil {
+		return err
+	}
+	line = strings.TrimLeft(line, " \t")
+	if line == "" {
+		// a range alone goes to its last line, ex) :5
+		if r.given && len(w.FileContents) > 0 {
+			w.pushJump(w.position)
+			w.position = Position{X: w.firstNonBlank(r.last), Y: r.last}
+		}
+		return nil
+	}
+	end := 0
+	for end < len(line) && ('a' <= line[end] && line[end] <= 'z' || 'A' <= line[end] && line[end] <= 'Z') {
+		end++
+	}
+	name, rest := line[:end], line[end:]
+	bang := strings.HasPrefix(rest, "!")
+	if bang {
+		rest = rest[1:]
+	}
+	cmd := findExCommand(name)
+	if cmd == nil {
+		return fmt.Errorf("E492: Not an editor command: %s", line)
+	}
+	if r.given && !cmd.ranged {
+		return errors.New("E481: No range allowed")
+	}
+	return cmd.run(w, exArgs{lineRange: r, bang: bang, args: strings.TrimSpace(rest)})
+}
+
+// sourceLines executes every line read from r. source names r in error messages.
+// All lines are executed, the first error is returned.
+func (w *Window) sourceLines(source string, r io.Reader) error {
+	var first error
+	sourcing := w.sourcing
+	defer func() { w.sourcing = sourcing }()
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		w.sourcing = fmt.Sprintf("%s line %d", source, n)
+		if err := w.ExecuteLine(sc.Text()); err != nil && first == nil {
+			first = fmt.Errorf("Error detected while processing %s line %d: %v", source, n, err)
+		}
+	}
+	if err := sc.Err(); err != nil && first == nil {
+		first = err
+	}
+	return first
+}
+
+// quitCommand is :quit, in the command-line window it closes the window.
+// It does not quit while a buffer is modified, :quit! quits and loses the changes.
+func (w *Window) quitCommand(c exArgs) error {
+	if w.cmdwin != nil {
+		w.closeCommandWindow()
+		return nil
+	}
+	if !c.bang {
+		if err := w.checkModified(); err != nil {
+			return err
+		}
+	}
+	w.Quit(0)
+	return nil
+}
+
+// checkModified returns an error when a buffer is modified, as quitting would lose the changes.
+func (w *Window) checkModified() error {
+	if w.modified {
+		return errModified
+	}
+	for _, b := range w.buffers {
+		if b.modified {
+			return fmt.Errorf("E162: No write since last change for buffer \"%s\"", bufferName(b.fileName))
+		}
+	}
+	return nil
+}
+
+func (w *Window) highlightCommand(c exArgs) error {
+	out, err := w.theme.Highlight(c.args)
+	if err != nil {
+		return err
+	}
+	if out != "" {
+		w.showMessage(out, "")
+	}
+	return nil
+}
+
+// colorSchemeDirs returns the directories searched for colour scheme files, ex) ~/.config/gim/colors
+func colorSchemeDirs() []string {
+	var dirs []string
+	home, _ := os.UserHomeDir()
+	config := os.Getenv("XDG_CONFIG_HOME")
+	if config == "" && home != "" {
+		config = filepath.Join(home, ".config")
+	}
+	if config != "" {
+		dirs = append(dirs, filepath.Join(config, "gim", "colors"))
+	}
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".gim", "colors"))
+	}
+	return dirs
+}
+
+func (w *Window) colorschemeCommand(c exArgs) error {
+	if c.args == "" {
+		w.showMessage(w.theme.Name, "")
+		return nil
+	}
+	return w.loadColorScheme(c.args)
+}
+
+// loadColorScheme executes the colour scheme file name.gim,
+// falling back to the built-in scheme of that name.
+func (w *Window) loadColorScheme(name string) error {
+	for _, dir := range colorSchemeDirs() {
+		path := filepath.Join(dir, name+".gim")
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		err = w.sourceLines(path, f)
+		f.Close()
+		w.theme.Name = name
+		return err
+	}
+	source, ok := syntax.Scheme(name)
+	if !ok {
+		return fmt.Errorf("E185: Cannot find color scheme '%s'", name)
+	}
+	err := w.sourceLines(name, strings.NewReader(source))
+	w.theme.Name = name
+	return err
+}
@@ -0,0 +1,241 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gim/syntax"
+)
+
+func TestWindow_ExecuteLine(t *testing.T) {
+	tests := []struct {
+		name      string
+		lines     []string
+		group     string
+		wantStyle syntax.Style
+		wantErr   string
+	}{
+		{
+			name:      "highlight",
+			lines:     []string{"highlight Comment ctermfg=1"},
+			group:     "Comment",
+			wantStyle: syntax.Style{Fg: syntax.Indexed(1)},
+		},
+		{
+			name:      "abbreviation and leading colon",
+			lines:     []string{":hi Comment ctermfg=2"},
+			group:     "Comment",
+			wantStyle: syntax.Style{Fg: syntax.Indexed(2)},
+		},
+		{
+			name:      "comment line",
+			lines:     []string{`" hi Comment ctermfg=2`},
+			group:     "Comment",
+			wantStyle: syntax.Style{Fg: syntax.Indexed(244)},
+		},
+		{
+			name:      "built-in colour scheme",
+			lines:     []string{"colorscheme light"},
+			group:     "String",
+			wantStyle: syntax.Style{Fg: syntax.Indexed(28)},
+		},
+		{name: "too short abbreviation", lines: []string{"col default"}, wantErr: "E492: Not an editor command: col default"},
+		{name: "unknown command", lines: []string{"foo"}, wantErr: "E492: Not an editor command: foo"},
+		{name: "unknown colour scheme", lines: []string{"colo nothing"}, wantErr: "E185: Cannot find color scheme 'nothing'"},
+		{name: "highlight error", lines: []string{"hi Comment ctermfg=x"}, wantErr: "E421: Color name or number not recognized: x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			w.Size = Size{Row: 10, Column: 80}
+			var err error
+			for _, l := range tt.lines {
+				if err = w.ExecuteLine(l); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("err = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := w.theme.Style(tt.group, syntax.Color256); got != tt.wantStyle {
+				t.Errorf("style of %s = %+v, want %+v", tt.group, got, tt.wantStyle)
+			}
+		})
+	}
+}
+
+func TestWindow_ExecuteCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		command      string
+		wantRows     []string
+		wantStyle    syntax.Style
+		wantHitEnter bool
+	}{
+		{
+			name:      "error is shown on the last line",
+			command:   "foo",
+			wantRows:  []string{"11111", "2222", "E492: Not an editor command: foo"},
+			wantStyle: syntax.Style{Fg: syntax.Indexed(15), Bg: syntax.Indexed(160)},
+		},
+		{
+			name:     "single line message",
+			command:  "colorscheme",
+			wantRows: []string{"11111", "2222", "default"},
+		},
+		{
+			name:         "listing waits for a key",
+			command:      "hi",
+			wantRows:     []string{"WarningMsg      xxx ctermfg=160 guifg=#d", "WildMenu        xxx links to PmenuSel", hitEnterPrompt},
+			wantHitEnter: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(40, 3)
+			w := NewWindow(term)
+			w.SetSize()
+			w.FileContents = [][]byte{[]byte("11111"), []byte("2222")}
+			w.AddCommand([]byte(tt.command))
+			w.ExecuteCommand()
+			w.PrintFileContents()
+			for i, want := range tt.wantRows {
+				if got := term.Row(i); got != want {
+					t.Errorf("row %d = %q, want %q", i, got, want)
+				}
+			}
+			if got := term.Cell(2, 0).Style; got != tt.wantStyle && !tt.wantHitEnter {
+				t.Errorf("style of the message = %+v, want %+v", got, tt.wantStyle)
+			}
+			if w.IsWaitingForKey() != tt.wantHitEnter {
+				t.Errorf("IsWaitingForKey() = %v, want %v", w.IsWaitingForKey(), tt.wantHitEnter)
+			}
+		})
+	}
+}
+
+func TestWindow_DismissMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		wantUsed bool
+	}{
+		{name: "enter", input: []byte{0xd}, wantUsed: true},
+		{name: "space", input: []byte(" "), wantUsed: true},
+		{name: "escape", input: []byte{0x1b}, wantUsed: true},
+		{name: "command", input: []byte(":"), wantUsed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			w.Size = Size{Row: 3, Column: 80}
+			w.hitEnter = true
+			if got := w.DismissMessage(tt.input); got != tt.wantUsed {
+				t.Errorf("DismissMessage() = %v, want %v", got, tt.wantUsed)
+			}
+			if w.IsWaitingForKey() {
+				t.Error("message is still shown")
+			}
+		})
+	}
+}
+
+func TestWindow_LoadColorScheme(t *testing.T) {
+	config, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(config)
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", config)
+
+	dir := filepath.Join(config, "gim", "colors")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"mine.gim":   "\" my scheme\nhighlight clear\nhi Comment ctermfg=9\n",
+		"broken.gim": "hi Comment ctermfg=9\nhi Comment nope\n",
+		"light.gim":  "hi String ctermfg=1\n",
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		scheme    string
+		group     string
+		wantStyle syntax.Style
+		wantErr   string
+	}{
+		{name: "user scheme", scheme: "mine", group: "Comment", wantStyle: syntax.Style{Fg: syntax.Indexed(9)}},
+		{name: "user scheme overrides built-in one", scheme: "light", group: "String", wantStyle: syntax.Style{Fg: syntax.Indexed(1)}},
+		{
+			name:      "error in a scheme",
+			scheme:    "broken",
+			group:     "Comment",
+			wantStyle: syntax.Style{Fg: syntax.Indexed(9)},
+			wantErr:   "Error detected while processing " + filepath.Join(dir, "broken.gim") + " line 2: E416: Missing equal sign: nope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			err := w.loadColorScheme(tt.scheme)
+			if (tt.wantErr == "" && err != nil) || (tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr)) {
+				t.Errorf("err = %v, want %q", err, tt.wantErr)
+			}
+			if w.theme.Name != tt.scheme {
+				t.Errorf("theme name = %s, want %s", w.theme.Name, tt.scheme)
+			}
+			if got := w.theme.Style(tt.group, syntax.Color256); got != tt.wantStyle {
+				t.Errorf("style of %s = %+v, want %+v", tt.group, got, tt.wantStyle)
+			}
+		})
+	}
+}
+
+func TestWindow_quitCommand(t *testing.T) {
+	none := filepath.Join(os.TempDir(), "gim-no-such-dir", "new.txt")
+	tests := []struct {
+		name     string
+		commands []string
+		wantQuit bool
+		wantErr  string
+	}{
+		{name: "not modified", commands: []string{"q"}, wantQuit: true},
+		{name: "modified", commands: []string{"normal x", "q"}, wantErr: "E37: No write since last change (add ! to override)"},
+		{name: "modified with !", commands: []string{"normal x", "q!"}, wantQuit: true},
+		{name: "hidden buffer modified", commands: []string{"normal x", "e! " + none, "q"}, wantErr: "E162: No write since last change for buffer \"[No Name]\""},
+		{name: "hidden buffer modified with !", commands: []string{"normal x", "e! " + none, "q!"}, wantQuit: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			w.FileContents = [][]byte{[]byte("abc")}
+			var err error
+			for _, c := range tt.commands {
+				if err = w.ExecuteLine(c); err != nil {
+					break
+				}
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("got: %v, want: %s", err, tt.wantErr)
+			}
+			if got := w.quitCode != nil; got != tt.wantQuit {
+				t.Errorf("got: quit %v, want: %v", got, tt.wantQuit)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gim/syntax"
+)
+
+// What the argument of an ex command is completed as, see exCommand.
+const (
+	completeNone = iota
+	completeFile
+	completeBuffer
+	completeOption
+	completeColorScheme
+	completeHelp
+)
+
+// completion is the candidates Tab goes through on the command line, ex) the files of :e src/
+type completion struct {
+	start int    // where the word completed starts in the command line
+	typed string // the word as typed, it comes back after the last candidate
+	items []string
+	index int // the candidate in the command line, len(items) for the word typed
+}
+
+// completeCommand replaces the word before the cursor with the next candidate, or the previous one
+// for Shift-Tab. A single candidate is just inserted, more are shown in the wildmenu.
+func (w *Window) completeCommand(forward bool) {
+	if w.commandPrompt() != ':' {
+		return
+	}
+	at := len(w.command) - w.commandRight
+	c := w.completion
+	if c == nil {
+		start, items := w.completions(string(w.command[:at]))
+		if len(items) == 0 {
+			return
+		}
+		c = &completion{start: start, typed: string(w.command[start:at]), items: items, index: len(items)}
+		if len(items) > 1 {
+			w.completion = c
+		}
+	}
+	if forward {
+		c.index = (c.index + 1) % (len(c.items) + 1)
+	} else {
+		c.index = (c.index + len(c.items)) % (len(c.items) + 1)
+	}
+	text := c.typed
+	if c.index < len(c.items) {
+		text = c.items[c.index]
+	}
+	w.command = concat(w.command[:c.start], []byte(text), w.command[at:])
+}
+
+// completions returns the candidates for the end of line and where the word they replace starts:
+// a command name, or the argument of the command.
+func (w *Window) completions(line string) (int, []string) {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == ':') {
+		i++
+	}
+	_, rest, err := w.parseRange(line[i:])
+	if err != nil {
+		return 0, nil
+	}
+	i = len(line) - len(strings.TrimLeft(rest, " \t"))
+	end := i
+	for end < len(line) && ('a' <= line[end] && line[end] <= 'z' || 'A' <= line[end] && line[end] <= 'Z') {
+		end++
+	}
+	if end == len(line) {
+		var names []string
+		for _, c := range exCommands {
+			if strings.HasPrefix(c.name, line[i:]) {
+				names = append(names, c.name)
+			}
+		}
+		return i, names
+	}
+	cmd := findExCommand(line[i:end])
+	if cmd == nil {
+		return 0, nil
+	}
+	if line[end] == '!' {
+		end++
+	}
+	if end == len(line) || line[end] != ' ' {
+		return 0, nil
+	}
+	// the last word of the arguments, ex) mouse= of :set nomouse mouse=
+	start := strings.LastIndexByte(line, ' ') + 1
+	word := line[start:]
+	var items []string
+	switch cmd.complete {
+	case completeFile:
+		items = completeFiles(word)
+	case completeBuffer:
+		for _, name := range w.bufferNames() {
+			if name != "" && strings.Contains(name, word) {
+				items = append(items, name)
+			}
+		}
+	case completeOption:
+		items = w.completeOptions(word)
+	case completeColorScheme:
+		items = completeColorSchemes(word)
+	case completeHelp:
+		for _, tag := range helpTags() {
+			if strings.Contains(tag, word) {
+				items = append(items, tag)
+			}
+		}
+	}
+	return start, items
+}
+
+// completeFiles returns the files whose path starts with word, directories end with /.
+// Hidden files are candidates when word starts with a dot.
+func completeFiles(word string) []string {
+	dir, base := filepath.Split(word)
+	path := dir
+	if path == "" {
+		path = "."
+	}
+	path = expandHome(path)
+	infos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, base) || strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".") {
+			continue
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := os.Stat(filepath.Join(path, name)); err == nil {
+				info = target
+			}
+		}
+		if info.IsDir() {
+			name += "/"
+		}
+		files = append(files, dir+name)
+	}
+	return files
+}
+
+// completeColorSchemes returns the colour schemes that start with word, from files and built in.
+func completeColorSchemes(word string) []string {
+	found := make(map[string]bool)
+	for _, name := range syntax.SchemeNames() {
+		found[name] = true
+	}
+	for _, dir := range colorSchemeDirs() {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*.gim"))
+		for _, m := range matches {
+			found[strings.TrimSuffix(filepath.Base(m), ".gim")] = true
+		}
+	}
+	var names []string
+	for name := range found {
+		if strings.HasPrefix(name, word) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// drawWildMenu draws the candidates of the completion on the line above the command line,
+// the one in the command line highlighted. < and > tell there are more before or after them.
+func (w *Window) drawWildMenu() {
+	c := w.completion
+	row := w.Row - 2
+	if c == nil || row < 0 {
+		return
+	}
+	bar, selected := w.style("StatusLine"), w.style("WildMenu")
+	w.drawString(row, 0, strings.Repeat(" ", w.Column), bar)
+	width := func(items []string) int {
+		n := 0
+		for _, item := range items {
+			n += stringWidth(item) + 2
+		}
+		return n
+	}
+	first := 0
+	if c.index < len(c.items) {
+		for first < c.index && width(c.items[first:c.index+1])+2 > w.Column-2 {
+			first++
+		}
+	}
+	col := 0
+	if first > 0 {
+		col = w.drawString(row, col, "< ", bar)
+	}
+	for i := first; i < len(c.items); i++ {
+		if col+stringWidth(c.items[i])+2 > w.Column-1 {
+			w.drawString(row, w.Column-1, ">", bar)
+			break
+		}
+		style := bar
+		if i == c.index {
+			style = selected
+		}
+		col = w.drawString(row, col, c.items[i], style)
+		col = w.drawString(row, col, "  ", bar)
+	}
+}
+
+// stringWidth returns how many columns s takes on the screen.
+func stringWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += cellWidth(r)
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWindow_completeCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"main.go", "map.go", ".hidden"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "mod"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		wantCommand string
+	}{
+		{name: "command", input: ":mar\t", wantCommand: "marks"},
+		{name: "command after a range", input: ":2,3norm\t", wantCommand: "2,3normal"},
+		{name: "first of the commands", input: ":re\t", wantCommand: "read"},
+		{name: "next command", input: ":re\t\t", wantCommand: "registers"},
+		{name: "back to the text typed", input: ":re\t\t\t", wantCommand: "re"},
+		{name: "previous command", input: ":re\x1b[Z", wantCommand: "registers"},
+		{name: "other keys end the completion", input: ":re\tx\t", wantCommand: "readx"},
+		{name: "no candidates", input: ":xyz\t", wantCommand: "xyz"},
+		{name: "option", input: ":set mo\t", wantCommand: "set mouse"},
+		{name: "second option", input: ":set nomouse mat\t", wantCommand: "set nomouse matchpairs"},
+		{name: "option value", input: ":set mps=\t", wantCommand: "set mps=(:),{:},[:]"},
+		{name: "colour scheme", input: ":colo li\t", wantCommand: "colo light"},
+		{name: "help tag", input: ":h delm\t", wantCommand: "h :delmarks"},
+		{name: "file", input: ":e " + dir + "/mai\t", wantCommand: "e " + dir + "/main.go"},
+		{name: "files", input: ":w " + dir + "/m\t\t\t", wantCommand: "w " + dir + "/mod/"},
+		{name: "hidden file", input: ":r " + dir + "/.\t", wantCommand: "r " + dir + "/.hidden"},
+		{name: "buffer", input: ":e " + dir + "/main.go\r:e " + dir + "/map.go\r:b mai\t", wantCommand: "b " + dir + "/main.go"},
+		{name: "in the middle of the line", input: ":mar abc\x02\x1b[C\x1b[C\x1b[C\tX", wantCommand: "marksX abc"},
+		{name: "not in a search", input: "/ab\t", wantCommand: "ab"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, []string{"a", "b", "c"}, Position{X: 1, Y: 1}, tt.input)
+			if got := string(w.command); got != tt.wantCommand {
+				t.Errorf("got: %q, want: %q", got, tt.wantCommand)
+			}
+		})
+	}
+}
+
+func TestCompleteFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.txt", "b.txt", ".a"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "sub"), filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		word string
+		want []string
+	}{
+		{name: "every file", word: dir + "/", want: []string{dir + "/a.txt", dir + "/b.txt", dir + "/link/", dir + "/sub/"}},
+		{name: "prefix", word: dir + "/a", want: []string{dir + "/a.txt"}},
+		{name: "hidden", word: dir + "/.", want: []string{dir + "/.a"}},
+		{name: "no directory", word: dir + "/none/", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completeFiles(tt.word); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow_drawWildMenu(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns int
+		command string
+		tabs    int
+		wantRow string
+		wantSel int
+	}{
+		{name: "candidates", columns: 30, command: "re", tabs: 1, wantRow: "read  registers", wantSel: 0},
+		{name: "second candidate", columns: 30, command: "re", tabs: 2, wantRow: "read  registers", wantSel: 6},
+		{name: "more after", columns: 20, command: "c", tabs: 1, wantRow: "cabbrev  cmap      >", wantSel: 0},
+		{name: "more before", columns: 20, command: "c", tabs: 5, wantRow: "< cnoremap         >", wantSel: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(tt.columns, 4)
+			w := NewWindow(term)
+			w.SetSize()
+			w.FileContents = [][]byte{[]byte("a")}
+			w.startCommandLine(':')
+			w.AddCommand([]byte(tt.command))
+			for i := 0; i < tt.tabs; i++ {
+				w.completeCommand(true)
+			}
+			w.PrintFileContents()
+			if got := term.Row(2); got != tt.wantRow {
+				t.Errorf("got: %q, want: %q", got, tt.wantRow)
+			}
+			if got, want := term.Cell(2, tt.wantSel).Style, w.style("WildMenu"); got != want {
+				t.Errorf("got: style %+v, want: %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package window
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The config files are ex commands executed at startup, ex) set mouse=a
+// The user config is $XDG_CONFIG_HOME/gim/gimrc or ~/.gimrc. A .gimrc in the current directory
+// is executed too, once it is trusted: the trust file keeps a hash of its contents, so it has to
+// be trusted again after it changes.
+
+// ProjectConfigName is the name of the config file of a project, in the directory gim is started in.
+const ProjectConfigName = ".gimrc"
+
+// configDir returns $XDG_CONFIG_HOME, ex) ~/.config
+func configDir() string {
+	if config := os.Getenv("XDG_CONFIG_HOME"); config != "" {
+		return config
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config")
+}
+
+// stateDir returns $XDG_STATE_HOME, ex) ~/.local/state
+func stateDir() string {
+	if state := os.Getenv("XDG_STATE_HOME"); state != "" {
+		return state
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state")
+}
+
+// ConfigFile returns the user config file: $XDG_CONFIG_HOME/gim/gimrc, or ~/.gimrc when it does not exist.
+// It returns "" when there is neither.
+func ConfigFile() string {
+	var files []string
+	if config := configDir(); config != "" {
+		files = append(files, filepath.Join(config, "gim", "gimrc"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(home, ".gimrc"))
+	}
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && !info.IsDir() {
+			return f
+		}
+	}
+	return ""
+}
+
+// ProjectConfigFile returns the .gimrc of the current directory, unless it is the user config file.
+// It returns "" when there is none.
+func ProjectConfigFile(userConfig string) string {
+	info, err := os.Stat(ProjectConfigName)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	if user, err := os.Stat(userConfig); err == nil && os.SameFile(info, user) {
+		return ""
+	}
+	path, err := filepath.Abs(ProjectConfigName)
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// expandHome replaces ~/ at the start of path with the home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
+
+// Source executes the ex commands of the file path, the first error is shown on the message line.
+func (w *Window) Source(path string) error {
+	err := w.source(path)
+	if err != nil {
+		w.showError(err)
+	}
+	return err
+}
+
+func (w *Window) source(path string) error {
+	f, err := os.Open(expandHome(path))
+	if err != nil {
+		return fmt.Errorf("E484: Can't open file %s", path)
+	}
+	defer f.Close()
+	return w.sourceLines(path, f)
+}
+
+// sourceCommand is :source, it executes the ex commands of a file, ex) :so ~/.gimrc
+func (w *Window) sourceCommand(c exArgs) error {
+	if c.args == "" {
+		return errors.New("E471: Argument required")
+	}
+	return w.source(c.args)
+}
+
+// TrustFile returns the file the trusted project config files are kept in, ex) ~/.local/state/gim/trust
+func TrustFile() string {
+	state := stateDir()
+	if state == "" {
+		return ""
+	}
+	return filepath.Join(state, "gim", "trust")
+}
+
+// fileHash returns the SHA-256 of the contents of the file path, in hex.
+func fileHash(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readTrust returns the lines of the trust file: a hash and the path of the file trusted, ex) 3a7b... /src/gim/.gimrc
+func readTrust(trustFile string) ([]string, error) {
+	f, err := os.Open(trustFile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var lines []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, sc.Err()
+}
+
+// Trusted reports whether the file path is trusted with its current contents.
+func Trusted(trustFile, path string) (bool, error) {
+	hash, err := fileHash(path)
+	if err != nil {
+		return false, err
+	}
+	lines, err := readTrust(trustFile)
+	if err != nil {
+		return false, err
+	}
+	for _, l := range lines {
+		if l == hash+" "+path {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Trust records the file path as trusted with its current contents.
+func Trust(trustFile, path string) error {
+	hash, err := fileHash(path)
+	if err != nil {
+		return err
+	}
+	lines, err := readTrust(trustFile)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	for _, l := range lines {
+		// the hash of the old contents is not trusted any longer
+		if i := strings.IndexByte(l, ' '); i >= 0 && l[i+1:] == path {
+			continue
+		}
+		b.WriteString(l + "\n")
+	}
+	b.WriteString(hash + " " + path + "\n")
+	if err := os.MkdirAll(filepath.Dir(trustFile), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(trustFile, []byte(b.String()), 0600)
+}
@@ -0,0 +1,181 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setenv sets the environment variable key for a test, it returns a function restoring it.
+func setenv(key, value string) func() {
+	old, ok := os.LookupEnv(key)
+	os.Setenv(key, value)
+	return func() {
+		if ok {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer setenv("HOME", filepath.Join(dir, "home"))()
+	defer setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))()
+	xdg, home := filepath.Join(dir, "config", "gim", "gimrc"), filepath.Join(dir, "home", ".gimrc")
+
+	if got := ConfigFile(); got != "" {
+		t.Errorf("got: %q, want: no file", got)
+	}
+	if err := os.MkdirAll(filepath.Dir(home), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(home, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConfigFile(); got != home {
+		t.Errorf("got: %q, want: %q", got, home)
+	}
+	if err := os.MkdirAll(filepath.Dir(xdg), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(xdg, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := ConfigFile(); got != xdg {
+		t.Errorf("got: %q, want: %q", got, xdg)
+	}
+}
+
+func TestWindow_sourceCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gimrc")
+	if err := ioutil.WriteFile(path, []byte("\" options\nset mps=<:>\nfoo\nset nomouse\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		command        string
+		wantErr        string
+		wantMatchpairs string
+	}{
+		{name: "source", command: "so " + path, wantErr: "Error detected while processing " + path + " line 3: E492: Not an editor command: foo", wantMatchpairs: "<:>"},
+		{name: "no file", command: "source", wantErr: "E471: Argument required", wantMatchpairs: defaultMatchPairs},
+		{name: "file not found", command: "source " + filepath.Join(dir, "none"), wantErr: "E484: Can't open file " + filepath.Join(dir, "none"), wantMatchpairs: defaultMatchPairs},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			w.options = optionValues{"mouse": {s: "a"}}
+			err := w.ExecuteLine(tt.command)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("got: %v, want: %s", err, tt.wantErr)
+			}
+			if got := w.stringOption("matchpairs"); got != tt.wantMatchpairs {
+				t.Errorf("got: %q, want: %q", got, tt.wantMatchpairs)
+			}
+		})
+	}
+}
+
+func TestTrust(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	trustFile := filepath.Join(dir, "state", "trust")
+	config, other := filepath.Join(dir, ".gimrc"), filepath.Join(dir, "other")
+	for _, f := range []string{config, other} {
+		if err := ioutil.WriteFile(f, []byte("set mouse=a\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	trusted := func(path string) bool {
+		t.Helper()
+		ok, err := Trusted(trustFile, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ok
+	}
+	if trusted(config) {
+		t.Errorf("got: trusted, want: not trusted before Trust")
+	}
+	if err := Trust(trustFile, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := Trust(trustFile, other); err != nil {
+		t.Fatal(err)
+	}
+	if !trusted(config) || !trusted(other) {
+		t.Errorf("got: not trusted, want: trusted after Trust")
+	}
+	if err := ioutil.WriteFile(config, []byte("set mouse=\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if trusted(config) {
+		t.Errorf("got: trusted, want: not trusted after a change")
+	}
+	if err := Trust(trustFile, config); err != nil {
+		t.Fatal(err)
+	}
+	if !trusted(config) || !trusted(other) {
+		t.Errorf("got: not trusted, want: trusted again")
+	}
+	lines, err := readTrust(trustFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 {
+		t.Errorf("got: %q, want: a line for each file", lines)
+	}
+	if info, err := os.Stat(trustFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("got: %v, want: a file only the user can read", info.Mode())
+	}
+}
+
+func TestProjectConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ProjectConfigFile(""); got != "" {
+		t.Errorf("got: %q, want: none", got)
+	}
+	if err := ioutil.WriteFile(ProjectConfigName, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ProjectConfigFile(""), filepath.Join(dir, ProjectConfigName); got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got := ProjectConfigFile(filepath.Join(dir, ProjectConfigName)); got != "" {
+		t.Errorf("got: %q, want: none for the user config", got)
+	}
+}
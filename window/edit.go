@@ -0,0 +1,177 @@
+package window
+
+import "unicode/utf8"
+
+// The buffer is changed only through these functions. They never modify a line in place:
+// a changed line is a new slice, so the undo history can keep the old lines without copying them.
+// They also tell the highlighter which lines changed.
+
+// insertText inserts text before the x-th byte (1-indexed) of the y-th line and returns
+// the position after it. Line breaks in text, \n, \r or \r\n, split the line.
+func (w *Window) insertText(pos Position, text []byte) Position {
+	return w.insertParts(pos, splitLines(text))
+}
+
+// insertParts inserts the lines of text: the first one is inserted in the line of pos
+// and the last one before the rest of that line.
+func (w *Window) insertParts(pos Position, parts [][]byte) Position {
+	if len(w.FileContents) == 0 {
+		w.FileContents = [][]byte{{}}
+	}
+	line := w.FileContents[pos.Y-1]
+	before, after := line[:pos.X-1], line[pos.X-1:]
+	if len(parts) == 1 {
+		w.setLine(pos.Y-1, concat(before, parts[0], after))
+		return Position{X: pos.X + len(parts[0]), Y: pos.Y}
+	}
+	last := parts[len(parts)-1]
+	lines := make([][]byte, len(parts))
+	lines[0] = concat(before, parts[0])
+	copy(lines[1:], parts[1:len(parts)-1])
+	lines[len(lines)-1] = concat(last, after)
+	w.setLine(pos.Y-1, lines[0])
+	w.insertLines(pos.Y, lines[1:])
+	return Position{X: len(last) + 1, Y: pos.Y + len(parts) - 1}
+}
+
+// splitLines splits text at \n, \r and \r\n.
+func splitLines(text []byte) [][]byte {
+	var lines [][]byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\n' && text[i] != '\r' {
+			continue
+		}
+		lines = append(lines, text[start:i])
+		if text[i] == '\r' && i+1 < len(text) && text[i+1] == '\n' {
+			i++
+		}
+		start = i + 1
+	}
+	return append(lines, text[start:])
+}
+
+func concat(parts ...[]byte) []byte {
+	n := 0
+	for _, p := range parts {
+		n += len(p)
+	}
+	b := make([]byte, 0, n)
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// setLine replaces the i-th line (0-indexed).
+func (w *Window) setLine(i int, line []byte) {
+	from, to := changedSpan(w.FileContents[i], line)
+	w.FileContents[i] = line
+	end := from + 1
+	if to > from {
+		end = runesBefore(line, to, 1) + 1
+	}
+	w.changed(Position{X: from + 1, Y: i + 1}, Position{X: end, Y: i + 1})
+	if w.highlighter != nil {
+		w.highlighter.Invalidate(i)
+	}
+	if w.indentCache != nil {
+		w.indentCache.Invalidate(i)
+	}
+}
+
+// insertLines inserts lines before the i-th line (0-indexed).
+func (w *Window) insertLines(i int, lines [][]byte) {
+	contents := make([][]byte, 0, len(w.FileContents)+len(lines))
+	contents = append(contents, w.FileContents[:i]...)
+	contents = append(contents, lines...)
+	w.FileContents = append(contents, w.FileContents[i:]...)
+	if w.highlighter != nil {
+		w.highlighter.InsertLines(i, len(lines))
+	}
+	if w.indentCache != nil {
+		w.indentCache.Invalidate(i)
+	}
+	w.adjustMarks(i, len(lines))
+	if len(lines) > 0 {
+		last := lines[len(lines)-1]
+		w.changed(Position{X: 1, Y: i + 1}, Position{X: runesBefore(last, len(last), 1) + 1, Y: i + len(lines)})
+	}
+}
+
+// Paste inserts text pasted in the terminal as one undoable change.
+// Unlike typed text it is inserted as is: nothing in it is taken as a command.
+func (w *Window) Paste(text []byte) {
+	switch w.mode {
+	case commandMode:
+		// only the first line fits on the command line
+		w.AddCommand(splitLines(text)[0])
+	case insertMode:
+		if len(text) == 0 {
+			return
+		}
+		w.saveUndo()
+		w.position = w.insertText(w.position, text)
+		// text typed after the paste is another change
+		w.changing = false
+	case normalMode:
+		if len(text) == 0 {
+			return
+		}
+		// as if typed after i, the cursor stays on the last character pasted
+		w.saveUndo()
+		w.position = w.insertText(w.position, text)
+		_, size := utf8.DecodeLastRune(w.FileContents[w.position.Y-1][:w.position.X-1])
+		w.position.X -= size
+	}
+}
+
+// deleteText deletes the text from from up to to, which is not deleted.
+// They may be on different lines: the rest of the last line is joined to the first.
+func (w *Window) deleteText(from, to Position) {
+	first, last := w.FileContents[from.Y-1], w.FileContents[to.Y-1]
+	w.deleteLines(from.Y, to.Y-from.Y)
+	w.setLine(from.Y-1, concat(first[:from.X-1], last[to.X-1:]))
+}
+
+// deleteLines deletes n lines from the i-th line (0-indexed).
+func (w *Window) deleteLines(i, n int) {
+	if n <= 0 {
+		return
+	}
+	contents := make([][]byte, 0, len(w.FileContents)-n)
+	contents = append(contents, w.FileContents[:i]...)
+	w.FileContents = append(contents, w.FileContents[i+n:]...)
+	if w.highlighter != nil {
+		w.highlighter.DeleteLines(i, n)
+	}
+	if w.indentCache != nil {
+		w.indentCache.Invalidate(i)
+	}
+	w.adjustMarks(i, -n)
+	if y := i + 1; y <= len(w.FileContents) {
+		w.changed(Position{X: 1, Y: y}, Position{X: 1, Y: y})
+	} else if y > 1 {
+		w.changed(Position{X: 1, Y: y - 1}, Position{X: 1, Y: y - 1})
+	}
+}
+
+// runesAfter returns the index of the byte after n characters from the i-th byte (0-indexed)
+// of line, or the end of the line when it has fewer characters.
+func runesAfter(line []byte, i, n int) int {
+	for ; n > 0 && i < len(line); n-- {
+		_, size := utf8.DecodeRune(line[i:])
+		i += size
+	}
+	return i
+}
+
+// runesBefore returns the index of the byte n characters before the i-th byte (0-indexed) of line,
+// or 0 when it has fewer characters.
+func runesBefore(line []byte, i, n int) int {
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRune(line[:i])
+		i -= size
+	}
+	return i
+}
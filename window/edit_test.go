@@ -0,0 +1,91 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_insertText(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		pos          Position
+		text         string
+		wantLines    []string
+		wantPosition Position
+	}{
+		{name: "in a line", lines: []string{"abc"}, pos: Position{X: 2, Y: 1}, text: "xy", wantLines: []string{"axybc"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "at the end", lines: []string{"abc"}, pos: Position{X: 4, Y: 1}, text: "d", wantLines: []string{"abcd"}, wantPosition: Position{X: 5, Y: 1}},
+		{
+			name:         "line breaks",
+			lines:        []string{"ab", "z"},
+			pos:          Position{X: 2, Y: 1},
+			text:         "1\r2\r\n3\n4",
+			wantLines:    []string{"a1", "2", "3", "4b", "z"},
+			wantPosition: Position{X: 2, Y: 4},
+		},
+		{name: "ends with a line break", lines: []string{"ab"}, pos: Position{X: 3, Y: 1}, text: "c\n", wantLines: []string{"abc", ""}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "empty buffer", lines: nil, pos: Position{X: 1, Y: 1}, text: "new", wantLines: []string{"new"}, wantPosition: Position{X: 4, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Window{}
+			for _, l := range tt.lines {
+				w.FileContents = append(w.FileContents, []byte(l))
+			}
+			before := w.snapshot()
+			pos := w.insertText(tt.pos, []byte(tt.text))
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if pos != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", pos, tt.wantPosition)
+			}
+			if got := linesOf(before.lines); !reflect.DeepEqual(got, tt.lines) && len(tt.lines) > 0 {
+				t.Errorf("got: %q before the change, want: %q", got, tt.lines)
+			}
+		})
+	}
+}
+
+func linesOf(contents [][]byte) []string {
+	var lines []string
+	for _, l := range contents {
+		lines = append(lines, string(l))
+	}
+	return lines
+}
+
+func TestWindow_Paste(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         int
+		text         string
+		wantLines    []string
+		wantPosition Position
+		wantCommand  string
+	}{
+		{name: "insert mode", mode: insertMode, text: "x\ry", wantLines: []string{"ax", "ybc"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "normal mode", mode: normalMode, text: "xあ", wantLines: []string{"axあbc"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "keys are not commands", mode: normalMode, text: ":q\r", wantLines: []string{"a:q", "bc"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "command mode", mode: commandMode, text: "set\rfoo", wantLines: []string{"abc"}, wantPosition: Position{X: 2, Y: 1}, wantCommand: "set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.FileContents = [][]byte{[]byte("abc")}
+			w.position = Position{X: 2, Y: 1}
+			w.mode = tt.mode
+			w.Paste([]byte(tt.text))
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if string(w.command) != tt.wantCommand {
+				t.Errorf("got: command %q, want: %q", w.command, tt.wantCommand)
+			}
+		})
+	}
+}
@@ -0,0 +1,388 @@
+package window
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// The files edited are buffers: the one shown is in the fields of the window, the others are
+// kept in buffers with their changes, ex) after :edit other.go
+// A modified buffer is left only with !, ex) :edit! other.go
+// A file that does not exist is edited as a new file, it is made when it is written. A new or empty
+// file is a buffer of one empty line as every buffer has a line, it is written empty until it is changed.
+
+var (
+	errNoFileName = errors.New("E32: No file name")
+	errModified   = errors.New("E37: No write since last change (add ! to override)")
+)
+
+// bufferName returns the name a buffer is shown with, ex) [No Name] when it has no file
+func bufferName(fileName string) string {
+	if fileName == "" {
+		return "[No Name]"
+	}
+	return fileName
+}
+
+// fileInfo returns the message shown after the file name is read or written, ex) "a.go" 3L, 20B
+// A file not stored as unix lines says how it is after the tags, ex) "a.txt" [New][noeol][dos] 3L, 20B
+func fileInfo(name string, lines [][]byte, f fileFormat, tags string) string {
+	info := tags + formatInfo(f)
+	if info != "" {
+		info += " "
+	}
+	return fmt.Sprintf("\"%s\" %s%dL, %dB", name, info, len(lines), len(encodeLines(lines, f)))
+}
+
+// newFileInfo returns the message shown when a file that does not exist is edited.
+func newFileInfo(name string) string {
+	return fmt.Sprintf("\"%s\" [New File]", name)
+}
+
+// openLines reads the lines of the file name to edit it. newFile is true when the file
+// does not exist, it has one empty line then as an empty file has.
+func openLines(name string) (lines [][]byte, f fileFormat, newFile bool, err error) {
+	lines, f, err = readLines(name)
+	switch {
+	case os.IsNotExist(err):
+		return [][]byte{{}}, fileFormat{format: "unix", eol: true}, true, nil
+	case err != nil:
+		return nil, f, false, readError(name, err)
+	}
+	if len(lines) == 0 {
+		lines = [][]byte{{}}
+	}
+	return lines, f, false, nil
+}
+
+// fileLines returns the lines of the buffer written to its file: none when it is the empty line
+// of a new or empty file that is not changed.
+func (w *Window) fileLines() [][]byte {
+	if !w.modified && w.fileStamp.size == 0 && w.isEmpty() {
+		return nil
+	}
+	return w.FileContents
+}
+
+// isEmpty reports whether the buffer has nothing but one empty line.
+func (w *Window) isEmpty() bool {
+	return len(w.FileContents) == 0 || len(w.FileContents) == 1 && len(w.FileContents[0]) == 0
+}
+
+// readError returns the error shown when the file name cannot be read, ex) it is a directory
+func readError(name string, err error) error {
+	if info, e := os.Stat(name); e == nil && info.IsDir() {
+		return fmt.Errorf("\"%s\" is a directory", name)
+	}
+	if os.IsPermission(err) {
+		return fmt.Errorf("\"%s\" [Permission Denied]", name)
+	}
+	return fmt.Errorf("E484: Can't open file %s", name)
+}
+
+// writeError returns the error shown when the file name cannot be written.
+func writeError(name string, err error) error {
+	if info, e := os.Stat(name); e == nil && info.IsDir() {
+		return fmt.Errorf("E502: \"%s\" is a directory", name)
+	}
+	if os.IsPermission(err) {
+		return fmt.Errorf("E212: Can't open file for writing: \"%s\" [Permission Denied]", name)
+	}
+	if os.IsNotExist(err) {
+		return fmt.Errorf("E212: Can't open file for writing: no directory %s (add ++p to make it)", filepath.Dir(name))
+	}
+	return fmt.Errorf("E212: Can't open file for writing: %v", err)
+}
+
+// editCommand is :edit, it edits the file of its argument, or reads the file of the buffer again.
+func (w *Window) editCommand(c exArgs) error {
+	name := c.args
+	if name == "" || name == w.fileName {
+		if w.fileName == "" {
+			return errNoFileName
+		}
+		if w.modified && !c.bang {
+			return errModified
+		}
+		return w.reload()
+	}
+	if b := w.findBufferByName(name); b != nil {
+		return w.switchBuffer(b, c.bang)
+	}
+	if w.modified && !c.bang {
+		return errModified
+	}
+	lines, f, newFile, err := openLines(name)
+	if err != nil {
+		return err
+	}
+	if w.fileName == "" && !w.modified && w.isEmpty() {
+		// the empty buffer the editor started with is used for the file
+		w.takeBuffer()
+	} else {
+		w.hideBuffer()
+		w.lastBuffer++
+	}
+	w.bufferNumber = w.lastBuffer
+	w.FileContents = lines
+	w.fileName = name
+	w.fileStamp = statFile(name)
+	w.setFileFormat(f)
+	w.detectFileType()
+	if newFile {
+		w.showMessage(newFileInfo(name), "")
+	} else {
+		w.showMessage(fileInfo(name, w.fileLines(), f, ""), "")
+	}
+	return nil
+}
+
+// reload reads the file of the buffer again, as a change that can be undone.
+func (w *Window) reload() error {
+	lines, f, newFile, err := openLines(w.fileName)
+	if err != nil {
+		return err
+	}
+	w.saveUndo()
+	w.FileContents = lines
+	w.fileStamp = statFile(w.fileName)
+	w.setFileFormat(f)
+	w.modified = false
+	if w.highlighter != nil {
+		w.highlighter.Reset()
+	}
+	if w.indentCache != nil {
+		w.indentCache.Reset()
+	}
+	if w.position.Y > len(lines) {
+		w.position.Y = len(lines)
+	}
+	w.clampCursor()
+	if newFile {
+		w.showMessage(newFileInfo(w.fileName), "")
+	} else {
+		w.showMessage(fileInfo(w.fileName, w.fileLines(), f, ""), "")
+	}
+	return nil
+}
+
+// hideBuffer keeps the buffer shown in buffers, the window is left with an empty one.
+func (w *Window) hideBuffer() {
+	b := w.takeBuffer()
+	i := 0
+	for i < len(w.buffers) && w.buffers[i].number < b.number {
+		i++
+	}
+	w.buffers = append(w.buffers, nil)
+	copy(w.buffers[i+1:], w.buffers[i:])
+	w.buffers[i] = b
+}
+
+// switchBuffer shows the buffer b, which is one of buffers.
+func (w *Window) switchBuffer(b *buffer, bang bool) error {
+	if w.modified && !bang {
+		return errModified
+	}
+	for i, h := range w.buffers {
+		if h == b {
+			w.buffers = append(w.buffers[:i:i], w.buffers[i+1:]...)
+			break
+		}
+	}
+	w.hideBuffer()
+	w.setBuffer(b)
+	w.showMessage(fileInfo(w.fileName, w.FileContents, w.fileFormat(), ""), "")
+	return nil
+}
+
+// findBufferByName returns the buffer not shown of the file name.
+func (w *Window) findBufferByName(name string) *buffer {
+	for _, b := range w.buffers {
+		if b.fileName == name {
+			return b
+		}
+	}
+	return nil
+}
+
+// bufferCommand is :buffer, it shows the buffer of a number, or of a name that contains its argument.
+func (w *Window) bufferCommand(c exArgs) error {
+	if c.args == "" {
+		return nil
+	}
+	if n, err := strconv.Atoi(c.args); err == nil {
+		if n == w.bufferNumber {
+			return nil
+		}
+		for _, b := range w.buffers {
+			if b.number == n {
+				return w.switchBuffer(b, c.bang)
+			}
+		}
+		return fmt.Errorf("E86: Buffer %d does not exist", n)
+	}
+	if c.args == w.fileName {
+		return nil
+	}
+	if b := w.findBufferByName(c.args); b != nil {
+		return w.switchBuffer(b, c.bang)
+	}
+	var found []*buffer
+	current := strings.Contains(w.fileName, c.args)
+	for _, b := range w.buffers {
+		if strings.Contains(b.fileName, c.args) {
+			found = append(found, b)
+		}
+	}
+	switch {
+	case len(found) == 0 && current:
+		return nil
+	case len(found) == 0:
+		return fmt.Errorf("E94: No matching buffer for %s", c.args)
+	case len(found) > 1 || current:
+		return fmt.Errorf("E93: More than one match for %s", c.args)
+	}
+	return w.switchBuffer(found[0], c.bang)
+}
+
+// bufferNames returns the file names of the buffers, by number.
+func (w *Window) bufferNames() []string {
+	var names []string
+	shown := false
+	for _, b := range w.buffers {
+		if !shown && b.number > w.bufferNumber {
+			names = append(names, w.fileName)
+			shown = true
+		}
+		names = append(names, b.fileName)
+	}
+	if !shown {
+		names = append(names, w.fileName)
+	}
+	return names
+}
+
+// lsCommand is :ls, it lists the buffers: % is the one shown, h the hidden ones and + the modified ones.
+func (w *Window) lsCommand(c exArgs) error {
+	type entry struct {
+		number   int
+		name     string
+		flags    string
+		line     int
+		modified bool
+	}
+	var entries []entry
+	for _, b := range w.buffers {
+		entries = append(entries, entry{number: b.number, name: b.fileName, flags: " h", line: b.position.Y, modified: b.modified})
+	}
+	i := 0
+	for i < len(entries) && entries[i].number < w.bufferNumber {
+		i++
+	}
+	current := entry{number: w.bufferNumber, name: w.fileName, flags: "%a", line: w.position.Y, modified: w.modified}
+	entries = append(entries[:i], append([]entry{current}, entries[i:]...)...)
+	var out []string
+	for _, e := range entries {
+		name := bufferName(e.name)
+		plus := " "
+		if e.modified {
+			plus = "+"
+		}
+		out = append(out, fmt.Sprintf("%3d %s %s %-18s line %d", e.number, e.flags, plus, "\""+name+"\"", e.line))
+	}
+	w.showMessage(strings.Join(out, "\n"), "")
+	return nil
+}
+
+// writeCommand is :write, it writes the buffer to its file or to the file of its argument.
+// The buffer is given the file name when it has none. ++p makes the directories of the file,
+// ex) :w ++p new/dir/a.go
+func (w *Window) writeCommand(c exArgs) error {
+	name, makeDirs := c.args, false
+	if name == "++p" || strings.HasPrefix(name, "++p ") {
+		name, makeDirs = strings.TrimSpace(name[3:]), true
+	}
+	if name == "" {
+		name = w.fileName
+	}
+	if name == "" {
+		return errNoFileName
+	}
+	if name != w.fileName && !c.bang {
+		if _, err := os.Stat(name); err == nil {
+			return errors.New("E13: File exists (add ! to override)")
+		}
+	}
+	if name == w.fileName && !c.bang && w.changedOnDisk() {
+		return fmt.Errorf("W11: Warning: File \"%s\" has changed since editing started (add ! to override)", name)
+	}
+	if makeDirs {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return fmt.Errorf("E212: Can't open file for writing: %v", err)
+		}
+	}
+	tags := ""
+	if _, err := os.Stat(name); os.IsNotExist(err) {
+		tags = "[New]"
+	}
+	f := w.fileFormat()
+	lines := w.fileLines()
+	if err := w.writeFile(name, encodeLines(lines, f), c.bang); err != nil {
+		if err == errBackup {
+			return err
+		}
+		return writeError(name, err)
+	}
+	if w.fileName == "" {
+		w.fileName = name
+		w.detectFileType()
+	}
+	if name == w.fileName {
+		w.modified = false
+		w.fileStamp = statFile(name)
+	}
+	w.showMessage(fileInfo(name, lines, f, tags)+" written", "")
+	return nil
+}
+
+// wqCommand is :wq, it writes the buffer and quits.
+func (w *Window) wqCommand(c exArgs) error {
+	if err := w.writeCommand(c); err != nil {
+		return err
+	}
+	return w.quitCommand(c)
+}
+
+// readCommand is :read, it inserts the lines of the file of its argument below the cursor line,
+// or below the line of its range, ex) :$read footer.txt
+func (w *Window) readCommand(c exArgs) error {
+	name := c.args
+	if name == "" {
+		name = w.fileName
+	}
+	if name == "" {
+		return errNoFileName
+	}
+	lines, _, err := readLines(name)
+	if err != nil {
+		return fmt.Errorf("E484: Can't open file %s", name)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	at := w.position.Y
+	if c.given {
+		at = c.last
+	}
+	if at > len(w.FileContents) {
+		at = len(w.FileContents)
+	}
+	w.saveUndo()
+	w.insertLines(at, lines)
+	w.position = Position{X: w.firstNonBlank(at + 1), Y: at + 1}
+	return nil
+}
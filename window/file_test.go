@@ -0,0 +1,299 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWindow_FileCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	one, two := filepath.Join(dir, "one.txt"), filepath.Join(dir, "two.txt")
+
+	tests := []struct {
+		name         string
+		commands     []string
+		wantLines    []string
+		wantPosition Position
+		wantFile     string
+		wantErr      string
+		wantMessage  string
+	}{
+		{name: "edit", commands: []string{"e " + two}, wantLines: []string{"x", "y"}, wantPosition: Position{X: 1, Y: 1}, wantFile: two, wantMessage: "\"" + two + "\" 2L, 4B"},
+		{name: "modified", commands: []string{"normal x", "e " + two}, wantLines: []string{"bc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one, wantErr: "E37: No write since last change (add ! to override)"},
+		{name: "modified with !", commands: []string{"normal x", "e! " + two, "b 1"}, wantLines: []string{"bc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one},
+		{name: "edit again", commands: []string{"normal x", "e!"}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one},
+		{name: "undo edit again", commands: []string{"normal x", "e!", "normal u"}, wantLines: []string{"bc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one},
+		{name: "back to a buffer", commands: []string{"2", "e " + two, "b 1"}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 2}, wantFile: one},
+		{name: "buffer by name", commands: []string{"e " + two, "e " + one, "b two"}, wantLines: []string{"x", "y"}, wantPosition: Position{X: 1, Y: 1}, wantFile: two},
+		{name: "no buffer", commands: []string{"b 9"}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one, wantErr: "E86: Buffer 9 does not exist"},
+		{name: "no matching buffer", commands: []string{"b zzz"}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one, wantErr: "E94: No matching buffer for zzz"},
+		{name: "more than one buffer", commands: []string{"e " + two, "b .txt"}, wantLines: []string{"x", "y"}, wantPosition: Position{X: 1, Y: 1}, wantFile: two, wantErr: "E93: More than one match for .txt"},
+		{name: "new file", commands: []string{"e " + filepath.Join(dir, "none")}, wantLines: []string{""}, wantPosition: Position{X: 1, Y: 1}, wantFile: filepath.Join(dir, "none"), wantMessage: "\"" + filepath.Join(dir, "none") + "\" [New File]"},
+		{name: "directory", commands: []string{"e " + dir}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one, wantErr: "\"" + dir + "\" is a directory"},
+		{name: "cannot read", commands: []string{"r " + filepath.Join(dir, "none")}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one, wantErr: "E484: Can't open file " + filepath.Join(dir, "none")},
+		{name: "read", commands: []string{"r " + two}, wantLines: []string{"abc", "x", "y", "def"}, wantPosition: Position{X: 1, Y: 2}, wantFile: one},
+		{name: "read below the last line", commands: []string{"$r " + two}, wantLines: []string{"abc", "def", "x", "y"}, wantPosition: Position{X: 1, Y: 3}, wantFile: one},
+		{name: "write another file", commands: []string{"w " + two}, wantLines: []string{"abc", "def"}, wantPosition: Position{X: 1, Y: 1}, wantFile: one, wantErr: "E13: File exists (add ! to override)"},
+		{name: "list", commands: []string{"e " + two, "normal x", "ls"}, wantLines: []string{"", "y"}, wantPosition: Position{X: 1, Y: 1}, wantFile: two, wantMessage: strings.Join([]string{
+			"  1  h   \"" + one + "\" line 1",
+			"  2 %a + \"" + two + "\" line 1",
+		}, "\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(one, []byte("abc\ndef\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(two, []byte("x\ny\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			w := NewWindow(NewSimTerminal(80, 24))
+			w.Size = Size{Row: 10, Column: 80}
+			if err := w.SetFileContents(one); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tt.commands {
+				if err = w.ExecuteLine(c); err != nil {
+					break
+				}
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("got: %v, want: %s", err, tt.wantErr)
+			}
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.fileName != tt.wantFile {
+				t.Errorf("got: file %q, want: %q", w.fileName, tt.wantFile)
+			}
+			if tt.wantMessage != "" && w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_writeCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "new.txt")
+
+	w := NewWindow(NewSimTerminal(80, 24))
+	w.FileContents = [][]byte{[]byte("a"), []byte("bc")}
+	w.modified = true
+	if err := w.ExecuteLine("w"); err == nil || err.Error() != "E32: No file name" {
+		t.Errorf("got: %v, want: E32: No file name", err)
+	}
+	if err := w.ExecuteLine("w " + path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "a\nbc\n" {
+		t.Errorf("got: %q, want: %q", b, "a\nbc\n")
+	}
+	if w.fileName != path || w.modified {
+		t.Errorf("got: file %q modified %v, want: %q not modified", w.fileName, w.modified, path)
+	}
+	if want := "\"" + path + "\" [New] 2L, 5B written"; w.message != want {
+		t.Errorf("got: %q, want: %q", w.message, want)
+	}
+}
+
+func TestWindow_helpCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        string
+		wantMessage string
+		wantErr     string
+	}{
+		{name: "command", args: ":marks", wantMessage: ":marks  list the marks, ex) :marks aB"},
+		{name: "command without colon", args: "marks", wantMessage: ":marks  list the marks, ex) :marks aB"},
+		{name: "option", args: "mouse", wantMessage: "'mouse'  the modes the mouse is used in, ex) :set mouse=a"},
+		{name: "prefix", args: "'mat", wantMessage: "'matchpairs'  the pairs % jumps between, ex) :set mps=(:),{:},[:]"},
+		{name: "no help", args: "nothing", wantErr: "E149: Sorry, no help for nothing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Window{}
+			err := w.helpCommand(exArgs{args: tt.args})
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestHelpTopics(t *testing.T) {
+	tags := make(map[string]bool)
+	for _, tag := range helpTags() {
+		tags[tag] = true
+	}
+	for _, c := range exCommands {
+		if !tags[":"+c.name] {
+			t.Errorf("got: no help for :%s, want: a topic", c.name)
+		}
+	}
+}
+
+func TestWindow_newFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	readOnly := filepath.Join(dir, "read-only")
+	if err := os.Mkdir(readOnly, 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		commands    []string
+		wantErr     string
+		wantMessage string
+		wantWritten string // the file written, empty when none is
+	}{
+		{name: "opened", file: "new.txt", wantMessage: "\"new.txt\" [New File]"},
+		{name: "written", file: "new.txt", commands: []string{"normal ia", "w"}, wantMessage: "\"new.txt\" [New] 1L, 2B written", wantWritten: "new.txt"},
+		{name: "written again", file: "new.txt", commands: []string{"normal ia", "w", "w"}, wantMessage: "\"new.txt\" 1L, 2B written", wantWritten: "new.txt"},
+		{name: "no directory", file: "a/b/new.txt", commands: []string{"w"}, wantErr: "E212: Can't open file for writing: no directory a/b (add ++p to make it)"},
+		{name: "make the directories", file: "a/b/new.txt", commands: []string{"w ++p"}, wantMessage: "\"a/b/new.txt\" [New] 0L, 0B written", wantWritten: "a/b/new.txt"},
+		{name: "make the directories of another file", file: "new.txt", commands: []string{"w ++p c/other.txt"}, wantMessage: "\"c/other.txt\" [New] 0L, 0B written", wantWritten: "c/other.txt"},
+		{name: "directory", file: "new.txt", commands: []string{"w! ."}, wantErr: "E502: \".\" is a directory"},
+		{name: "permission denied", file: "read-only/new.txt", commands: []string{"w"}, wantErr: "E212: Can't open file for writing: \"read-only/new.txt\" [Permission Denied]"},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if strings.HasPrefix(tt.file, "read-only") && os.Geteuid() == 0 {
+				t.Skip("root can write to any directory")
+			}
+			sub, err := ioutil.TempDir(dir, "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(readOnly, filepath.Join(sub, "read-only")); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(sub); err != nil {
+				t.Fatal(err)
+			}
+			w := NewWindow(NewSimTerminal(80, 24))
+			if err := w.SetFileContents(tt.file); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tt.commands {
+				if err = w.ExecuteLine(c); err != nil {
+					break
+				}
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("got: %v, want: %s", err, tt.wantErr)
+			}
+			if tt.wantMessage != "" && w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+			if _, err := os.Stat(tt.file); tt.wantWritten != tt.file && !os.IsNotExist(err) {
+				t.Errorf("got: %s written, want: not written", tt.file)
+			}
+			if tt.wantWritten != "" {
+				if _, err := os.Stat(tt.wantWritten); err != nil {
+					t.Errorf("got: %v, want: %s written", err, tt.wantWritten)
+				}
+			}
+		})
+	}
+}
+
+func TestWindow_motionsInNewFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	empty := filepath.Join(dir, "empty.txt")
+	if err := ioutil.WriteFile(empty, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := []string{"l", "\x1b[C", "\x1b[B", "j", "w", "e", "b", "0", "^", "$", "G", "gg", "fx", ";", "%", "x", "dd", "p", "J"}
+	for _, file := range []string{filepath.Join(dir, "new.txt"), empty} {
+		for _, input := range inputs {
+			t.Run(filepath.Base(file)+" "+input, func(t *testing.T) {
+				term := NewSimTerminal(20, 8)
+				w := NewWindow(term)
+				if err := w.SetFileContents(file); err != nil {
+					t.Fatal(err)
+				}
+				term.Type([]byte(input))
+				runTyped(t, term, w)
+				if got := linesOf(w.FileContents); !reflect.DeepEqual(got, []string{""}) {
+					t.Errorf("got: %q, want: one empty line", got)
+				}
+				if w.position != (Position{X: 1, Y: 1}) {
+					t.Errorf("got: %+v, want: {X:1 Y:1}", w.position)
+				}
+			})
+		}
+	}
+}
+
+func TestWindow_emptyFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "empty.txt")
+	if err := ioutil.WriteFile(path, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	w := NewWindow(NewSimTerminal(80, 24))
+	if err := w.SetFileContents(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.ExecuteLine("e!"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "\"" + path + "\" 0L, 0B"; w.message != want {
+		t.Errorf("got: %q, want: %q", w.message, want)
+	}
+	if err := w.ExecuteLine("w"); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(path); err != nil || len(data) != 0 {
+		t.Errorf("got: %q, %v, want: the file left empty", data, err)
+	}
+}
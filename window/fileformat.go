@@ -0,0 +1,147 @@
+package window
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+// A file is read into lines without the line breaks, and how it stored them is kept in
+// the buffer options fileformat, bomb and endofline, so it is written back as it was,
+// ex) a file of Windows keeps its \r\n and a file without a final line break does not get one.
+// A binary file, one with a NUL byte or bytes that are not UTF-8, sets the option binary:
+// its lines are split at \n only and it is written with the bytes it was read with.
+
+// utf8BOM is the byte order mark a UTF-8 file may start with.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
+// lineBreaks are the line breaks of the values of fileformat.
+var lineBreaks = map[string]string{
+	"unix": "\n",
+	"dos":  "\r\n",
+	"mac":  "\r",
+}
+
+// fileFormat is how the lines of a file are stored.
+type fileFormat struct {
+	format string // ex) dos
+	bomb   bool   // the file starts with a BOM
+	eol    bool   // the last line ends with a line break
+	binary bool   // the lines are split at \n and nothing else is changed, see isBinary
+}
+
+// isBinary reports whether data is not text: it has a NUL byte or bytes that are not UTF-8.
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data, 0) >= 0 || !utf8.Valid(data)
+}
+
+// detectFormat returns the fileformat of data: dos when every \n follows a \r,
+// unix when some do not, and mac when there is no \n but a \r.
+func detectFormat(data []byte) string {
+	n := bytes.Count(data, []byte("\n"))
+	switch {
+	case n > 0 && bytes.Count(data, []byte("\r\n")) == n:
+		return "dos"
+	case n == 0 && bytes.IndexByte(data, '\r') >= 0:
+		return "mac"
+	}
+	return "unix"
+}
+
+// decodeLines splits the contents of a file into lines and returns how they were stored.
+func decodeLines(data []byte) ([][]byte, fileFormat) {
+	f := fileFormat{format: detectFormat(data), eol: true}
+	if isBinary(data) {
+		f = fileFormat{format: "unix", eol: true, binary: true}
+	} else if bytes.HasPrefix(data, utf8BOM) {
+		data = data[len(utf8BOM):]
+		f.bomb = true
+	}
+	if len(data) == 0 {
+		return nil, f
+	}
+	lineBreak := []byte(lineBreaks[f.format])
+	if bytes.HasSuffix(data, lineBreak) {
+		data = data[:len(data)-len(lineBreak)]
+	} else {
+		f.eol = false
+	}
+	return bytes.Split(data, lineBreak), f
+}
+
+// encodeLines returns the contents of a file of lines stored as f says.
+func encodeLines(lines [][]byte, f fileFormat) []byte {
+	var b bytes.Buffer
+	lineBreak := lineBreaks[f.format]
+	if f.binary {
+		lineBreak = "\n"
+	} else if f.bomb {
+		b.Write(utf8BOM)
+	}
+	for i, l := range lines {
+		b.Write(l)
+		if i < len(lines)-1 || f.eol {
+			b.WriteString(lineBreak)
+		}
+	}
+	return b.Bytes()
+}
+
+// readLines reads the lines of the file name.
+func readLines(name string) ([][]byte, fileFormat, error) {
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fileFormat{}, err
+	}
+	lines, f := decodeLines(data)
+	return lines, f, nil
+}
+
+// fileFormat returns how the lines of the buffer are stored in its file.
+func (w *Window) fileFormat() fileFormat {
+	return fileFormat{
+		format: w.stringOption("fileformat"),
+		bomb:   w.boolOption("bomb"),
+		eol:    w.boolOption("endofline"),
+		binary: w.boolOption("binary"),
+	}
+}
+
+// setFileFormat sets the options of the buffer to how its file stores the lines, ex) after it is read.
+func (w *Window) setFileFormat(f fileFormat) {
+	local := w.localOptions(bufferScope)
+	local["fileformat"] = optionValue{s: f.format}
+	local["bomb"] = optionValue{b: f.bomb}
+	local["endofline"] = optionValue{b: f.eol}
+	local["binary"] = optionValue{b: f.binary}
+}
+
+// fileFormatChanged marks the buffer of a file modified, it has to be written in the new format.
+func (w *Window) fileFormatChanged() {
+	if w.fileName != "" {
+		w.modified = true
+	}
+}
+
+func checkFileFormat(v optionValue) error {
+	if _, ok := lineBreaks[v.s]; !ok {
+		return fmt.Errorf("E474: Invalid argument: fileformat=%s", v.s)
+	}
+	return nil
+}
+
+// formatInfo returns what fileInfo shows of f, ex) [noeol][dos]
+func formatInfo(f fileFormat) string {
+	var s string
+	if f.binary {
+		s += "[binary]"
+	}
+	if !f.eol {
+		s += "[noeol]"
+	}
+	if f.format != "unix" && !f.binary {
+		s += "[" + f.format + "]"
+	}
+	return s
+}
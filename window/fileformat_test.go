@@ -0,0 +1,134 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantLines  []string
+		wantFormat fileFormat
+	}{
+		{name: "unix", data: "a\nb\n", wantLines: []string{"a", "b"}, wantFormat: fileFormat{format: "unix", eol: true}},
+		{name: "dos", data: "a\r\nb\r\n", wantLines: []string{"a", "b"}, wantFormat: fileFormat{format: "dos", eol: true}},
+		{name: "mac", data: "a\rb\r", wantLines: []string{"a", "b"}, wantFormat: fileFormat{format: "mac", eol: true}},
+		{name: "unix with a \\r", data: "a\r\nb\n", wantLines: []string{"a\r", "b"}, wantFormat: fileFormat{format: "unix", eol: true}},
+		{name: "no final line break", data: "a\nb", wantLines: []string{"a", "b"}, wantFormat: fileFormat{format: "unix"}},
+		{name: "empty line at the end", data: "a\n\n", wantLines: []string{"a", ""}, wantFormat: fileFormat{format: "unix", eol: true}},
+		{name: "BOM", data: "\xef\xbb\xbfa\r\n", wantLines: []string{"a"}, wantFormat: fileFormat{format: "dos", bomb: true, eol: true}},
+		{name: "NUL", data: "a\x00\r\nb", wantLines: []string{"a\x00\r", "b"}, wantFormat: fileFormat{format: "unix", binary: true}},
+		{name: "not UTF-8", data: "\xef\xbb\xbf\xff\r\n", wantLines: []string{"\xef\xbb\xbf\xff\r"}, wantFormat: fileFormat{format: "unix", eol: true, binary: true}},
+		{name: "empty", data: "", wantLines: nil, wantFormat: fileFormat{format: "unix", eol: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, f := decodeLines([]byte(tt.data))
+			var got []string
+			for _, l := range lines {
+				got = append(got, string(l))
+			}
+			if !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if f != tt.wantFormat {
+				t.Errorf("got: %+v, want: %+v", f, tt.wantFormat)
+			}
+			if data := encodeLines(lines, f); string(data) != tt.data {
+				t.Errorf("got: %q written back, want: %q", data, tt.data)
+			}
+		})
+	}
+}
+
+func TestWindow_writeFileFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name        string
+		data        string
+		commands    []string
+		want        string
+		wantMessage string
+	}{
+		{name: "dos", data: "a\r\nb\r\n", commands: []string{"normal x", "w"}, want: "\r\nb\r\n", wantMessage: "\"" + path + "\" [dos] 2L, 5B written"},
+		{name: "no final line break", data: "a\nb", commands: []string{"normal x", "w"}, want: "\nb", wantMessage: "\"" + path + "\" [noeol] 2L, 2B written"},
+		{name: "BOM", data: "\xef\xbb\xbfab\n", commands: []string{"normal x", "w"}, want: "\xef\xbb\xbfb\n", wantMessage: "\"" + path + "\" 1L, 5B written"},
+		{name: "set fileformat", data: "a\nb\n", commands: []string{"set ff=dos", "w"}, want: "a\r\nb\r\n", wantMessage: "\"" + path + "\" [dos] 2L, 6B written"},
+		{name: "set endofline", data: "a\nb", commands: []string{"set eol nobomb", "w"}, want: "a\nb\n", wantMessage: "\"" + path + "\" 2L, 4B written"},
+		{name: "binary", data: "\xff\r\n", commands: []string{"normal x", "w"}, want: "\r\n", wantMessage: "\"" + path + "\" [binary] 1L, 2B written"},
+		{name: "set binary", data: "a\r\n", commands: []string{"set bin", "w"}, want: "a\n", wantMessage: "\"" + path + "\" [binary] 1L, 2B written"},
+		{name: "edit", data: "a\rb", commands: []string{"e!"}, want: "a\rb", wantMessage: "\"" + path + "\" [noeol][mac] 2L, 3B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(path, []byte(tt.data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			w := NewWindow(NewSimTerminal(80, 24))
+			if err := w.SetFileContents(path); err != nil {
+				t.Fatal(err)
+			}
+			for _, c := range tt.commands {
+				if err := w.ExecuteLine(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.want {
+				t.Errorf("got: %q, want: %q", b, tt.want)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_SetFileContentsLongLine(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "min.js")
+	long := strings.Repeat("x", 1<<20)
+	if err := ioutil.WriteFile(path, []byte(long+"\nend"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWindow(NewSimTerminal(80, 24))
+	if err := w.SetFileContents(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(w.FileContents) != 2 || string(w.FileContents[0]) != long || string(w.FileContents[1]) != "end" {
+		t.Errorf("got: %d lines, want: a line of %d bytes and end", len(w.FileContents), len(long))
+	}
+}
+
+func TestWindow_setFileFormat(t *testing.T) {
+	w := NewWindow(NewSimTerminal(80, 24))
+	w.fileName = "a.txt"
+	if err := w.ExecuteLine("set ff=mac"); err != nil {
+		t.Fatal(err)
+	}
+	if !w.modified {
+		t.Errorf("got: not modified, want: modified")
+	}
+	if err := w.ExecuteLine("set ff=windows"); err == nil || err.Error() != "E474: Invalid argument: fileformat=windows" {
+		t.Errorf("got: %v, want: E474: Invalid argument: fileformat=windows", err)
+	}
+}
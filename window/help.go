@@ -0,0 +1,140 @@
+package window
+
+import (
+	"fmt"
+	"strings"
+)
+
+// helpTopic is what :help shows for a tag, ex) :marks
+type helpTopic struct {
+	tag, text string
+}
+
+// helpTopics are the tags of the ex commands, the options and some normal commands.
+var helpTopics = []helpTopic{
+	{":abbreviate", "define an abbreviation for insert and command-line modes, ex) :ab teh the; :ab lists them"},
+	{":buffer", "show the buffer of a number or a name, ex) :b 2"},
+	{":buffers", "list the buffers, same as :ls"},
+	{":cabbrev", "define an abbreviation for the command line, ex) :ca W w"},
+	{":cmap", "map keys in command-line mode, ex) :cmap <C-a> <Home>"},
+	{":cmapclear", "remove the command-line mode mappings"},
+	{":cnoreabbrev", "define a command-line abbreviation, its keys are not mapped"},
+	{":cnoremap", "map keys in command-line mode, the keys are not mapped again"},
+	{":colorscheme", "load a colour scheme, ex) :colo default"},
+	{":cunabbrev", "remove a command-line abbreviation"},
+	{":cunmap", "remove a command-line mode mapping"},
+	{":delmarks", "delete marks, ex) :delm a-d; :delm! deletes a-z"},
+	{":edit", "edit a file, ex) :e main.go; :e! discards the changes"},
+	{":help", "show help for a command or an option, ex) :h :set"},
+	{":highlight", "set or list highlight groups, ex) :hi Comment ctermfg=244"},
+	{":iabbrev", "define an abbreviation for insert mode, ex) :ia teh the"},
+	{":imap", "map keys in insert mode, ex) :imap jk <Esc>"},
+	{":imapclear", "remove the insert mode mappings"},
+	{":inoreabbrev", "define an insert mode abbreviation, its keys are not mapped"},
+	{":inoremap", "map keys in insert mode, the keys are not mapped again"},
+	{":iunabbrev", "remove an insert mode abbreviation, ex) :iuna teh"},
+	{":iunmap", "remove an insert mode mapping"},
+	{":ls", "list the buffers: % shown, h hidden, + modified"},
+	{":map", "map keys in normal, visual and operator-pending modes, :map! in insert and command-line modes, ex) :map <leader>w :w<CR>; :map lists them"},
+	{":mapclear", "remove the mappings of :map"},
+	{":marks", "list the marks, ex) :marks aB"},
+	{":nmap", "map keys in normal mode, ex) :nmap <silent> <leader>w :w<CR>"},
+	{":nmapclear", "remove the normal mode mappings"},
+	{":nnoremap", "map keys in normal mode, the keys are not mapped again, ex) :nnoremap Y y$"},
+	{":noreabbrev", "define an abbreviation as :ab, its keys are not mapped"},
+	{":noremap", "map keys as :map, the keys are not mapped again"},
+	{":normal", "execute normal mode keys on each line of the range, ex) :%norm A;"},
+	{":nunmap", "remove a normal mode mapping, ex) :nunmap Y"},
+	{":quit", "quit the editor, :q! quits when buffers are modified and loses their changes"},
+	{":read", "insert a file below the cursor line, ex) :r header.txt"},
+	{":registers", "list the registers"},
+	{":set", "set an option, ex) :set ts=4; :set ts? shows it, :set ts& resets it, :set all lists all"},
+	{":setlocal", "set an option only for the buffer or the window, ex) :setl nowrap"},
+	{":source", "execute the ex commands of a file, ex) :so ~/.gimrc"},
+	{":unabbreviate", "remove an abbreviation of insert and command-line modes"},
+	{":unmap", "remove a mapping of :map, ex) :unmap <leader>w"},
+	{":vmap", "map keys in visual mode"},
+	{":vmapclear", "remove the visual mode mappings"},
+	{":vnoremap", "map keys in visual mode, the keys are not mapped again"},
+	{":vunmap", "remove a visual mode mapping"},
+	{":wq", "write the buffer and quit"},
+	{":write", "write the buffer, ex) :w or :w other.txt; :w ++p makes the directories of the file, :w! writes a file changed since it was read"},
+	{"'autoindent'", "indent a new line as the line it is opened from, ex) :set ai"},
+	{"'backup'", "keep the backup made before a file is written, ex) :set bk"},
+	{"'backupdir'", "the directories to make backups in, . is the directory of the file, ex) :set bdir=~/tmp"},
+	{"'binary'", "write the lines with \\n and nothing else changed, it is on for a file with NUL bytes or bytes that are not UTF-8, ex) :set bin"},
+	{"'bomb'", "write a byte order mark at the start of the file, ex) :set nobomb"},
+	{"'endofline'", "write a line break after the last line, it is off for a file read without one, ex) :set eol"},
+	{"'expandtab'", "insert spaces for a tab, ex) :set et"},
+	{"'fileformat'", "the line breaks written: unix for \\n, dos for \\r\\n and mac for \\r, ex) :set ff=unix"},
+	{"'ignorecase'", "ignore case in search patterns, ex) :set ic"},
+	{"'mapleader'", "the keys <leader> stands for in mappings, ex) :set mapleader=,"},
+	{"'matchpairs'", "the pairs % jumps between, ex) :set mps=(:),{:},[:]"},
+	{"'mouse'", "the modes the mouse is used in, ex) :set mouse=a"},
+	{"'number'", "show the line numbers, ex) :set nu"},
+	{"'shiftround'", "round the indent of > and < to a multiple of shiftwidth, ex) :set sr"},
+	{"'shiftwidth'", "the columns of a level of indent, 0 for tabstop, ex) :set sw=4"},
+	{"'smartcase'", "with ignorecase, do not ignore case when the pattern has capitals, ex) :set scs"},
+	{"'smartindent'", "indent a level more after a line ending with {, ex) :set si"},
+	{"'softtabstop'", "the columns Tab and Backspace take in insert mode, -1 for shiftwidth, ex) :set sts=4"},
+	{"'tabstop'", "the number of columns a tab takes, ex) :set ts=4"},
+	{"'timeoutlen'", "how long keys wait to make a mapping, in milliseconds, ex) :set tm=500"},
+	{"'ttimeoutlen'", "how long an Escape waits for the rest of a key the terminal sends, in milliseconds, ex) :set ttm=10"},
+	{"'wrap'", "show long lines on more than one row, ex) :set nowrap"},
+	{"'writebackup'", "make a backup before a file is written, it is removed after the file is written unless backup is set, ex) :set nowb"},
+	{"<Leader>", "the mapleader option in a mapping, \\ by default"},
+	{"%", "go to the matching bracket"},
+	{"/", "search forward for a pattern, n goes to the next match"},
+	{"<", "shift lines to the left by shiftwidth, ex) <<"},
+	{"=", "indent lines again by the rules of the file type, ex) =G or =="},
+	{">", "shift lines to the right by shiftwidth, ex) >>"},
+	{"?", "search backward for a pattern"},
+	{"q:", "open the command-line window with the history of ex commands"},
+	{"c_CTRL-R", "insert a register on the command line"},
+	{"c_<Tab>", "complete the word before the cursor on the command line"},
+}
+
+// helpTags returns the tags :help knows.
+func helpTags() []string {
+	var tags []string
+	for _, t := range helpTopics {
+		tags = append(tags, t.tag)
+	}
+	return tags
+}
+
+// findHelp returns the topic of subject: its tag, the tag of a command or an option of that name,
+// or the first tag starting with subject.
+func findHelp(subject string) (helpTopic, bool) {
+	for _, tag := range []string{subject, ":" + subject, "'" + subject + "'"} {
+		for _, t := range helpTopics {
+			if t.tag == tag {
+				return t, true
+			}
+		}
+	}
+	for _, t := range helpTopics {
+		if strings.HasPrefix(t.tag, subject) {
+			return t, true
+		}
+	}
+	return helpTopic{}, false
+}
+
+// helpCommand is :help, it shows the help of its argument, or every tag without one.
+func (w *Window) helpCommand(c exArgs) error {
+	if c.args == "" {
+		var out []string
+		for _, t := range helpTopics {
+			out = append(out, fmt.Sprintf("%-14s %s", t.tag, t.text))
+		}
+		w.showMessage(strings.Join(out, "\n"), "")
+		return nil
+	}
+	t, ok := findHelp(c.args)
+	if !ok {
+		return fmt.Errorf("E149: Sorry, no help for %s", c.args)
+	}
+	w.showMessage(fmt.Sprintf("%s  %s", t.tag, t.text), "")
+	return nil
+}
@@ -0,0 +1,284 @@
+package window
+
+import (
+	"bytes"
+
+	"gim/syntax"
+)
+
+// Indent is the blanks at the start of a line, measured in columns.
+// With 'autoindent' a new line gets the indent of the line it is opened from, and with 'smartindent'
+// a level more after a line ending with {. A file type with an Indenter, ex) go, is indented by it
+// instead. = indents lines again, > and < shift them by 'shiftwidth'.
+
+// shiftWidth returns the columns of a level of indent: 'shiftwidth', or 'tabstop' when it is 0.
+func (w *Window) shiftWidth() int {
+	if sw := w.numberOption("shiftwidth"); sw > 0 {
+		return sw
+	}
+	return w.numberOption("tabstop")
+}
+
+// softTabStop returns the columns Tab and Backspace move over in insert mode, 0 for a real tab.
+// A negative 'softtabstop' is 'shiftwidth'.
+func (w *Window) softTabStop() int {
+	if sts := w.numberOption("softtabstop"); sts >= 0 {
+		return sts
+	}
+	return w.shiftWidth()
+}
+
+// indenting reports whether new lines are indented.
+func (w *Window) indenting() bool {
+	return w.boolOption("autoindent") || w.boolOption("smartindent")
+}
+
+// indentOf returns the indent of line y.
+func (w *Window) indentOf(y int) int {
+	return syntax.IndentWidth(w.FileContents[y-1], w.numberOption("tabstop"))
+}
+
+// blanks returns the blanks from the column from up to the column to: tabs and spaces,
+// or only spaces with 'expandtab'.
+func (w *Window) blanks(from, to int) []byte {
+	var b []byte
+	for col := from; col < to; {
+		if n := w.tabWidth(col); !w.boolOption("expandtab") && col+n <= to {
+			b = append(b, '\t')
+			col += n
+		} else {
+			b = append(b, ' ')
+			col++
+		}
+	}
+	return b
+}
+
+// setIndent makes the indent of line y indent columns. The cursor stays on the same text of the line.
+func (w *Window) setIndent(y, indent int) {
+	if indent < 0 {
+		indent = 0
+	}
+	line := w.FileContents[y-1]
+	old := len(line) - len(bytes.TrimLeft(line, " \t"))
+	blanks := w.blanks(0, indent)
+	if bytes.Equal(line[:old], blanks) {
+		return
+	}
+	w.setLine(y-1, concat(blanks, line[old:]))
+	if w.position.Y == y {
+		if w.position.X -= old; w.position.X < 1 {
+			w.position.X = 1
+		}
+		w.position.X += len(blanks)
+	}
+}
+
+// ruleIndent returns the indent of line y by the Indenter of the file type, or else by the rules
+// of 'smartindent' when smart is true. It returns false when there is no rule for the line.
+func (w *Window) ruleIndent(y int, smart bool) (int, bool) {
+	if in := syntax.LookupIndenter(w.fileType); in != nil {
+		if w.indentCache == nil {
+			w.indentCache = syntax.NewIndentCache(in)
+		}
+		n := w.indentCache.Indent(w.FileContents, y-1, w.indentOptions())
+		return n, n >= 0
+	}
+	if smart {
+		return w.smartIndent(y), true
+	}
+	return 0, false
+}
+
+// indentOptions returns the options the indenter of the file type works with.
+func (w *Window) indentOptions() syntax.IndentOptions {
+	return syntax.IndentOptions{ShiftWidth: w.shiftWidth(), TabStop: w.numberOption("tabstop")}
+}
+
+// smartIndent returns the indent of line y for 'smartindent': that of the line above, a level more
+// after a line ending with {, and that of the line with the matching { for a line starting with }.
+func (w *Window) smartIndent(y int) int {
+	if bytes.HasPrefix(bytes.TrimLeft(w.FileContents[y-1], " \t"), []byte("}")) {
+		depth := 0
+		for i := y - 1; i >= 1; i-- {
+			line := w.FileContents[i-1]
+			depth += bytes.Count(line, []byte("}")) - bytes.Count(line, []byte("{"))
+			if depth < 0 {
+				return w.indentOf(i)
+			}
+		}
+		return 0
+	}
+	for i := y - 1; i >= 1; i-- {
+		line := bytes.TrimRight(w.FileContents[i-1], " \t")
+		if len(line) == 0 {
+			continue
+		}
+		if bytes.HasSuffix(line, []byte("{")) {
+			return w.indentOf(i) + w.shiftWidth()
+		}
+		return w.indentOf(i)
+	}
+	return 0
+}
+
+// autoIndent indents the new line of the cursor in insert mode: by the rules of the file type
+// or 'smartindent', or else as the line from, ex) the line above after o
+func (w *Window) autoIndent(from int) {
+	if !w.indenting() {
+		return
+	}
+	y := w.position.Y
+	n, ok := w.ruleIndent(y, w.boolOption("smartindent"))
+	if !ok {
+		n = 0
+		if from >= 1 && from <= len(w.FileContents) {
+			n = w.indentOf(from)
+		}
+	}
+	w.setIndent(y, n)
+	if in := w.insertion; in != nil && len(bytes.TrimLeft(w.FileContents[y-1], " \t")) == 0 {
+		in.indented = y
+	}
+}
+
+// takeIndented returns the line autoIndent indented while nothing is typed after the indent,
+// and forgets it. It returns 0 when there is none.
+func (w *Window) takeIndented() int {
+	in := w.insertion
+	if in == nil {
+		return 0
+	}
+	y := in.indented
+	in.indented = 0
+	return y
+}
+
+// clearIndent removes the indent of line y when nothing was typed after it,
+// ex) Enter and then Escape leave an empty line.
+func (w *Window) clearIndent(y int) {
+	if line := w.FileContents[y-1]; len(line) > 0 && len(bytes.TrimLeft(line, " \t")) == 0 {
+		w.setLine(y-1, []byte{})
+		if w.position.Y == y {
+			w.position.X = 1
+		}
+	}
+}
+
+// reindentTyped indents the line of the cursor again when what was typed makes the file type
+// indent it differently, ex) } in a Go file. With 'smartindent' it is a line starting with }.
+func (w *Window) reindentTyped() {
+	if !w.indenting() || w.insertion != nil && w.insertion.replace {
+		return
+	}
+	y := w.position.Y
+	line := w.FileContents[y-1]
+	in := syntax.LookupIndenter(w.fileType)
+	if in != nil && in.Reindents(line) || in == nil && w.boolOption("smartindent") && string(bytes.TrimSpace(line)) == "}" {
+		if n, ok := w.ruleIndent(y, true); ok {
+			w.setIndent(y, n)
+		}
+	}
+}
+
+// blanksBefore returns the start (1-indexed) of the blanks just before the cursor.
+func (w *Window) blanksBefore() int {
+	line := w.FileContents[w.position.Y-1]
+	x := w.position.X
+	for x > 1 && isBlank(line[x-2]) {
+		x--
+	}
+	return x
+}
+
+// insertTab inserts a tab in insert mode. With 'softtabstop' the blanks before the cursor
+// reach the next multiple of it, and with 'expandtab' they are spaces.
+func (w *Window) insertTab() {
+	y := w.position.Y
+	col := w.displayColumn(y-1, w.position.X)
+	if sts := w.softTabStop(); sts > 0 && (w.insertion == nil || !w.insertion.replace) {
+		start := w.blanksBefore()
+		from := w.displayColumn(y-1, start)
+		w.beginChange()
+		w.deleteText(Position{X: start, Y: y}, w.position)
+		w.position.X = start
+		w.typeText(w.blanks(from, (col/sts+1)*sts))
+		return
+	}
+	if w.boolOption("expandtab") {
+		// spaces up to the next tab stop
+		w.typeText(bytes.Repeat([]byte(" "), w.tabWidth(col)))
+		return
+	}
+	w.typeText([]byte("\t"))
+}
+
+// backspaceBlanks deletes the blanks before the cursor back to the previous multiple of 'softtabstop'.
+// It returns false when there is no softtabstop or no blank before the cursor.
+func (w *Window) backspaceBlanks() bool {
+	sts := w.softTabStop()
+	y, x := w.position.Y, w.position.X
+	if sts <= 0 || x == 1 || !isBlank(w.FileContents[y-1][x-2]) {
+		return false
+	}
+	start := w.blanksBefore()
+	from := w.displayColumn(y-1, start)
+	to := (w.displayColumn(y-1, x) - 1) / sts * sts
+	if to < from {
+		to = from
+	}
+	w.beginChange()
+	w.deleteText(Position{X: start, Y: y}, w.position)
+	w.position = w.insertText(Position{X: start, Y: y}, w.blanks(from, to))
+	return true
+}
+
+// shiftLines is > and <, it shifts the lines from first to last by 'shiftwidth'
+// to the right or to the left. With 'shiftround' the indent is a multiple of shiftwidth.
+func (w *Window) shiftLines(right bool, first, last int) {
+	sw := w.shiftWidth()
+	for y := first; y <= last; y++ {
+		if len(w.FileContents[y-1]) == 0 {
+			continue
+		}
+		n := w.indentOf(y)
+		switch {
+		case right && w.boolOption("shiftround"):
+			n = (n/sw + 1) * sw
+		case right:
+			n += sw
+		case w.boolOption("shiftround"):
+			n = ((n+sw-1)/sw - 1) * sw
+		default:
+			n -= sw
+		}
+		w.setIndent(y, n)
+	}
+}
+
+// indentLines is =, it indents the lines from first to last again by the rules of the file type,
+// or of 'smartindent' when it has none. Blank lines are made empty.
+// The indenter goes down the lines once, each line is indented from the ones above as they are now.
+func (w *Window) indentLines(first, last int) {
+	var scanner syntax.IndentScanner
+	if in := syntax.LookupIndenter(w.fileType); in != nil {
+		scanner = in.Start(w.indentOptions())
+		for _, line := range w.FileContents[:first-1] {
+			scanner.Scan(line)
+		}
+	}
+	for y := first; y <= last; y++ {
+		if line := w.FileContents[y-1]; len(bytes.TrimLeft(line, " \t")) == 0 {
+			if len(line) > 0 {
+				w.setLine(y-1, []byte{})
+			}
+		} else if scanner == nil {
+			w.setIndent(y, w.smartIndent(y))
+		} else if n := scanner.Indent(line); n >= 0 {
+			w.setIndent(y, n)
+		}
+		if scanner != nil {
+			scanner.Scan(w.FileContents[y-1])
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_indent(t *testing.T) {
+	tests := []struct {
+		name         string
+		fileType     string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+	}{
+		{name: "autoindent", lines: []string{"\tabc"}, position: Position{X: 1, Y: 1}, input: ":set ai\rA\rx\033", wantLines: []string{"\tabc", "\tx"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "noautoindent", lines: []string{"\tabc"}, position: Position{X: 1, Y: 1}, input: "A\rx\033", wantLines: []string{"\tabc", "x"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "o", lines: []string{"  abc", "d"}, position: Position{X: 1, Y: 1}, input: ":set ai\rox\033", wantLines: []string{"  abc", "  x", "d"}, wantPosition: Position{X: 3, Y: 2}},
+		{name: "O", lines: []string{"a", "  bc"}, position: Position{X: 1, Y: 2}, input: ":set ai\rOx\033", wantLines: []string{"a", "  x", "  bc"}, wantPosition: Position{X: 3, Y: 2}},
+		{name: "blanks after the cursor", lines: []string{"  ab  cd"}, position: Position{X: 4, Y: 1}, input: ":set ai\ra\r\033", wantLines: []string{"  ab", "  cd"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "nothing typed after the indent", lines: []string{"  ab"}, position: Position{X: 1, Y: 1}, input: ":set ai\rA\r\rx\033", wantLines: []string{"  ab", "", "  x"}, wantPosition: Position{X: 3, Y: 3}},
+		{name: "escape after the indent", lines: []string{"  ab"}, position: Position{X: 1, Y: 1}, input: ":set ai\ro\033", wantLines: []string{"  ab", ""}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "smartindent", lines: []string{"if a {"}, position: Position{X: 1, Y: 1}, input: ":set si sw=2 et\rA\rb\r}\033", wantLines: []string{"if a {", "  b", "}"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "go", fileType: "go", lines: []string{"func f() {"}, position: Position{X: 1, Y: 1}, input: ":set ai\rA\rswitch {\rcase a:\rb()\rdefault:\r}\r}\033", wantLines: []string{"func f() {", "\tswitch {", "\tcase a:", "\t\tb()", "\tdefault:", "\t}", "}"}, wantPosition: Position{X: 1, Y: 7}},
+		{name: "go between braces", fileType: "go", lines: []string{"func f() {}"}, position: Position{X: 11, Y: 1}, input: ":set ai\ri\r\033kox\033", wantLines: []string{"func f() {", "\tx", "}"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "go without autoindent", fileType: "go", lines: []string{"func f() {"}, position: Position{X: 1, Y: 1}, input: "A\rx\033", wantLines: []string{"func f() {", "x"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "undo at once", lines: []string{"  ab"}, position: Position{X: 1, Y: 1}, input: ":set ai\rA\rx\033u", wantLines: []string{"  ab"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "repeat", lines: []string{"  ab", "c"}, position: Position{X: 1, Y: 1}, input: ":set ai\rox\033j.", wantLines: []string{"  ab", "  x", "c", "x"}, wantPosition: Position{X: 1, Y: 4}},
+		{name: "softtabstop", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":set sts=4\rA\t\t\tb\033", wantLines: []string{"a\t    b"}, wantPosition: Position{X: 7, Y: 1}},
+		{name: "softtabstop backspace", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":set sts=4\rA\t\t\t\x7f\x7fb\033", wantLines: []string{"a   b"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "softtabstop with expandtab", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":set sts=4 et\rA\t\tb\033", wantLines: []string{"a       b"}, wantPosition: Position{X: 9, Y: 1}},
+		{name: "softtabstop of shiftwidth", lines: []string{""}, position: Position{X: 1, Y: 1}, input: ":set sts=-1 sw=2 et\rA\tb\033", wantLines: []string{"  b"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: ">>", lines: []string{"a", "b"}, position: Position{X: 1, Y: 1}, input: ":set sw=2 et\r>>", wantLines: []string{"  a", "b"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "> with a motion", lines: []string{"a", "", "b"}, position: Position{X: 1, Y: 1}, input: ":set sw=4\r>G", wantLines: []string{"    a", "", "    b"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "> with tabs", lines: []string{"    a"}, position: Position{X: 1, Y: 1}, input: ":set sw=4\r>>", wantLines: []string{"\ta"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "<<", lines: []string{"      a"}, position: Position{X: 1, Y: 1}, input: ":set sw=4\r<<", wantLines: []string{"  a"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "shiftround", lines: []string{"      a", "   b"}, position: Position{X: 1, Y: 1}, input: ":set sw=4 sr et\r>>j<<", wantLines: []string{"        a", "b"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "count", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: ":set sw=1\r2>>", wantLines: []string{" a", " b", "c"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "shiftwidth 0 is tabstop", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: ":set sw=0 ts=3 et\r>>", wantLines: []string{"   a"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "=", fileType: "go", lines: []string{"func f() {", "if a {", "        b()", "  }", "   ", "}"}, position: Position{X: 1, Y: 1}, input: "=G", wantLines: []string{"func f() {", "\tif a {", "\t\tb()", "\t}", "", "}"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "==", fileType: "go", lines: []string{"func f() {", "b()", "}"}, position: Position{X: 1, Y: 2}, input: "==", wantLines: []string{"func f() {", "\tb()", "}"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "= without a file type", lines: []string{"if a {", "b", "      }"}, position: Position{X: 1, Y: 1}, input: ":set sw=2 et\r=2j", wantLines: []string{"if a {", "  b", "}"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "= is repeated", fileType: "go", lines: []string{"{", "a", "b"}, position: Position{X: 1, Y: 2}, input: "==j.", wantLines: []string{"{", "\ta", "\tb"}, wantPosition: Position{X: 2, Y: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(20, 8)
+			w := NewWindow(term)
+			w.fileType = tt.fileType
+			for _, l := range tt.lines {
+				w.FileContents = append(w.FileContents, []byte(l))
+			}
+			w.position = tt.position
+			term.Type([]byte(tt.input))
+			runTyped(t, term, w)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+		})
+	}
+}
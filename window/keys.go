@@ -0,0 +1,379 @@
+package window
+
+import (
+	"bytes"
+	"unicode/utf8"
+
+	"gim/terminfo"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+var asciiSequences = []*prompt.ASCIICode{
+	{Key: prompt.Escape, ASCIICode: []byte{0x1b}},
+	{Key: prompt.Up, ASCIICode: []byte{0x1b, 0x5b, 0x41}},
+	{Key: prompt.Down, ASCIICode: []byte{0x1b, 0x5b, 0x42}},
+	{Key: prompt.Right, ASCIICode: []byte{0x1b, 0x5b, 0x43}},
+	{Key: prompt.Left, ASCIICode: []byte{0x1b, 0x5b, 0x44}},
+	{Key: prompt.ControlC, ASCIICode: []byte{0x3}},
+	{Key: prompt.Delete, ASCIICode: []byte{0x1b, 0x5b, 0x33, 0x7e}},
+	{Key: prompt.Backspace, ASCIICode: []byte{0x7f}},
+	{Key: prompt.Enter, ASCIICode: []byte{0xd}},
+	// the cursor keys in application mode, and the other forms of the editing keys
+	{Key: prompt.Up, ASCIICode: []byte("\033OA")},
+	{Key: prompt.Down, ASCIICode: []byte("\033OB")},
+	{Key: prompt.Right, ASCIICode: []byte("\033OC")},
+	{Key: prompt.Left, ASCIICode: []byte("\033OD")},
+	{Key: prompt.Home, ASCIICode: []byte("\033[H")},
+	{Key: prompt.Home, ASCIICode: []byte("\033OH")},
+	{Key: prompt.Home, ASCIICode: []byte("\033[1~")},
+	{Key: prompt.End, ASCIICode: []byte("\033[F")},
+	{Key: prompt.End, ASCIICode: []byte("\033OF")},
+	{Key: prompt.End, ASCIICode: []byte("\033[4~")},
+}
+
+// terminfoKeys are the keys whose sequences are read from terminfo.
+var terminfoKeys = []struct {
+	cap string
+	key prompt.Key
+}{
+	{"kcuu1", prompt.Up}, {"kcud1", prompt.Down}, {"kcuf1", prompt.Right}, {"kcub1", prompt.Left},
+	{"khome", prompt.Home}, {"kend", prompt.End}, {"kpp", prompt.PageUp}, {"knp", prompt.PageDown},
+	{"kich1", prompt.Insert}, {"kdch1", prompt.Delete}, {"kbs", prompt.Backspace}, {"kcbt", prompt.BackTab},
+	{"kLFT", prompt.ShiftLeft}, {"kRIT", prompt.ShiftRight}, {"kri", prompt.ShiftUp}, {"kind", prompt.ShiftDown},
+	{"kf1", prompt.F1}, {"kf2", prompt.F2}, {"kf3", prompt.F3}, {"kf4", prompt.F4},
+	{"kf5", prompt.F5}, {"kf6", prompt.F6}, {"kf7", prompt.F7}, {"kf8", prompt.F8},
+	{"kf9", prompt.F9}, {"kf10", prompt.F10}, {"kf11", prompt.F11}, {"kf12", prompt.F12},
+}
+
+// keySequences returns the sequences of the keys of ti followed by asciiSequences,
+// which are kept because terminals send some keys in more than one form.
+func keySequences(ti *terminfo.Terminfo) []*prompt.ASCIICode {
+	var keys []*prompt.ASCIICode
+	for _, k := range terminfoKeys {
+		if seq := ti.String(k.cap); seq != "" {
+			keys = append(keys, &prompt.ASCIICode{Key: k.key, ASCIICode: []byte(seq)})
+		}
+	}
+	return append(keys, asciiSequences...)
+}
+
+// Mod is a set of modifier keys held with a key.
+type Mod uint8
+
+const (
+	ModShift Mod = 1 << iota
+	ModAlt
+	ModCtrl
+)
+
+// KeyEvent is one key typed.
+type KeyEvent struct {
+	Key  prompt.Key // NotDefined when a character is typed
+	Mod  Mod
+	Rune rune   // the character typed, when Key is NotDefined
+	Data []byte // the bytes the terminal sent for the key
+	// Mouse is the mouse event when Key is Vt100MouseEvent.
+	Mouse MouseEvent
+}
+
+// KeyDecoder splits the input of the terminal into keys. A key split over reads
+// is kept until the rest arrives, and an Escape alone is only reported by Flush,
+// when the option ttimeoutlen passed without more input, see Run.
+// Text pasted in bracketed paste mode is one BracketedPaste key whose Data is the text.
+type KeyDecoder struct {
+	keys    []*prompt.ASCIICode
+	buf     []byte
+	pasting bool
+	paste   []byte
+}
+
+var pasteEnd = []byte("\033[201~")
+
+// NewKeyDecoder returns a decoder for the keys of the terminal described by ti, which may be nil.
+func NewKeyDecoder(ti *terminfo.Terminfo) *KeyDecoder {
+	keys := asciiSequences
+	if ti != nil {
+		keys = keySequences(ti)
+	}
+	return &KeyDecoder{keys: keys}
+}
+
+// Feed adds input and returns the keys completed by it.
+func (d *KeyDecoder) Feed(b []byte) []KeyEvent {
+	d.buf = append(d.buf, b...)
+	return d.decode(false)
+}
+
+// Pending reports whether input waits for the rest of a key.
+// A paste is not pending: it waits for its end however long it takes.
+func (d *KeyDecoder) Pending() bool {
+	return len(d.buf) > 0 && !d.pasting
+}
+
+// Flush returns the waiting input as keys without waiting for more.
+func (d *KeyDecoder) Flush() []KeyEvent {
+	return d.decode(true)
+}
+
+func (d *KeyDecoder) decode(force bool) []KeyEvent {
+	var events []KeyEvent
+	for len(d.buf) > 0 {
+		if d.pasting {
+			ev, ok := d.pasted()
+			if !ok {
+				break
+			}
+			events = append(events, ev)
+			continue
+		}
+		ev, n := d.next(d.buf)
+		if n == 0 {
+			if !force {
+				break
+			}
+			// the rest never came, ex) Escape typed alone
+			ev, n = d.incomplete(d.buf)
+		}
+		if ev.Key == prompt.BracketedPaste {
+			// the text up to the end marker is pasted, keys in it are not decoded
+			d.pasting = true
+			d.buf = d.buf[n:]
+			continue
+		}
+		ev.Data = append([]byte(nil), d.buf[:n]...)
+		events = append(events, ev)
+		d.buf = d.buf[n:]
+	}
+	if len(d.buf) == 0 {
+		d.buf = nil
+	}
+	return events
+}
+
+// pasted moves the pasted text in buf to paste and returns it as a key
+// when its end marker arrived.
+func (d *KeyDecoder) pasted() (KeyEvent, bool) {
+	end := bytes.Index(d.buf, pasteEnd)
+	if end < 0 {
+		// keep what may be the start of the marker
+		keep := len(pasteEnd) - 1
+		if keep > len(d.buf) {
+			keep = len(d.buf)
+		}
+		d.paste = append(d.paste, d.buf[:len(d.buf)-keep]...)
+		d.buf = d.buf[len(d.buf)-keep:]
+		return KeyEvent{}, false
+	}
+	ev := KeyEvent{Key: prompt.BracketedPaste, Data: append(d.paste, d.buf[:end]...)}
+	if ev.Data == nil {
+		ev.Data = []byte{}
+	}
+	d.buf = d.buf[end+len(pasteEnd):]
+	d.pasting = false
+	d.paste = nil
+	return ev, true
+}
+
+// incomplete decodes the start of b which did not become a complete key.
+func (d *KeyDecoder) incomplete(b []byte) (KeyEvent, int) {
+	if b[0] == 0x1b {
+		return KeyEvent{Key: prompt.Escape}, 1
+	}
+	// a broken UTF-8 sequence
+	return KeyEvent{Key: prompt.NotDefined, Rune: utf8.RuneError}, 1
+}
+
+// next decodes the key at the start of b and returns its length, or 0 when b is not complete.
+func (d *KeyDecoder) next(b []byte) (KeyEvent, int) {
+	if ev, n, wait := d.lookup(b); wait {
+		return KeyEvent{}, 0
+	} else if n > 0 {
+		return ev, n
+	}
+	if b[0] == 0x1b {
+		return d.escape(b)
+	}
+	if b[0] < 0x20 {
+		key := controlKey(b[0])
+		if key == prompt.Tab || key == prompt.Enter {
+			return KeyEvent{Key: key}, 1
+		}
+		return KeyEvent{Key: key, Mod: ModCtrl}, 1
+	}
+	if !utf8.FullRune(b) {
+		return KeyEvent{}, 0
+	}
+	r, size := utf8.DecodeRune(b)
+	return KeyEvent{Key: prompt.NotDefined, Rune: r}, size
+}
+
+// lookup finds the longest known sequence at the start of b.
+// wait is true when b may be the start of a longer one.
+func (d *KeyDecoder) lookup(b []byte) (ev KeyEvent, n int, wait bool) {
+	for _, k := range d.keys {
+		seq := k.ASCIICode
+		if len(seq) == 1 && seq[0] == 0x1b {
+			// decided by escape, it is the start of the other sequences
+			continue
+		}
+		switch {
+		case bytes.HasPrefix(b, seq):
+			if len(seq) > n {
+				ev, n = KeyEvent{Key: k.Key, Mod: keyMods[k.Key]}, len(seq)
+			}
+		case bytes.HasPrefix(seq, b):
+			wait = true
+		}
+	}
+	return ev, n, wait
+}
+
+// escape decodes a key starting with Escape: a CSI or SS3 sequence, or a key typed with Alt.
+func (d *KeyDecoder) escape(b []byte) (KeyEvent, int) {
+	if len(b) < 2 {
+		return KeyEvent{}, 0
+	}
+	switch b[1] {
+	case '[':
+		return csi(b)
+	case 'O':
+		if len(b) < 3 {
+			return KeyEvent{}, 0
+		}
+		if key, ok := ss3Keys[b[2]]; ok {
+			return KeyEvent{Key: key}, 3
+		}
+		return KeyEvent{Key: prompt.Ignore}, 3
+	case 0x1b:
+		return KeyEvent{Key: prompt.Escape}, 1
+	}
+	ev, n := d.next(b[1:])
+	if n == 0 {
+		return ev, 0
+	}
+	ev.Mod |= ModAlt
+	return ev, n + 1
+}
+
+// csi decodes a control sequence, ex) ESC [ 1 ; 5 C for Ctrl-Right.
+func csi(b []byte) (KeyEvent, int) {
+	i := 2
+	for i < len(b) && b[i] >= 0x20 && b[i] <= 0x3f {
+		i++
+	}
+	if i >= len(b) {
+		return KeyEvent{}, 0
+	}
+	n := i + 1
+	if b[i] < 0x40 || b[i] > 0x7e {
+		// not a control sequence, do not insert it
+		return KeyEvent{Key: prompt.Ignore}, i
+	}
+	if b[i] == 'M' && i == 2 {
+		// a mouse event in the old format, ESC [ M and 3 bytes
+		if len(b) < 6 {
+			return KeyEvent{}, 0
+		}
+		mouse, mod := decodeMouse(int(b[3])-32, int(b[4])-32, int(b[5])-32, false)
+		return KeyEvent{Key: prompt.Vt100MouseEvent, Mod: mod, Mouse: mouse}, 6
+	}
+	params := splitParams(b[2:i])
+	if b[2] == '<' && (b[i] == 'M' || b[i] == 'm') && len(params) == 3 {
+		// a mouse event in the SGR format, ESC [ < button ; x ; y M, m when released
+		mouse, mod := decodeMouse(params[0], params[1], params[2], b[i] == 'm')
+		return KeyEvent{Key: prompt.Vt100MouseEvent, Mod: mod, Mouse: mouse}, n
+	}
+	mod := Mod(0)
+	if len(params) > 1 && params[1] > 1 {
+		mod = Mod(params[1]-1) & (ModShift | ModAlt | ModCtrl)
+	}
+	var key prompt.Key
+	switch final := b[i]; {
+	case final == '~':
+		key = tildeKeys[params[0]]
+	case final == 'Z':
+		key = prompt.BackTab
+		mod |= ModShift
+	default:
+		key = ss3Keys[final]
+	}
+	if key == 0 {
+		return KeyEvent{Key: prompt.Ignore}, n
+	}
+	return KeyEvent{Key: withMod(key, mod), Mod: mod}, n
+}
+
+// splitParams returns the numbers of "1;5", at least one, which is 0 when it is empty.
+func splitParams(b []byte) []int {
+	params := []int{0}
+	for _, c := range b {
+		switch {
+		case c == ';':
+			params = append(params, 0)
+		case c >= '0' && c <= '9':
+			params[len(params)-1] = params[len(params)-1]*10 + int(c-'0')
+		}
+	}
+	return params
+}
+
+// withMod returns the key prompt has for key with mod, ex) ShiftLeft.
+func withMod(key prompt.Key, mod Mod) prompt.Key {
+	modKeys := map[Mod]map[prompt.Key]prompt.Key{
+		ModShift: {prompt.Left: prompt.ShiftLeft, prompt.Right: prompt.ShiftRight, prompt.Up: prompt.ShiftUp,
+			prompt.Down: prompt.ShiftDown, prompt.Delete: prompt.ShiftDelete},
+		ModCtrl: {prompt.Left: prompt.ControlLeft, prompt.Right: prompt.ControlRight, prompt.Up: prompt.ControlUp,
+			prompt.Down: prompt.ControlDown, prompt.Delete: prompt.ControlDelete},
+	}
+	if k, ok := modKeys[mod][key]; ok {
+		return k
+	}
+	return key
+}
+
+// keyMods are the modifiers of the keys that include one.
+var keyMods = map[prompt.Key]Mod{
+	prompt.ShiftLeft: ModShift, prompt.ShiftRight: ModShift, prompt.ShiftUp: ModShift, prompt.ShiftDown: ModShift,
+	prompt.ShiftDelete: ModShift, prompt.BackTab: ModShift,
+	prompt.ControlLeft: ModCtrl, prompt.ControlRight: ModCtrl, prompt.ControlUp: ModCtrl, prompt.ControlDown: ModCtrl,
+	prompt.ControlDelete: ModCtrl, prompt.ControlC: ModCtrl,
+}
+
+// ss3Keys are the final characters of ESC O x and ESC [ x.
+var ss3Keys = map[byte]prompt.Key{
+	'A': prompt.Up, 'B': prompt.Down, 'C': prompt.Right, 'D': prompt.Left,
+	'H': prompt.Home, 'F': prompt.End,
+	'P': prompt.F1, 'Q': prompt.F2, 'R': prompt.F3, 'S': prompt.F4,
+}
+
+// tildeKeys are the numbers of ESC [ n ~.
+var tildeKeys = map[int]prompt.Key{
+	1: prompt.Home, 2: prompt.Insert, 3: prompt.Delete, 4: prompt.End,
+	5: prompt.PageUp, 6: prompt.PageDown, 7: prompt.Home, 8: prompt.End,
+	11: prompt.F1, 12: prompt.F2, 13: prompt.F3, 14: prompt.F4, 15: prompt.F5,
+	17: prompt.F6, 18: prompt.F7, 19: prompt.F8, 20: prompt.F9, 21: prompt.F10,
+	23: prompt.F11, 24: prompt.F12,
+	200: prompt.BracketedPaste,
+}
+
+// controlKey returns the key of a control character, ex) ControlA for 0x01.
+func controlKey(c byte) prompt.Key {
+	switch c {
+	case 0x00:
+		return prompt.ControlSpace
+	case 0x09:
+		return prompt.Tab
+	case 0x0d:
+		return prompt.Enter
+	case 0x1c:
+		return prompt.ControlBackslash
+	case 0x1d:
+		return prompt.ControlSquareClose
+	case 0x1e:
+		return prompt.ControlCircumflex
+	case 0x1f:
+		return prompt.ControlUnderscore
+	}
+	return prompt.ControlA + prompt.Key(c-1)
+}
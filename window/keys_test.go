@@ -0,0 +1,198 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+
+	"gim/terminfo"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// key is a KeyEvent without Data, to compare in tests.
+type key struct {
+	Key  prompt.Key
+	Mod  Mod
+	Rune rune
+}
+
+func keysOf(events []KeyEvent) []key {
+	var keys []key
+	for _, ev := range events {
+		keys = append(keys, key{ev.Key, ev.Mod, ev.Rune})
+	}
+	return keys
+}
+
+func char(r rune) key {
+	return key{Key: prompt.NotDefined, Rune: r}
+}
+
+func TestKeyDecoder_Feed(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []string
+		want        []key
+		wantPending bool
+	}{
+		{name: "characters", input: []string{"ab"}, want: []key{char('a'), char('b')}},
+		{name: "pasted text with a sequence", input: []string{"a\033[Ab"}, want: []key{char('a'), {Key: prompt.Up}, char('b')}},
+		{name: "multibyte characters", input: []string{"日本"}, want: []key{char('日'), char('本')}},
+		{name: "multibyte character split", input: []string{"\xe6\x97", "\xa5"}, want: []key{char('日')}},
+		{name: "sequence split", input: []string{"\033", "[", "B"}, want: []key{{Key: prompt.Down}}},
+		{name: "escape waits", input: []string{"\033"}, wantPending: true},
+		{name: "partial CSI waits", input: []string{"x\033[1;"}, want: []key{char('x')}, wantPending: true},
+		{name: "two escapes", input: []string{"\033\033[C"}, want: []key{{Key: prompt.Escape}, {Key: prompt.Right}}},
+		{name: "control keys", input: []string{"\x01\t\r\x7f\x03"}, want: []key{
+			{Key: prompt.ControlA, Mod: ModCtrl}, {Key: prompt.Tab}, {Key: prompt.Enter},
+			{Key: prompt.Backspace}, {Key: prompt.ControlC, Mod: ModCtrl},
+		}},
+		{name: "alt", input: []string{"\033x"}, want: []key{{Key: prompt.NotDefined, Mod: ModAlt, Rune: 'x'}}},
+		{name: "shift arrow", input: []string{"\033[1;2D"}, want: []key{{Key: prompt.ShiftLeft, Mod: ModShift}}},
+		{name: "ctrl arrow", input: []string{"\033[1;5C"}, want: []key{{Key: prompt.ControlRight, Mod: ModCtrl}}},
+		{name: "alt arrow", input: []string{"\033[1;3A"}, want: []key{{Key: prompt.Up, Mod: ModAlt}}},
+		{name: "editing keys", input: []string{"\033[H\033[4~\033[5~\033[6~\033[2~\033[3~"}, want: []key{
+			{Key: prompt.Home}, {Key: prompt.End}, {Key: prompt.PageUp}, {Key: prompt.PageDown},
+			{Key: prompt.Insert}, {Key: prompt.Delete},
+		}},
+		{name: "ctrl delete", input: []string{"\033[3;5~"}, want: []key{{Key: prompt.ControlDelete, Mod: ModCtrl}}},
+		{name: "function keys", input: []string{"\033OP\033[15~\033[24~\033[1;2S"}, want: []key{
+			{Key: prompt.F1}, {Key: prompt.F5}, {Key: prompt.F12}, {Key: prompt.F4, Mod: ModShift},
+		}},
+		{name: "application cursor keys", input: []string{"\033OA\033OF"}, want: []key{{Key: prompt.Up}, {Key: prompt.End}}},
+		{name: "unknown sequence is ignored", input: []string{"\033[99~a"}, want: []key{{Key: prompt.Ignore}, char('a')}},
+		{name: "back tab", input: []string{"\033[Z"}, want: []key{{Key: prompt.BackTab, Mod: ModShift}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewKeyDecoder(terminfo.Xterm())
+			var got []KeyEvent
+			for _, in := range tt.input {
+				got = append(got, d.Feed([]byte(in))...)
+			}
+			if !reflect.DeepEqual(keysOf(got), tt.want) {
+				t.Errorf("got: %+v, want: %+v", keysOf(got), tt.want)
+			}
+			if d.Pending() != tt.wantPending {
+				t.Errorf("got: pending %v, want: %v", d.Pending(), tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestKeyDecoder_Flush(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []key
+	}{
+		{name: "escape", input: "\033", want: []key{{Key: prompt.Escape}}},
+		{name: "escape and bracket", input: "\033[", want: []key{{Key: prompt.Escape}, char('[')}},
+		{name: "broken character", input: "\xe6\x97", want: []key{char(0xfffd), char(0xfffd)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewKeyDecoder(nil)
+			got := d.Feed([]byte(tt.input))
+			got = append(got, d.Flush()...)
+			if !reflect.DeepEqual(keysOf(got), tt.want) {
+				t.Errorf("got: %+v, want: %+v", keysOf(got), tt.want)
+			}
+			if d.Pending() {
+				t.Errorf("got: pending after Flush, want: nothing pending")
+			}
+		})
+	}
+}
+
+func TestKeyDecoder_Data(t *testing.T) {
+	d := NewKeyDecoder(nil)
+	in := []byte("a\033[A")
+	events := d.Feed(in)
+	in[0] = 'x'
+	if len(events) != 2 || string(events[0].Data) != "a" || string(events[1].Data) != "\033[A" {
+		t.Errorf("got: %+v, want: the bytes of a and Up", events)
+	}
+}
+
+func TestKeyDecoder_Paste(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []string
+		want        []KeyEvent
+		wantPending bool
+	}{
+		{
+			name:  "paste between keys",
+			input: []string{"a\033[200~x\033[Ay\r\033[201~b"},
+			want: []KeyEvent{
+				{Key: prompt.NotDefined, Rune: 'a', Data: []byte("a")},
+				{Key: prompt.BracketedPaste, Data: []byte("x\033[Ay\r")},
+				{Key: prompt.NotDefined, Rune: 'b', Data: []byte("b")},
+			},
+		},
+		{
+			name:  "markers split over reads",
+			input: []string{"\033[20", "0~hel", "lo\033[2", "01~"},
+			want:  []KeyEvent{{Key: prompt.BracketedPaste, Data: []byte("hello")}},
+		},
+		{
+			name:  "empty paste",
+			input: []string{"\033[200~\033[201~"},
+			want:  []KeyEvent{{Key: prompt.BracketedPaste, Data: []byte{}}},
+		},
+		{
+			name:        "paste without end waits",
+			input:       []string{"\033[200~abc\033"},
+			wantPending: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewKeyDecoder(nil)
+			var got []KeyEvent
+			for _, in := range tt.input {
+				got = append(got, d.Feed([]byte(in))...)
+			}
+			if d.Pending() {
+				// a timeout must not end the paste
+				got = append(got, d.Flush()...)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+			if d.Pending() != tt.wantPending {
+				t.Errorf("got: pending %v, want: %v", d.Pending(), tt.wantPending)
+			}
+		})
+	}
+}
+
+func TestKeyDecoder_Mouse(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantMouse MouseEvent
+		wantMod   Mod
+	}{
+		{name: "left press", input: "\033[<0;5;3M", wantMouse: MouseEvent{Button: MouseLeft, Action: MousePress, Row: 2, Col: 4}},
+		{name: "left release", input: "\033[<0;5;3m", wantMouse: MouseEvent{Button: MouseLeft, Action: MouseRelease, Row: 2, Col: 4}},
+		{name: "drag", input: "\033[<32;10;1M", wantMouse: MouseEvent{Button: MouseLeft, Action: MouseDrag, Row: 0, Col: 9}},
+		{name: "wheel up", input: "\033[<64;1;1M", wantMouse: MouseEvent{Button: MouseWheelUp, Row: 0, Col: 0}},
+		{name: "wheel down with ctrl", input: "\033[<81;1;1M", wantMouse: MouseEvent{Button: MouseWheelDown, Row: 0, Col: 0}, wantMod: ModCtrl},
+		{name: "right press with shift", input: "\033[<6;2;2M", wantMouse: MouseEvent{Button: MouseRight, Row: 1, Col: 1}, wantMod: ModShift},
+		{name: "old format", input: "\033[M %#", wantMouse: MouseEvent{Button: MouseLeft, Row: 2, Col: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewKeyDecoder(nil)
+			got := d.Feed([]byte(tt.input))
+			if len(got) != 1 || got[0].Key != prompt.Vt100MouseEvent {
+				t.Fatalf("got: %+v, want: one mouse event", got)
+			}
+			if got[0].Mouse != tt.wantMouse || got[0].Mod != tt.wantMod {
+				t.Errorf("got: %+v %v, want: %+v %v", got[0].Mouse, got[0].Mod, tt.wantMouse, tt.wantMod)
+			}
+		})
+	}
+}
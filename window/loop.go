@@ -0,0 +1,276 @@
+package window
+
+import (
+	"context"
+	"time"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// Run is the event loop of the editor. It owns the window: keys, resizes, timers and
+// the results of jobs all arrive over channels and are handled here one at a time,
+// so nothing else may touch the window while it runs. The screen is redrawn once
+// for all the events that arrived together.
+// Run returns when Quit is called, with the code given to it, or when ctx is done.
+func (w *Window) Run(ctx context.Context) (int, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w.quit = cancel
+	if w.callbacks == nil {
+		w.callbacks = make(chan func(*Window), 16)
+		w.done = make(chan struct{})
+	}
+	defer close(w.done)
+	if err := w.SetSize(); err != nil {
+		return 1, err
+	}
+	decoder := w.KeyDecoder()
+	// fires when a key sequence was not completed in time, ex) Escape typed alone
+	var timeout <-chan time.Time
+	// fires when the keys typed did not make a mapping in time, see map.go
+	var mapTimeout <-chan time.Time
+	events := w.Terminal.Events()
+	w.PrintFileContents()
+	for {
+		select {
+		case <-ctx.Done():
+			return w.exitCode(ctx)
+		case ev := <-events:
+			if err := w.handleEvent(ev, decoder); err != nil {
+				return 1, err
+			}
+		case <-timeout:
+			for _, k := range decoder.Flush() {
+				w.HandleKey(k)
+			}
+		case <-mapTimeout:
+			w.mapTypeahead(true)
+		case f := <-w.callbacks:
+			f(w)
+		}
+		// handle the rest of the batch before drawing
+	batch:
+		for w.quitCode == nil {
+			select {
+			case ev := <-events:
+				if err := w.handleEvent(ev, decoder); err != nil {
+					return 1, err
+				}
+			case f := <-w.callbacks:
+				f(w)
+			default:
+				break batch
+			}
+		}
+		if w.quitCode != nil {
+			return w.exitCode(ctx)
+		}
+		timeout = nil
+		if decoder.Pending() {
+			timeout = time.After(time.Duration(w.numberOption("ttimeoutlen")) * time.Millisecond)
+		}
+		mapTimeout = nil
+		if len(w.typeahead) > 0 {
+			mapTimeout = time.After(time.Duration(w.numberOption("timeoutlen")) * time.Millisecond)
+		}
+		w.PrintFileContents()
+	}
+}
+
+// exitCode returns what Run returns when ctx is done.
+func (w *Window) exitCode(ctx context.Context) (int, error) {
+	if w.quitCode != nil {
+		return *w.quitCode, nil
+	}
+	return 1, ctx.Err()
+}
+
+func (w *Window) handleEvent(ev Event, decoder *KeyDecoder) error {
+	switch ev.Type {
+	case EventError:
+		// the keys typed before the terminal hung up are handled
+		for _, k := range decoder.Flush() {
+			w.HandleKey(k)
+		}
+		w.mapTypeahead(true)
+		return ev.Err
+	case EventResize:
+		return w.SetSize()
+	case EventInput:
+		for _, k := range decoder.Feed(ev.Data) {
+			w.HandleKey(k)
+		}
+	}
+	return nil
+}
+
+// Quit makes Run return code, ex) after :q
+func (w *Window) Quit(code int) {
+	w.quitCode = &code
+	if w.quit != nil {
+		w.quit()
+	}
+}
+
+// Go runs job outside the event loop. The function it returns is called in the loop,
+// where it may use the window.
+func (w *Window) Go(job func() func(*Window)) {
+	go func() {
+		w.post(job())
+	}()
+}
+
+// AfterFunc calls f in the event loop after d.
+func (w *Window) AfterFunc(d time.Duration, f func(*Window)) *time.Timer {
+	return time.AfterFunc(d, func() {
+		w.post(f)
+	})
+}
+
+// post sends f to the event loop, unless it has finished.
+func (w *Window) post(f func(*Window)) {
+	select {
+	case w.callbacks <- f:
+	case <-w.done:
+	}
+}
+
+// HandleKey runs the key typed, or the keys of the mapping it makes with the keys typed before it,
+// see map.go.
+func (w *Window) HandleKey(k KeyEvent) {
+	if w.recording != 0 && w.executing == 0 {
+		w.record(k)
+	}
+	if k.Key == prompt.BracketedPaste || k.Key == prompt.Vt100MouseEvent {
+		w.mapTypeahead(true)
+		w.handleKey(k)
+		return
+	}
+	w.typeahead = append(w.typeahead, k)
+	w.mapTypeahead(false)
+}
+
+// splitAlt returns the key after Escape when k is Alt-x, which is handled as Escape and x
+// as terminals send it, ex) Escape typed just before Ctrl-C
+func splitAlt(k KeyEvent) (KeyEvent, bool) {
+	if k.Mod&ModAlt == 0 || len(k.Data) < 2 || k.Data[0] != 0x1b || k.Data[1] == '[' {
+		return k, false
+	}
+	k.Mod &^= ModAlt
+	k.Data = k.Data[1:]
+	return k, true
+}
+
+// handleKey runs a key without mapping it.
+func (w *Window) handleKey(k KeyEvent) {
+	if x, ok := splitAlt(k); ok {
+		w.handleKey(KeyEvent{Key: prompt.Escape, Data: []byte{0x1b}})
+		k = x
+	}
+	w.expandAbbreviation(k)
+	b := k.Data
+	switch {
+	case k.Key == prompt.BracketedPaste:
+		if w.IsInsertMode() {
+			w.recordInsert(k)
+		}
+		w.Paste(b)
+	case k.Key == prompt.Vt100MouseEvent:
+		w.handleMouse(k.Mouse)
+	case w.IsWaitingForKey() && w.DismissMessage(b):
+	case w.IsCommandMode():
+		w.commandKey(k)
+	case w.IsNormalMode() && (w.pending != "" || w.operator != ""):
+		switch k.Key {
+		case prompt.NotDefined, prompt.Enter, prompt.Tab:
+			// ex) the character after r or the motion after d
+			w.typeNormal(b)
+		default:
+			w.cancelNormal()
+		}
+	case w.IsInsertMode() && isInsertKey(k.Key):
+		w.recordInsert(k)
+		w.insertKey(k)
+	default:
+		if w.insertion != nil && isCursorKey(k.Key) {
+			// the text typed before the cursor moved is not inserted again
+			w.insertion.typed = nil
+			w.insertion.count = 1
+		}
+		switch k.Key {
+		case prompt.Up:
+			w.InputtedUp()
+		case prompt.Down:
+			w.InputtedDown()
+		case prompt.Left:
+			w.InputtedLeft()
+		case prompt.Right:
+			w.InputtedRight()
+		case prompt.ControlC:
+			if w.cmdwin != nil {
+				// the line of the cursor goes back to the command line
+				typ, line := w.cmdwin.typ, w.FileContents[w.position.Y-1]
+				w.closeCommandWindow()
+				w.startCommandLine(typ)
+				w.AddCommand(line)
+				return
+			}
+			w.interrupt()
+		case prompt.Enter:
+			if w.cmdwin != nil && w.IsNormalMode() {
+				w.executeCommandWindow()
+			}
+		case prompt.ControlR:
+			if w.IsNormalMode() {
+				w.Redo()
+			}
+		case prompt.ControlO, prompt.Tab:
+			if w.IsNormalMode() {
+				w.typeNormal(b)
+			}
+		case prompt.Escape:
+			if w.IsInsertMode() {
+				w.finishInsert()
+			}
+			w.cancelNormal()
+			w.SetNormalMode()
+		case prompt.NotDefined:
+			w.InputtedOther(b)
+		}
+	}
+}
+
+// interrupt is Ctrl-C: it ends insert and visual mode and forgets a count as Escape does.
+// In normal mode it quits, unless a buffer is modified.
+func (w *Window) interrupt() {
+	if !w.IsNormalMode() || w.count > 0 || w.register != 0 {
+		if w.IsInsertMode() {
+			w.finishInsert()
+		}
+		w.cancelNormal()
+		w.SetNormalMode()
+		return
+	}
+	if err := w.checkModified(); err != nil {
+		w.showError(err)
+		return
+	}
+	w.Quit(130)
+}
+
+// isInsertKey reports whether k edits the text in insert mode, see insertKey.
+func isInsertKey(k prompt.Key) bool {
+	switch k {
+	case prompt.Enter, prompt.Tab, prompt.Backspace, prompt.ControlH:
+		return true
+	}
+	return false
+}
+
+func isCursorKey(k prompt.Key) bool {
+	switch k {
+	case prompt.Up, prompt.Down, prompt.Left, prompt.Right:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+package window
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+// runWindow runs w until it quits and returns what Run returned.
+func runWindow(t *testing.T, ctx context.Context, w *Window) (int, error) {
+	t.Helper()
+	type result struct {
+		code int
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		code, err := w.Run(ctx)
+		done <- result{code, err}
+	}()
+	select {
+	case r := <-done:
+		return r.code, r.err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+		return 0, nil
+	}
+}
+
+// runTyped runs w on the keys typed in term until they are handled, the terminal then hangs up.
+func runTyped(t *testing.T, term *SimTerminal, w *Window) {
+	t.Helper()
+	term.Hangup()
+	if _, err := runWindow(t, context.Background(), w); err != io.EOF {
+		t.Fatalf("got: %v, want: %v", err, io.EOF)
+	}
+}
+
+func TestWindow_Run(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []string
+		wantCode int
+		wantRows []string
+	}{
+		{
+			name:     ":q quits",
+			input:    []string{":q\r"},
+			wantCode: 0,
+			wantRows: []string{"11111", "2222"},
+		},
+		{
+			name:     "ctrl-c",
+			input:    []string{"\x03"},
+			wantCode: 130,
+		},
+		{
+			name:     "keys split over reads",
+			input:    []string{"ia", "\xe3\x81", "\x82\033", "[D", "\033", ":q!\r"},
+			wantCode: 0,
+			wantRows: []string{"aあ11111", "2222"},
+		},
+		{
+			name:     "ctrl-c does not quit with changes",
+			input:    []string{"x\x03", ":q!\r"},
+			wantCode: 0,
+			wantRows: []string{"1111", "2222"},
+		},
+		{
+			name:     "ctrl-c ends insert mode",
+			input:    []string{"ia\x03", "x:q!\r"},
+			wantCode: 0,
+			wantRows: []string{"11111", "2222"},
+		},
+		{
+			name:     "ctrl-c leaves the command line",
+			input:    []string{":q", "\x03", "\x03"},
+			wantCode: 130,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(20, 4)
+			w := NewWindow(term)
+			w.FileContents = [][]byte{[]byte("11111"), []byte("2222")}
+			for _, in := range tt.input {
+				term.Type([]byte(in))
+			}
+			code, err := runWindow(t, context.Background(), w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("got: code %d, want: %d", code, tt.wantCode)
+			}
+			for i, want := range tt.wantRows {
+				if got := string(w.FileContents[i]); got != want {
+					t.Errorf("got: line %d = %q, want: %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWindow_interrupt(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		hidden      bool // another buffer is modified
+		wantLine    string
+		wantMode    int
+		wantMessage string
+	}{
+		{name: "modified", input: "x\x03", wantLine: "1111", wantMode: normalMode, wantMessage: errModified.Error()},
+		{name: "modified hidden buffer", input: "\x03", hidden: true, wantLine: "11111", wantMode: normalMode, wantMessage: `E162: No write since last change for buffer "b.txt"`},
+		{name: "visual mode", input: "vl\x03x", wantLine: "1111", wantMode: normalMode, wantMessage: errModified.Error()},
+		{name: "count", input: "3\x03x", wantLine: "1111", wantMode: normalMode, wantMessage: errModified.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(20, 4)
+			w := NewWindow(term)
+			w.FileContents = [][]byte{[]byte("11111")}
+			if tt.hidden {
+				w.buffers = []*buffer{{fileName: "b.txt", modified: true}}
+			}
+			term.Type([]byte(tt.input))
+			term.Type([]byte("\x03"))
+			runTyped(t, term, w)
+			if got := string(w.FileContents[0]); got != tt.wantLine {
+				t.Errorf("got: %q, want: %q", got, tt.wantLine)
+			}
+			if w.mode != tt.wantMode {
+				t.Errorf("got: mode %d, want: %d", w.mode, tt.wantMode)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_RunResize(t *testing.T) {
+	term := NewSimTerminal(20, 4)
+	w := NewWindow(term)
+	w.FileContents = [][]byte{[]byte("11111")}
+	term.Resize(30, 6)
+	term.Type([]byte(":q\r"))
+	if _, err := runWindow(t, context.Background(), w); err != nil {
+		t.Fatal(err)
+	}
+	if w.Size != (Size{Row: 6, Column: 30}) {
+		t.Errorf("got: %+v, want: %+v", w.Size, Size{Row: 6, Column: 30})
+	}
+}
+
+func TestWindow_RunCancel(t *testing.T) {
+	w := NewWindow(NewSimTerminal(20, 4))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := runWindow(t, ctx, w); err != context.Canceled {
+		t.Errorf("got: %v, want: %v", err, context.Canceled)
+	}
+}
+
+func TestWindow_RunJobs(t *testing.T) {
+	term := NewSimTerminal(20, 4)
+	w := NewWindow(term)
+	w.Go(func() func(*Window) {
+		// the job runs outside the loop, its result is applied in it
+		return func(w *Window) {
+			w.showMessage("job done", "")
+			w.AfterFunc(time.Millisecond, func(w *Window) { w.Quit(7) })
+		}
+	})
+	code, err := runWindow(t, context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != 7 {
+		t.Errorf("got: code %d, want: 7", code)
+	}
+	if got := term.Row(3); got != "job done" {
+		t.Errorf("got: %q, want: %q", got, "job done")
+	}
+}
+
+func TestWindow_RunRedrawsOncePerBatch(t *testing.T) {
+	term := NewSimTerminal(20, 4)
+	w := NewWindow(term)
+	w.FileContents = [][]byte{[]byte("")}
+	// the first frame is drawn before the input is read
+	term.Type([]byte("i"))
+	term.Type([]byte("a"))
+	term.Type([]byte("b"))
+	w.AfterFunc(50*time.Millisecond, func(w *Window) { w.Quit(0) })
+	if _, err := runWindow(t, context.Background(), w); err != nil {
+		t.Fatal(err)
+	}
+	if got := term.Row(0); got != "ab" {
+		t.Errorf("got: %q, want: %q", got, "ab")
+	}
+	// the typed keys are in one batch: the first frame, then only "ab"
+	if got, want := term.Out.String(), "\033[0m\033[H\033[2Jab"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+}
+
+func TestWindow_RunTtimeoutlen(t *testing.T) {
+	tests := []struct {
+		name        string
+		ttimeoutlen string
+		wantInsert  bool
+	}{
+		{name: "escape waits", ttimeoutlen: "5000", wantInsert: true},
+		{name: "escape alone", ttimeoutlen: "0", wantInsert: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(20, 4)
+			w := NewWindow(term)
+			w.FileContents = [][]byte{[]byte("")}
+			if err := w.ExecuteLine("set ttm=" + tt.ttimeoutlen); err != nil {
+				t.Fatal(err)
+			}
+			term.Type([]byte("i\033"))
+			var insert bool
+			w.AfterFunc(100*time.Millisecond, func(w *Window) {
+				insert = w.IsInsertMode()
+				w.Quit(0)
+			})
+			if _, err := runWindow(t, context.Background(), w); err != nil {
+				t.Fatal(err)
+			}
+			if insert != tt.wantInsert {
+				t.Errorf("got: insert mode %v, want: %v", insert, tt.wantInsert)
+			}
+		})
+	}
+}
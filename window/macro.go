@@ -0,0 +1,229 @@
+package window
+
+import (
+	"errors"
+	"fmt"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// maxExecuteDepth is how deep macros may execute macros, ex) a macro that executes itself
+const maxExecuteDepth = 1000
+
+// recordCommand is q followed by a register: the keys typed until q is typed again are kept in it,
+// an upper case register appends them. q:, q/ and q? open the command-line window instead.
+func (w *Window) recordCommand(k normalKeys) {
+	if len(k.arg) == 1 && (k.arg[0] == ':' || k.arg[0] == '/' || k.arg[0] == '?') {
+		w.openCommandWindow(k.arg[0], "")
+		return
+	}
+	if len(k.arg) != 1 || !isRegister(k.arg[0]) || k.arg[0] == '-' || k.arg[0] == '_' {
+		w.fail()
+		return
+	}
+	w.recording = k.arg[0]
+	w.recorded = nil
+}
+
+// record keeps a key typed while recording.
+func (w *Window) record(k KeyEvent) {
+	if x, ok := splitAlt(k); ok {
+		w.recorded = append(w.recorded, KeyEvent{Key: prompt.Escape, Data: []byte{0x1b}})
+		k = x
+	}
+	k.Data = append([]byte(nil), k.Data...)
+	w.recorded = append(w.recorded, k)
+}
+
+// keysText returns the bytes of keys as they were typed.
+func keysText(keys []KeyEvent) []byte {
+	var text []byte
+	for _, k := range keys {
+		if k.Key == prompt.BracketedPaste {
+			text = append(text, "\033[200~"...)
+			text = append(text, k.Data...)
+			text = append(text, "\033[201~"...)
+			continue
+		}
+		text = append(text, k.Data...)
+	}
+	return text
+}
+
+// stopRecording keeps the keys recorded in the register, without the q that stopped it.
+// The register keeps the keys as they were read besides their text, so an Escape followed
+// by a key is not executed as one key, ex) <Esc>O is not the start of a key of the keypad.
+func (w *Window) stopRecording() {
+	keys := w.recorded
+	if n := len(keys); n > 0 && keys[n-1].Key == prompt.NotDefined && string(keys[n-1].Data) == "q" {
+		keys = keys[:n-1]
+	}
+	name := w.recording
+	w.recording, w.recorded = 0, nil
+	if w.registers == nil {
+		w.registers = make(map[byte]register)
+	}
+	if 'A' <= name && name <= 'Z' {
+		name += 'a' - 'A'
+		if old, ok := w.registers[name]; ok {
+			keys = append(w.registerKeys(old), keys...)
+		}
+	}
+	w.registers[name] = register{text: keysText(keys), keys: keys}
+}
+
+// registerKeys returns the keys executing the register r types: the keys recorded in it,
+// or else its text read as keys.
+func (w *Window) registerKeys(r register) []KeyEvent {
+	if r.keys != nil {
+		return r.keys
+	}
+	text := r.text
+	if r.linewise {
+		// each line ends with a line break
+		text = concat(text, []byte("\n"))
+	}
+	return w.decodeKeys(text)
+}
+
+// decodeKeys reads text as keys, the keys of the terminal are read as they are typed.
+func (w *Window) decodeKeys(text []byte) []KeyEvent {
+	d := &KeyDecoder{keys: w.KeyDecoder().keys}
+	return append(d.Feed(text), d.Flush()...)
+}
+
+// executeRegisterCommand is @ followed by a register, it executes the text of the register count times
+// as if it was typed. @@ executes the register executed last and @: the last command line.
+func (w *Window) executeRegisterCommand(k normalKeys) {
+	name := k.arg[0]
+	if name == '@' {
+		name = w.lastExecuted
+		if name == 0 {
+			w.showError(errors.New("E748: No previously used register"))
+			return
+		}
+	}
+	if name == ':' {
+		w.lastExecuted = name
+		for i := 0; i < k.times(); i++ {
+			if err := w.ExecuteLine(w.lastCommandLine); err != nil {
+				w.showError(err)
+				return
+			}
+		}
+		return
+	}
+	r, ok := w.getRegister(name)
+	if !ok || !isRegister(name) {
+		w.fail()
+		return
+	}
+	w.lastExecuted = name
+	keys := w.registerKeys(r)
+	for i := 0; i < k.times(); i++ {
+		if !w.feedEvents(keys, true) {
+			return
+		}
+	}
+}
+
+// feedKeys handles keys as if they were typed, ex) a macro. The changes they make are undone at once.
+// It stops at the first command that fails and reports whether none did.
+func (w *Window) feedKeys(keys []byte) bool {
+	return w.feed(keys, true)
+}
+
+// feed is feedKeys, the keys are mapped only when remap is true.
+func (w *Window) feed(keys []byte, remap bool) bool {
+	return w.feedEvents(w.decodeKeys(keys), remap)
+}
+
+// feedEvents handles keys already read as if they were typed.
+// The keys typed before wait in the typeahead until the keys fed are handled.
+func (w *Window) feedEvents(keys []KeyEvent, remap bool) bool {
+	if w.executing >= maxExecuteDepth {
+		w.showError(errors.New("E169: Command too recursive"))
+		return false
+	}
+	if w.executing == 0 {
+		w.failed = false
+	}
+	w.startExecuting()
+	defer w.stopExecuting()
+	typeahead := w.typeahead
+	w.typeahead = nil
+	defer func() { w.typeahead = typeahead }()
+	for _, k := range keys {
+		if remap {
+			w.HandleKey(k)
+		} else {
+			w.handleKey(k)
+		}
+		if w.failed {
+			return false
+		}
+	}
+	// the keys do not wait for more to make a mapping
+	w.mapTypeahead(true)
+	return !w.failed
+}
+
+// startExecuting starts executing keys that were not typed, they are not recorded
+// and the changes they make are one undo step.
+func (w *Window) startExecuting() {
+	w.executing++
+}
+
+func (w *Window) stopExecuting() {
+	w.executing--
+	if w.executing == 0 {
+		w.undoGrouped = false
+	}
+}
+
+// fail stops the macro being executed, ex) when a motion cannot move.
+func (w *Window) fail() {
+	w.failed = true
+}
+
+// normalExCommand is :normal, it executes its argument as keys typed in normal mode,
+// with a range on each line with the cursor at its start.
+func (w *Window) normalExCommand(c exArgs) error {
+	if c.args == "" {
+		return errors.New("E471: Argument required")
+	}
+	w.startExecuting()
+	defer w.stopExecuting()
+	if !c.given {
+		w.executeNormal(c.args)
+		return nil
+	}
+	for y := c.first; y <= c.last && y <= len(w.FileContents); y++ {
+		w.position = Position{X: 1, Y: y}
+		w.executeNormal(c.args)
+	}
+	return nil
+}
+
+// executeNormal executes keys in normal mode, a command they leave unfinished is ended as with Escape.
+func (w *Window) executeNormal(keys string) {
+	w.failed = false
+	w.feedKeys([]byte(keys))
+	w.failed = false
+	switch w.mode {
+	case insertMode:
+		w.finishInsert()
+	case commandMode:
+		w.ResetCommand()
+	}
+	w.SetNormalMode()
+	w.cancelNormal()
+}
+
+// recordingMessage is shown while keys are recorded, ex) recording @a
+func (w *Window) recordingMessage() string {
+	if w.recording == 0 {
+		return ""
+	}
+	return fmt.Sprintf("recording @%c", w.recording)
+}
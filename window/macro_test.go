@@ -0,0 +1,117 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_Macros(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "record and execute", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "qaxq@a", wantLines: []string{"cd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "execute with a count", lines: []string{"abcde"}, position: Position{X: 1, Y: 1}, input: "qaxq2@a", wantLines: []string{"de"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "execute the last register again", lines: []string{"abcde"}, position: Position{X: 1, Y: 1}, input: "qaxq@a@@", wantLines: []string{"de"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "no register executed", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "@@", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E748: No previously used register"},
+		{name: "insert mode keys", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: "qaA!\033jq@a@@", wantLines: []string{"a!", "b!", "c!"}, wantPosition: Position{X: 2, Y: 3}},
+		{name: "upper case register appends", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "qaxqqAxq@a", wantLines: []string{""}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "recursive macro stops when a motion fails", lines: []string{"1", "2", "3", "4"}, position: Position{X: 1, Y: 1}, input: "qaqqaA!\033j@aq@a", wantLines: []string{"1!", "2!", "3!", "4!"}, wantPosition: Position{X: 2, Y: 4}},
+		{name: "count stops when a motion fails", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "qaA!\033jq9@a", wantLines: []string{"1!", "2!", "3!"}, wantPosition: Position{X: 2, Y: 3}},
+		{name: "too recursive", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "qaqqa@aq@a", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E169: Command too recursive"},
+		{name: "undone at once", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "qaxxq@au", wantLines: []string{"cdef"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "edit a macro", lines: []string{"abc", ""}, position: Position{X: 1, Y: 1}, input: "qaA-\033qj\"ap0lr+0\"ay$ddk@a", wantLines: []string{"abc-+"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "linewise register", lines: []string{"abc", "x"}, position: Position{X: 1, Y: 1}, input: "j\"byyk@b", wantLines: []string{"bc", "x"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "last command line", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: ":2\r:3\rgg@:", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_macroOfKeysTypedApart(t *testing.T) {
+	// each part is read apart from the next one, ex) Escape alone after the escape timeout
+	parts := []string{"qaA;", "\033", "Ox", "\033", "jq", "@a"}
+	w := NewWindow(NewSimTerminal(20, 8))
+	w.FileContents = [][]byte{[]byte("a"), []byte("b")}
+	for _, p := range parts {
+		d := w.KeyDecoder()
+		for _, k := range append(d.Feed([]byte(p)), d.Flush()...) {
+			w.HandleKey(k)
+		}
+	}
+	want := []string{"x", "x", "a;;", "b"}
+	if got := linesOf(w.FileContents); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	if got, want := string(w.registers['a'].text), "A;\033Ox\033j"; got != want {
+		t.Errorf("got: register %q, want: %q", got, want)
+	}
+}
+
+func TestWindow_NormalExCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "cursor line", lines: []string{"ab", "cd"}, position: Position{X: 2, Y: 2}, input: ":normal x\r", wantLines: []string{"ab", "c"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "range", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: ":1,2norm Ax\r", wantLines: []string{"1x", "2x", "3"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "macro on every line", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "qqA;\033q:2,$normal @q\r", wantLines: []string{"1;", "2;", "3;"}, wantPosition: Position{X: 2, Y: 3}},
+		{name: "undone at once", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: ":%norm x\ru", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "a failure stops a line only", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: ":%norm kx\r", wantLines: []string{"", "", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "no argument", lines: []string{"1"}, position: Position{X: 1, Y: 1}, input: ":normal\r", wantLines: []string{"1"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E471: Argument required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_recordingMessage(t *testing.T) {
+	term := NewSimTerminal(20, 4)
+	w := NewWindow(term)
+	w.FileContents = [][]byte{[]byte("a")}
+	for _, k := range w.KeyDecoder().Feed([]byte("qb")) {
+		w.HandleKey(k)
+	}
+	if got, want := w.recordingMessage(), "recording @b"; got != want {
+		t.Errorf("got: %q, want: %q", got, want)
+	}
+	for _, k := range w.KeyDecoder().Feed([]byte("q")) {
+		w.HandleKey(k)
+	}
+	if got := w.recordingMessage(); got != "" {
+		t.Errorf("got: %q, want: %q", got, "")
+	}
+}
@@ -0,0 +1,422 @@
+package window
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// Mappings make keys typed stand for other keys, ex) :nmap <leader>w :w<CR>
+// The keys typed wait in the typeahead while they are the start of a longer mapping, until
+// timeoutlen passed. The keys of a mapping are mapped again, unless it was defined with
+// a noremap command.
+
+// mapping is the keys lhs standing for the keys rhs in some modes.
+type mapping struct {
+	modes   string // the modes it is used in: n normal, v visual, o operator pending, i insert, c command line
+	lhs     string
+	rhs     string
+	noremap bool   // the keys of rhs are not mapped again
+	silent  bool   // the command line of rhs is not left on the screen
+	source  string // where it was defined, ex) /home/gim/.gimrc line 3
+}
+
+// keyNames are the keys written by name in mappings, ex) <CR>
+// key is the key decoded from the terminal for the keys sent as a sequence, ex) prompt.Up
+var keyNames = []struct {
+	name string
+	key  prompt.Key
+	seq  string
+}{
+	{"CR", prompt.NotDefined, "\r"},
+	{"Enter", prompt.NotDefined, "\r"},
+	{"Return", prompt.NotDefined, "\r"},
+	{"NL", prompt.NotDefined, "\n"},
+	{"Esc", prompt.NotDefined, "\x1b"},
+	{"Tab", prompt.NotDefined, "\t"},
+	{"BS", prompt.NotDefined, "\x7f"},
+	{"Space", prompt.NotDefined, " "},
+	{"lt", prompt.NotDefined, "<"},
+	{"Bar", prompt.NotDefined, "|"},
+	{"Bslash", prompt.NotDefined, "\\"},
+	{"Nop", prompt.NotDefined, ""},
+	{"Del", prompt.Delete, "\x1b[3~"},
+	{"Up", prompt.Up, "\x1b[A"},
+	{"Down", prompt.Down, "\x1b[B"},
+	{"Right", prompt.Right, "\x1b[C"},
+	{"Left", prompt.Left, "\x1b[D"},
+	{"Home", prompt.Home, "\x1b[H"},
+	{"End", prompt.End, "\x1b[F"},
+	{"PageUp", prompt.PageUp, "\x1b[5~"},
+	{"PageDown", prompt.PageDown, "\x1b[6~"},
+	{"Insert", prompt.Insert, "\x1b[2~"},
+	{"S-Tab", prompt.BackTab, "\x1b[Z"},
+	{"S-Up", prompt.ShiftUp, "\x1b[1;2A"},
+	{"S-Down", prompt.ShiftDown, "\x1b[1;2B"},
+	{"S-Right", prompt.ShiftRight, "\x1b[1;2C"},
+	{"S-Left", prompt.ShiftLeft, "\x1b[1;2D"},
+	{"S-Del", prompt.ShiftDelete, "\x1b[3;2~"},
+	{"C-Up", prompt.ControlUp, "\x1b[1;5A"},
+	{"C-Down", prompt.ControlDown, "\x1b[1;5B"},
+	{"C-Right", prompt.ControlRight, "\x1b[1;5C"},
+	{"C-Left", prompt.ControlLeft, "\x1b[1;5D"},
+	{"C-Del", prompt.ControlDelete, "\x1b[3;5~"},
+	{"F1", prompt.F1, "\x1bOP"},
+	{"F2", prompt.F2, "\x1bOQ"},
+	{"F3", prompt.F3, "\x1bOR"},
+	{"F4", prompt.F4, "\x1bOS"},
+	{"F5", prompt.F5, "\x1b[15~"},
+	{"F6", prompt.F6, "\x1b[17~"},
+	{"F7", prompt.F7, "\x1b[18~"},
+	{"F8", prompt.F8, "\x1b[19~"},
+	{"F9", prompt.F9, "\x1b[20~"},
+	{"F10", prompt.F10, "\x1b[21~"},
+	{"F11", prompt.F11, "\x1b[23~"},
+	{"F12", prompt.F12, "\x1b[24~"},
+}
+
+// parseKeys returns the keys written in a mapping, ex) \x17w for <C-w>w
+// <leader> is the mapleader option, \ when it is empty.
+func (w *Window) parseKeys(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		end := strings.IndexByte(s[i:], '>')
+		if s[i] != '<' || end < 0 {
+			b.WriteByte(s[i])
+			i++
+			continue
+		}
+		name := s[i+1 : i+end]
+		if keys, ok := w.namedKeys(name); ok {
+			b.WriteString(keys)
+			i += end + 1
+			continue
+		}
+		b.WriteByte('<')
+		i++
+	}
+	return b.String()
+}
+
+// namedKeys returns the keys of a name written in <>, ex) Esc or C-w
+func (w *Window) namedKeys(name string) (string, bool) {
+	if strings.EqualFold(name, "leader") {
+		leader := w.stringOption("mapleader")
+		if leader == "" || strings.Contains(strings.ToLower(leader), "<leader>") {
+			return "\\", true
+		}
+		return w.parseKeys(leader), true
+	}
+	for _, k := range keyNames {
+		if strings.EqualFold(k.name, name) {
+			return k.seq, true
+		}
+	}
+	if len(name) == 3 && (name[:2] == "C-" || name[:2] == "c-") {
+		c := name[2]
+		if 'a' <= c && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		switch {
+		case '@' <= c && c <= '_':
+			return string([]byte{c - '@'}), true
+		case c == '?':
+			return "\x7f", true
+		}
+	}
+	return "", false
+}
+
+// keyNotation returns how the keys are written in a mapping, ex) <C-W>w
+// A space is <Space> in lhs, where it would end the keys.
+func keyNotation(keys string, lhs bool) string {
+	if keys == "" {
+		return "<Nop>"
+	}
+	var b strings.Builder
+next:
+	for i := 0; i < len(keys); {
+		for _, k := range keyNames {
+			if len(k.seq) > 1 && strings.HasPrefix(keys[i:], k.seq) {
+				b.WriteString("<" + k.name + ">")
+				i += len(k.seq)
+				continue next
+			}
+		}
+		c := keys[i]
+		switch {
+		case c == '\r':
+			b.WriteString("<CR>")
+		case c == '\n':
+			b.WriteString("<NL>")
+		case c == 0x1b:
+			b.WriteString("<Esc>")
+		case c == '\t':
+			b.WriteString("<Tab>")
+		case c == 0x7f:
+			b.WriteString("<BS>")
+		case c == ' ' && lhs:
+			b.WriteString("<Space>")
+		case c < ' ':
+			b.WriteString("<C-" + string(rune(c+'@')) + ">")
+		default:
+			b.WriteByte(c)
+		}
+		i++
+	}
+	return b.String()
+}
+
+// keyString returns the keys of k as they are matched with mappings, ex) \x1b[A for Up
+// whichever sequence the terminal sent. A key held with more modifiers than its name says,
+// ex) Alt-Left, is matched by the sequence sent.
+func keyString(k KeyEvent) string {
+	for _, n := range keyNames {
+		if n.key != prompt.NotDefined && n.key == k.Key && k.Mod == keyMods[k.Key] {
+			return n.seq
+		}
+	}
+	return string(k.Data)
+}
+
+// mapMode returns the mode of the mappings used for the next key, 0 when none are.
+// The keys after commands such as r, g or a register are not mapped.
+func (w *Window) mapMode() byte {
+	switch {
+	case w.IsWaitingForKey():
+		return 0
+	case w.mode == insertMode:
+		return 'i'
+	case w.mode == commandMode:
+		if w.commandRegister {
+			return 0
+		}
+		return 'c'
+	case w.pending != "":
+		return 0
+	case w.mode == visualMode:
+		return 'v'
+	case w.operator != "":
+		return 'o'
+	}
+	return 'n'
+}
+
+// findMapping returns the longest mapping of mode that keys start with, and the number of the keys
+// it takes. longer is true when keys are the start of a longer mapping.
+func (w *Window) findMapping(mode byte, keys []KeyEvent) (m *mapping, n int, longer bool) {
+	if mode == 0 {
+		return nil, 0, false
+	}
+	var typed []string
+	all := ""
+	for _, k := range keys {
+		all += keyString(k)
+		typed = append(typed, all)
+	}
+	for _, mp := range w.mappings {
+		if strings.IndexByte(mp.modes, mode) < 0 {
+			continue
+		}
+		if len(mp.lhs) > len(all) && strings.HasPrefix(mp.lhs, all) {
+			longer = true
+			continue
+		}
+		for i, t := range typed {
+			if t == mp.lhs && (m == nil || len(mp.lhs) > len(m.lhs)) {
+				m, n = mp, i+1
+			}
+		}
+	}
+	return m, n, longer
+}
+
+// mapTypeahead runs the keys of the typeahead, replacing those that make a mapping.
+// It waits for more keys while they may make a longer mapping, unless timedOut.
+func (w *Window) mapTypeahead(timedOut bool) {
+	for len(w.typeahead) > 0 {
+		m, n, longer := w.findMapping(w.mapMode(), w.typeahead)
+		if longer && !timedOut {
+			return
+		}
+		if m != nil {
+			w.typeahead = w.typeahead[n:]
+			w.executeMapping(m)
+			continue
+		}
+		k := w.typeahead[0]
+		w.typeahead = w.typeahead[1:]
+		w.handleKey(k)
+	}
+}
+
+// executeMapping handles the keys of the mapping m as if they were typed.
+func (w *Window) executeMapping(m *mapping) {
+	if w.executing >= maxExecuteDepth {
+		w.showError(errors.New("E223: Recursive mapping"))
+		return
+	}
+	if w.executing == 0 {
+		w.failed = false
+	}
+	outer := w.mapping
+	w.mapping = m
+	w.startExecuting()
+	defer func() {
+		w.stopExecuting()
+		w.mapping = outer
+	}()
+	switch {
+	case m.noremap:
+		w.feed([]byte(m.rhs), false)
+	case strings.HasPrefix(m.rhs, m.lhs):
+		// the keys mapped are not mapped again at the start, ex) :nmap x xp
+		if w.feed([]byte(m.lhs), false) {
+			w.feed([]byte(m.rhs[len(m.lhs):]), true)
+		}
+	default:
+		w.feed([]byte(m.rhs), true)
+	}
+}
+
+// mapCommand returns an ex command defining or listing the mappings of modes, ex) :nmap
+// With ! the commands for every mode are for insert and command-line modes, ex) :map!
+func mapCommand(modes string, noremap bool) func(*Window, exArgs) error {
+	return func(w *Window, c exArgs) error {
+		modes, err := bangModes(modes, c.bang)
+		if err != nil {
+			return err
+		}
+		return w.defineMapping(&w.mappings, modes, noremap, c.args, "No mapping found")
+	}
+}
+
+// defineMapping adds the mapping of args to list, ex) <silent> x dd
+// Without its keys, it lists the mappings starting with lhs or shows none when there is no one.
+func (w *Window) defineMapping(list *[]*mapping, modes string, noremap bool, args, none string) error {
+	silent := false
+	for strings.HasPrefix(strings.ToLower(args), "<silent>") {
+		args, silent = strings.TrimLeft(args[len("<silent>"):], " \t"), true
+	}
+	lhs, rhs := args, ""
+	if i := strings.IndexAny(args, " \t"); i >= 0 {
+		lhs, rhs = args[:i], strings.TrimLeft(args[i:], " \t")
+	}
+	if rhs == "" {
+		w.listMappings(*list, modes, w.parseKeys(lhs), none)
+		return nil
+	}
+	m := &mapping{modes: modes, lhs: w.parseKeys(lhs), rhs: w.parseKeys(rhs), noremap: noremap, silent: silent, source: w.sourcing}
+	if m.lhs == "" {
+		return fmt.Errorf("E474: Invalid argument: %s", lhs)
+	}
+	removeMappings(list, modes, m.lhs)
+	*list = append(*list, m)
+	return nil
+}
+
+// unmapCommand returns an ex command deleting a mapping of modes, ex) :nunmap
+func unmapCommand(modes string) func(*Window, exArgs) error {
+	return func(w *Window, c exArgs) error {
+		modes, err := bangModes(modes, c.bang)
+		if err != nil {
+			return err
+		}
+		if c.args == "" {
+			return errors.New("E471: Argument required")
+		}
+		if !removeMappings(&w.mappings, modes, w.parseKeys(c.args)) {
+			return errors.New("E31: No such mapping")
+		}
+		return nil
+	}
+}
+
+// mapclearCommand returns an ex command deleting the mappings of modes, ex) :imapclear
+func mapclearCommand(modes string) func(*Window, exArgs) error {
+	return func(w *Window, c exArgs) error {
+		modes, err := bangModes(modes, c.bang)
+		if err != nil {
+			return err
+		}
+		for _, m := range append([]*mapping(nil), w.mappings...) {
+			removeMappings(&w.mappings, modes, m.lhs)
+		}
+		return nil
+	}
+}
+
+// bangModes returns the modes of a map command typed with or without !
+func bangModes(modes string, bang bool) (string, error) {
+	if !bang {
+		return modes, nil
+	}
+	if modes != "nvo" {
+		return "", errors.New("E477: No ! allowed")
+	}
+	return "ic", nil
+}
+
+// removeMappings removes the modes from the mappings of lhs in list and reports whether there was one.
+func removeMappings(list *[]*mapping, modes, lhs string) bool {
+	found := false
+	var kept []*mapping
+	for _, m := range *list {
+		if m.lhs == lhs && strings.ContainsAny(m.modes, modes) {
+			found = true
+			left := m.modes
+			for _, c := range modes {
+				left = strings.Replace(left, string(c), "", 1)
+			}
+			if left == "" {
+				continue
+			}
+			m.modes = left
+		}
+		kept = append(kept, m)
+	}
+	*list = kept
+	return found
+}
+
+// listMappings shows the mappings of modes in list starting with lhs, and where they were defined,
+// or none when there is no one. ex) n  <Space>w    * :w<CR>
+func (w *Window) listMappings(list []*mapping, modes, lhs, none string) {
+	var found []*mapping
+	for _, m := range list {
+		if strings.ContainsAny(m.modes, modes) && strings.HasPrefix(m.lhs, lhs) {
+			found = append(found, m)
+		}
+	}
+	if len(found) == 0 {
+		w.showMessage(none, "")
+		return
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].lhs < found[j].lhs })
+	var out []string
+	for _, m := range found {
+		flag := " "
+		if m.noremap {
+			flag = "*"
+		}
+		out = append(out, fmt.Sprintf("%-3s%-11s %s %s", modesName(m.modes), keyNotation(m.lhs, true), flag, keyNotation(m.rhs, false)))
+		if m.source != "" {
+			out = append(out, "\tLast set from "+m.source)
+		}
+	}
+	w.showMessage(strings.Join(out, "\n"), "")
+}
+
+// modesName returns how the modes of a mapping are listed, ex) ! for insert and command-line modes
+func modesName(modes string) string {
+	switch modes {
+	case "nvo":
+		return " "
+	case "ic":
+		return "!"
+	}
+	return modes
+}
@@ -0,0 +1,149 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func TestWindow_mappings(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "normal", input: ":nmap x dd\rx", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "more keys", input: ":nmap xy dd\rxy", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "not the mapping", input: ":nmap xy dd\rxl", wantLines: []string{"bc", "def", "ghi"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "count", input: ":nmap x dd\r2x", wantLines: []string{"ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "mapped again", input: ":nmap Q dd\r:nmap x Q\rx", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "noremap", input: ":nmap j dd\r:nnoremap x j\rx", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "starting with itself", input: ":nmap x xp\rx", wantLines: []string{"bac", "def", "ghi"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "recursive", input: ":nmap x y\r:nmap y x\rx", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E223: Recursive mapping"},
+		{name: "insert", input: ":imap jk <Esc>\rAjjkx", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "visual", input: ":vmap q v\rvqx", wantLines: []string{"bc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "not in insert mode", input: ":nmap x dd\rix\x1b", wantLines: []string{"xabc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "map is not for insert mode", input: ":map x dd\rix\x1b", wantLines: []string{"xabc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "map! is for insert mode", input: ":map! x y\rix\x1b", wantLines: []string{"yabc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "operator pending", input: ":map L $\rdL", wantLines: []string{"", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "not after r", input: ":nmap x dd\rrx", wantLines: []string{"xbc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "command line", input: ":cnoremap <C-a> <Home>\r:et ts=4\x01s\r", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "leader", input: ":set mapleader=,\r:nmap <leader>d dd\r,d", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "default leader", input: ":nmap <Leader>d dd\r\\d", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "command line shown", input: ":nmap x :set ts=4<CR>\rx", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: ":set ts=4"},
+		{name: "silent", input: ":nmap <silent> x :set ts=4<CR>\rx", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "unmap", input: ":nmap x dd\r:nunmap x\rx", wantLines: []string{"bc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "unmap another mode", input: ":map x dd\r:vunmap x\rx", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "no such mapping", input: ":nunmap x\r", wantLines: []string{"abc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E31: No such mapping"},
+		{name: "mapclear", input: ":nmap x dd\r:nmap X dd\r:nmapclear\rx", wantLines: []string{"bc", "def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dot repeats the keys of the mapping", input: ":nmap x dd\rx.", wantLines: []string{"ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "function key", input: ":nmap <F2> dd\r\x1bOQ", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "function key sent in another form", input: ":nmap <F2> dd\r\x1b[12~", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "modified cursor key", input: ":nmap <C-Right> dd\r\x1b[1;5C", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "insert key", input: ":nmap <Insert> dd\r\x1b[2~", wantLines: []string{"def", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "recorded as typed", input: ":nmap x dd\rqaxq@a", wantLines: []string{"ghi"}, wantPosition: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, []string{"abc", "def", "ghi"}, Position{X: 1, Y: 1}, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_mapTimeout(t *testing.T) {
+	w := NewWindow(NewSimTerminal(20, 8))
+	w.FileContents = [][]byte{[]byte("abc"), []byte("def")}
+	if err := w.ExecuteLine("nmap xy dd"); err != nil {
+		t.Fatal(err)
+	}
+	w.HandleKey(KeyEvent{Key: prompt.NotDefined, Data: []byte("x")})
+	if got := linesOf(w.FileContents); !reflect.DeepEqual(got, []string{"abc", "def"}) {
+		t.Errorf("got: %q, want: the key waiting for the mapping", got)
+	}
+	w.mapTypeahead(true)
+	if got := linesOf(w.FileContents); !reflect.DeepEqual(got, []string{"bc", "def"}) {
+		t.Errorf("got: %q, want: the key handled after the timeout", got)
+	}
+}
+
+func TestWindow_listMappings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "gimrc")
+	if err := ioutil.WriteFile(path, []byte("\" mappings\nnnoremap <Space>w :w<CR>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		commands    []string
+		wantMessage string
+	}{
+		{name: "all", commands: []string{"so " + path, "map x <C-w>l", "imap jk <Esc>", "map"}, wantMessage: strings.Join([]string{
+			"n  <Space>w    * :w<CR>",
+			"\tLast set from " + path + " line 2",
+			"   x             <C-W>l",
+		}, "\n")},
+		{name: "insert", commands: []string{"map x <C-w>l", "imap jk <Esc>", "map! j <Nop>", "imap"}, wantMessage: "!  j             <Nop>\ni  jk            <Esc>"},
+		{name: "starting with", commands: []string{"nmap ab x", "nmap b x", "nmap a"}, wantMessage: "n  ab            x"},
+		{name: "none", commands: []string{"nmap"}, wantMessage: "No mapping found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			for _, c := range tt.commands {
+				if err := w.ExecuteLine(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_parseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		keys string
+		want string
+	}{
+		{name: "text", keys: "dd", want: "dd"},
+		{name: "names", keys: "<Esc>:w<cr>", want: "\x1b:w\r"},
+		{name: "control", keys: "<C-w>l", want: "\x17l"},
+		{name: "less than", keys: "a<lt>b<", want: "a<b<"},
+		{name: "unknown name", keys: "<foo>", want: "<foo>"},
+		{name: "leader", keys: "<leader>w", want: "\\w"},
+		{name: "cursor key", keys: "<Up>", want: "\x1b[A"},
+		{name: "function key", keys: "<F2>", want: "\x1bOQ"},
+		{name: "modified key", keys: "<C-Left><s-up>", want: "\x1b[1;5D\x1b[1;2A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Window{}
+			if got := w.parseKeys(tt.keys); got != tt.want {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
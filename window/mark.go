@@ -0,0 +1,333 @@
+package window
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Marks remember positions in the buffer, ex) ma then 'a goes back to the line.
+// a-z are local to the buffer and A-Z are file marks, which remember the file too.
+// The others are set by the editor:
+//
+//	' or `  the position before the last jump
+//	.       the last change
+//	^       where insert mode was stopped
+//	[ ]     the first and last character of the text last changed or yanked
+//	< >     the start and end of the last selection
+//
+// Marks move with the lines they are on as lines are inserted and deleted, see adjustMarks.
+
+// fileMark is a mark of A-Z.
+type fileMark struct {
+	fileName string
+	position Position
+}
+
+// maxJumps is how many positions the jump list keeps.
+const maxJumps = 100
+
+var (
+	errMarkNotSet  = errors.New("E20: Mark not set")
+	errUnknownMark = errors.New("E78: Unknown mark")
+)
+
+// markOrder is the order :marks lists the marks in.
+const markOrder = "'abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ[]^.<>"
+
+func isLocalMark(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
+
+func isFileMark(c byte) bool {
+	return 'A' <= c && c <= 'Z'
+}
+
+// markCommand is m, it sets the mark typed after it to the cursor position, ex) ma
+func (w *Window) markCommand(k normalKeys) {
+	if len(k.arg) != 1 {
+		w.showError(errors.New("E191: Argument must be a letter or forward/backward quote"))
+		return
+	}
+	if err := w.setMark(k.arg[0], w.position); err != nil {
+		w.showError(err)
+	}
+}
+
+// setMark sets the mark name to p. m' and m` add p to the jump list.
+func (w *Window) setMark(name byte, p Position) error {
+	switch {
+	case name == '\'' || name == '`':
+		w.pushJump(p)
+	case isFileMark(name):
+		if w.fileMarks == nil {
+			w.fileMarks = make(map[byte]fileMark)
+		}
+		w.fileMarks[name] = fileMark{fileName: w.fileName, position: p}
+	case isLocalMark(name) || strings.IndexByte("[]<>", name) >= 0:
+		w.putMark(name, p)
+	default:
+		return errors.New("E191: Argument must be a letter or forward/backward quote")
+	}
+	return nil
+}
+
+func (w *Window) putMark(name byte, p Position) {
+	if w.marks == nil {
+		w.marks = make(map[byte]Position)
+	}
+	w.marks[name] = p
+}
+
+// markPosition returns where the mark name is, on a line of the buffer.
+func (w *Window) markPosition(name byte) (Position, error) {
+	if name == '`' {
+		name = '\''
+	}
+	var p Position
+	switch {
+	case isFileMark(name):
+		m, ok := w.fileMarks[name]
+		// the marks of other files are not in this buffer
+		if !ok || m.fileName != w.fileName {
+			return p, errMarkNotSet
+		}
+		p = m.position
+	case isLocalMark(name) || strings.IndexByte("'.^[]<>", name) >= 0:
+		var ok bool
+		if p, ok = w.marks[name]; !ok {
+			return p, errMarkNotSet
+		}
+	default:
+		return p, errUnknownMark
+	}
+	if len(w.FileContents) == 0 {
+		return Position{X: 1, Y: 1}, nil
+	}
+	if p.Y > len(w.FileContents) {
+		p.Y = len(w.FileContents)
+	}
+	if line := w.FileContents[p.Y-1]; p.X > len(line) {
+		p.X = len(line) + 1
+	}
+	return p, nil
+}
+
+// moveToMark is ' and ` followed by a mark: ' goes to the first non-blank character of its line.
+// A mark A-Z of another file edits that file first, which an operator can not do.
+func (w *Window) moveToMark(arg []byte, linewise bool, op bool) (Position, bool) {
+	if len(arg) != 1 {
+		w.showError(errUnknownMark)
+		return w.position, false
+	}
+	if m, ok := w.fileMarks[arg[0]]; ok && isFileMark(arg[0]) && m.fileName != w.fileName {
+		if op {
+			return w.position, false
+		}
+		if err := w.editCommand(exArgs{args: m.fileName}); err != nil {
+			w.showError(err)
+			return w.position, false
+		}
+	}
+	p, err := w.markPosition(arg[0])
+	if err != nil {
+		w.showError(err)
+		return w.position, false
+	}
+	if linewise {
+		p.X = w.firstNonBlank(p.Y)
+	}
+	return p, true
+}
+
+// pushJump adds p to the end of the jump list, as the position before a jump, ex) before G
+// A line is in the list once.
+func (w *Window) pushJump(p Position) {
+	jumps := w.jumps[:0:0]
+	for _, j := range w.jumps {
+		if j.Y != p.Y {
+			jumps = append(jumps, j)
+		}
+	}
+	jumps = append(jumps, p)
+	if len(jumps) > maxJumps {
+		jumps = jumps[len(jumps)-maxJumps:]
+	}
+	w.jumps = jumps
+	w.jumpIndex = len(jumps)
+	w.putMark('\'', p)
+}
+
+// jumpCommand is Ctrl-O and Ctrl-I (Tab), they go to count older or newer positions of the jump list.
+func (w *Window) jumpCommand(k normalKeys) {
+	n := k.times()
+	if k.name == "\x0f" {
+		n = -n
+		if w.jumpIndex == len(w.jumps) {
+			// the position left is kept to come back to with Ctrl-I
+			w.pushJump(w.position)
+			w.jumpIndex--
+		}
+	}
+	i := w.jumpIndex + n
+	if i < 0 || i >= len(w.jumps) || len(w.FileContents) == 0 {
+		w.fail()
+		return
+	}
+	w.jumpIndex = i
+	p := w.jumps[i]
+	if p.Y > len(w.FileContents) {
+		p.Y = len(w.FileContents)
+	}
+	w.position = p
+	w.clampCursor()
+}
+
+// changed extends the marks [ and ] over the text changed from from to to, and sets . to from.
+// They cover all the text a change makes, which starts when the buffer is saved for undo.
+func (w *Window) changed(from, to Position) {
+	if w.changeMarked {
+		if start := w.marks['[']; before(start, from) {
+			from = start
+		}
+		if end := w.marks[']']; before(to, end) {
+			to = end
+		}
+	}
+	w.putMark('.', from)
+	w.putMark('[', from)
+	w.putMark(']', to)
+	w.changeMarked = true
+}
+
+// before reports whether p is before q in the buffer.
+func before(p, q Position) bool {
+	return p.Y < q.Y || p.Y == q.Y && p.X < q.X
+}
+
+// changedSpan returns the bytes [from, to) of line that are not in old, ex) the character typed.
+func changedSpan(old, line []byte) (from, to int) {
+	for from < len(old) && from < len(line) && old[from] == line[from] {
+		from++
+	}
+	to = len(line)
+	for to > from && len(old)-(len(line)-to) > from && old[len(old)-(len(line)-to)-1] == line[to-1] {
+		to--
+	}
+	return from, to
+}
+
+// adjustMarks moves the marks below the i-th line (0-indexed) as n lines are inserted there,
+// or -n lines are deleted from there. The marks of letters on deleted lines are deleted,
+// the others move to the line after them.
+func (w *Window) adjustMarks(i, n int) {
+	adjust := func(p Position) (Position, bool) {
+		switch {
+		case p.Y <= i:
+		case n > 0 || p.Y > i-n:
+			p.Y += n
+		default:
+			return Position{X: 1, Y: i + 1}, false
+		}
+		return p, true
+	}
+	for name, p := range w.marks {
+		q, kept := adjust(p)
+		if !kept && isLocalMark(name) {
+			delete(w.marks, name)
+			continue
+		}
+		w.marks[name] = q
+	}
+	for name, m := range w.fileMarks {
+		if m.fileName != w.fileName {
+			continue
+		}
+		q, kept := adjust(m.position)
+		if !kept {
+			delete(w.fileMarks, name)
+			continue
+		}
+		w.fileMarks[name] = fileMark{fileName: m.fileName, position: q}
+	}
+	for j, p := range w.jumps {
+		w.jumps[j], _ = adjust(p)
+	}
+}
+
+// marksCommand is :marks, it lists the marks, or the marks in its argument, ex) :marks aB
+func (w *Window) marksCommand(c exArgs) error {
+	out := []string{"mark line  col file/text"}
+	for _, name := range []byte(markOrder) {
+		if c.args != "" && strings.IndexByte(c.args, name) < 0 {
+			continue
+		}
+		p, err := w.markPosition(name)
+		text := ""
+		if m, ok := w.fileMarks[name]; ok && isFileMark(name) && err != nil {
+			p, text, err = m.position, m.fileName, nil
+		}
+		if err != nil {
+			continue
+		}
+		if text == "" && p.Y <= len(w.FileContents) {
+			text = printable(bytes.TrimLeft(w.FileContents[p.Y-1], " \t"), false)
+		}
+		out = append(out, fmt.Sprintf(" %c %6d %4d %s", name, p.Y, p.X-1, text))
+	}
+	if len(out) == 1 && c.args != "" {
+		return fmt.Errorf("E283: No marks matching \"%s\"", c.args)
+	}
+	w.showMessage(strings.Join(out, "\n"), "")
+	return nil
+}
+
+// delmarksCommand is :delmarks, it deletes the marks in its argument, ex) :delmarks a-dX
+// :delmarks! deletes the marks a-z.
+func (w *Window) delmarksCommand(c exArgs) error {
+	if c.bang {
+		if c.args != "" {
+			return errors.New("E474: Invalid argument")
+		}
+		for name := range w.marks {
+			if isLocalMark(name) {
+				delete(w.marks, name)
+			}
+		}
+		return nil
+	}
+	if c.args == "" {
+		return errors.New("E471: Argument required")
+	}
+	var names []byte
+	args := strings.Replace(c.args, " ", "", -1)
+	for i := 0; i < len(args); i++ {
+		from, to := args[i], args[i]
+		if i+2 < len(args) && args[i+1] == '-' {
+			// a range of letters of the same case, ex) a-d
+			to = args[i+2]
+			if !(isLocalMark(from) && isLocalMark(to) || isFileMark(from) && isFileMark(to)) || to < from {
+				return fmt.Errorf("E475: Invalid argument: %s", args[i:])
+			}
+			i += 2
+		}
+		for name := from; ; name++ {
+			if strings.IndexByte(markOrder+"`", name) < 0 {
+				return fmt.Errorf("E475: Invalid argument: %s", args[i:])
+			}
+			names = append(names, name)
+			if name == to {
+				break
+			}
+		}
+	}
+	for _, name := range names {
+		if name == '`' {
+			name = '\''
+		}
+		delete(w.marks, name)
+		delete(w.fileMarks, name)
+	}
+	return nil
+}
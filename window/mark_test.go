@@ -0,0 +1,242 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWindow_Marks(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "line of a mark", lines: []string{"  ab", "c", "d"}, position: Position{X: 4, Y: 1}, input: "majj'a", wantLines: []string{"  ab", "c", "d"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "position of a mark", lines: []string{"  ab", "c", "d"}, position: Position{X: 4, Y: 1}, input: "majj`a", wantLines: []string{"  ab", "c", "d"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "file mark", lines: []string{"ab", "c"}, position: Position{X: 2, Y: 1}, input: "mBj`B", wantLines: []string{"ab", "c"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "delete to a mark", lines: []string{"1", "2", "3", "4"}, position: Position{X: 1, Y: 1}, input: "majjd'a", wantLines: []string{"4"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "delete to the position of a mark", lines: []string{"abcd"}, position: Position{X: 2, Y: 1}, input: "ma$d`a", wantLines: []string{"ad"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "change to the position of a mark", lines: []string{"abcd"}, position: Position{X: 2, Y: 1}, input: "ma$c`aX\033", wantLines: []string{"aXd"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "mark not set", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "'a", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E20: Mark not set"},
+		{name: "unknown mark", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "'!", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E78: Unknown mark"},
+		{name: "invalid mark", lines: []string{"a"}, position: Position{X: 1, Y: 1}, input: "m!", wantLines: []string{"a"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E191: Argument must be a letter or forward/backward quote"},
+		{name: "mark moves with inserted lines", lines: []string{"1", "2"}, position: Position{X: 1, Y: 2}, input: "maggOx\033'a", wantLines: []string{"x", "1", "2"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "mark moves with deleted lines", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 3}, input: "maggdd'a", wantLines: []string{"2", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "mark is deleted with its line", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 2}, input: "madd'a", wantLines: []string{"1", "3"}, wantPosition: Position{X: 1, Y: 2}, wantMessage: "E20: Mark not set"},
+		{name: "mark is back after undo", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "jmaddugg'a", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "mark moves back after undo", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "jjmakddugg'a", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "mark moves again after redo", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "jjmakddu\x12gg'a", wantLines: []string{"1", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "mark set after the change", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "ddjmaugg'a", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "position before the last jump", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "G''", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "jump back again", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "G``", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "jump back twice", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "G''''", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "set the position before a jump", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "jm'j''", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "last change", lines: []string{"abc", "def"}, position: Position{X: 1, Y: 1}, input: "jlxgg`.", wantLines: []string{"abc", "df"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "where insert mode was stopped", lines: []string{"abc", "def"}, position: Position{X: 1, Y: 1}, input: "Ax\033j`^", wantLines: []string{"abcx", "def"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "start of the text yanked", lines: []string{"abc def"}, position: Position{X: 6, Y: 1}, input: "yb$`[", wantLines: []string{"abc def"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "end of the text yanked", lines: []string{"abc def"}, position: Position{X: 1, Y: 1}, input: "yw`]", wantLines: []string{"abc def"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "end of the text put", lines: []string{"ab", "c"}, position: Position{X: 1, Y: 1}, input: "yyjp'[']", wantLines: []string{"ab", "c", "ab"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "end of the lines yanked", lines: []string{"ab", "cd", "e"}, position: Position{X: 1, Y: 1}, input: "yj`]", wantLines: []string{"ab", "cd", "e"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "end of the text inserted", lines: []string{"ab"}, position: Position{X: 1, Y: 1}, input: "ixyz\0330`]", wantLines: []string{"xyzab"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "selection", lines: []string{"ab", "cd"}, position: Position{X: 1, Y: 1}, input: "v\x1b[B\x1b[C\033gg`>", wantLines: []string{"ab", "cd"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "ex address", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "jmaG:'a\r", wantLines: []string{"1", "2", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "ex range of marks", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "majmb:'a,'bnorm Ax\r", wantLines: []string{"1x", "2x", "3"}, wantPosition: Position{X: 2, Y: 2}},
+		{name: "ex range of a selection", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 2}, input: "v\x1b[B\033:'<,'>norm x\r", wantLines: []string{"1", "", ""}, wantPosition: Position{X: 1, Y: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_JumpList(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		wantPosition Position
+	}{
+		{name: "older", input: "3G5Ggg\x0f", wantPosition: Position{X: 1, Y: 5}},
+		{name: "older with a count", input: "3G5Ggg2\x0f", wantPosition: Position{X: 1, Y: 3}},
+		{name: "no older", input: "3G5Ggg9\x0f", wantPosition: Position{X: 1, Y: 1}},
+		{name: "newer", input: "3G5Ggg2\x0f\t", wantPosition: Position{X: 1, Y: 5}},
+		{name: "back to where it started", input: "3G5Ggg2\x0f2\t", wantPosition: Position{X: 1, Y: 1}},
+		{name: "no newer", input: "3G\t", wantPosition: Position{X: 1, Y: 3}},
+		{name: "ex line number", input: ":4\r\x0f", wantPosition: Position{X: 1, Y: 1}},
+		{name: "mark", input: "4Gmagg'a\x0f", wantPosition: Position{X: 1, Y: 1}},
+		{name: "not a jump", input: "jjj\x0f", wantPosition: Position{X: 1, Y: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, []string{"1", "2", "3", "4", "5"}, Position{X: 1, Y: 1}, tt.input)
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestWindow_adjustMarks(t *testing.T) {
+	tests := []struct {
+		name          string
+		i, n          int
+		wantMarks     map[byte]Position
+		wantFileMarks map[byte]fileMark
+		wantJumps     []Position
+	}{
+		{
+			name:          "insert",
+			i:             1,
+			n:             2,
+			wantMarks:     map[byte]Position{'a': {X: 2, Y: 1}, 'b': {X: 1, Y: 5}, '.': {X: 3, Y: 4}},
+			wantFileMarks: map[byte]fileMark{'A': {fileName: "f", position: Position{X: 1, Y: 4}}, 'B': {fileName: "g", position: Position{X: 1, Y: 2}}},
+			wantJumps:     []Position{{X: 1, Y: 1}, {X: 1, Y: 5}},
+		},
+		{
+			name:          "delete",
+			i:             1,
+			n:             -1,
+			wantMarks:     map[byte]Position{'a': {X: 2, Y: 1}, 'b': {X: 1, Y: 2}, '.': {X: 1, Y: 2}},
+			wantFileMarks: map[byte]fileMark{'B': {fileName: "g", position: Position{X: 1, Y: 2}}},
+			wantJumps:     []Position{{X: 1, Y: 1}, {X: 1, Y: 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.fileName = "f"
+			w.marks = map[byte]Position{'a': {X: 2, Y: 1}, 'b': {X: 1, Y: 3}, '.': {X: 3, Y: 2}}
+			w.fileMarks = map[byte]fileMark{'A': {fileName: "f", position: Position{X: 1, Y: 2}}, 'B': {fileName: "g", position: Position{X: 1, Y: 2}}}
+			w.jumps = []Position{{X: 1, Y: 1}, {X: 1, Y: 3}}
+			w.adjustMarks(tt.i, tt.n)
+			if !reflect.DeepEqual(w.marks, tt.wantMarks) {
+				t.Errorf("got: %v, want: %v", w.marks, tt.wantMarks)
+			}
+			if !reflect.DeepEqual(w.fileMarks, tt.wantFileMarks) {
+				t.Errorf("got: %v, want: %v", w.fileMarks, tt.wantFileMarks)
+			}
+			if !reflect.DeepEqual(w.jumps, tt.wantJumps) {
+				t.Errorf("got: %v, want: %v", w.jumps, tt.wantJumps)
+			}
+		})
+	}
+}
+
+func TestWindow_marksCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "all marks",
+			lines: []string{"marks"},
+			want: "mark line  col file/text\n" +
+				" a      2    1 b c\n" +
+				" A      1    0 a\n" +
+				" B      4    0 other.go",
+		},
+		{name: "marks in the argument", lines: []string{"marks Ba"}, want: "mark line  col file/text\n a      2    1 b c\n B      4    0 other.go"},
+		{name: "no marks matching", lines: []string{"marks xy"}, wantErr: `E283: No marks matching "xy"`},
+		{name: "delete marks", lines: []string{"delmarks a B", "marks"}, want: "mark line  col file/text\n A      1    0 a"},
+		{name: "delete a range of marks", lines: []string{"delm A-B", "marks"}, want: "mark line  col file/text\n a      2    1 b c"},
+		{name: "delete the marks of letters", lines: []string{"delmarks!", "marks"}, want: "mark line  col file/text\n A      1    0 a\n B      4    0 other.go"},
+		{name: "no argument", lines: []string{"delmarks"}, wantErr: "E471: Argument required"},
+		{name: "invalid argument", lines: []string{"delmarks a-B"}, wantErr: "E475: Invalid argument: a-B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.FileContents = [][]byte{[]byte("a"), []byte("  b c")}
+			w.position = Position{X: 2, Y: 2}
+			w.setMark('a', w.position)
+			w.position = Position{X: 1, Y: 1}
+			w.setMark('A', w.position)
+			w.fileMarks['B'] = fileMark{fileName: "other.go", position: Position{X: 1, Y: 4}}
+			var err error
+			for _, l := range tt.lines {
+				if err = w.ExecuteLine(l); err != nil {
+					break
+				}
+			}
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.message != tt.want {
+				t.Errorf("got: %q, want: %q", w.message, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow_fileMarkOfOtherFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	first, other := filepath.Join(dir, "first.txt"), filepath.Join(dir, "other.txt")
+	if err := ioutil.WriteFile(first, []byte("a\n  b c\nd\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(other, []byte("x\ny\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		input        string
+		wantFile     string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "'", input: "jllmA:e " + other + "\r'A", wantFile: first, wantPosition: Position{X: 3, Y: 2}},
+		{name: "`", input: "jllmA:e " + other + "\r`A", wantFile: first, wantPosition: Position{X: 3, Y: 2}},
+		{name: "hidden buffer", input: "jllmA:e " + other + "\rj:e " + first + "\r`A", wantFile: first, wantPosition: Position{X: 3, Y: 2}},
+		{name: "modified", input: "jllmA:e " + other + "\rx`A", wantFile: other, wantPosition: Position{X: 1, Y: 1}, wantMessage: errModified.Error()},
+		{name: "operator", input: "jllmA:e " + other + "\rd`A", wantFile: other, wantPosition: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(80, 8)
+			w := NewWindow(term)
+			if err := w.SetFileContents(first); err != nil {
+				t.Fatal(err)
+			}
+			term.Type([]byte(tt.input))
+			runTyped(t, term, w)
+			if w.fileName != tt.wantFile {
+				t.Errorf("got: %q, want: %q", w.fileName, tt.wantFile)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if tt.wantMessage != "" && w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
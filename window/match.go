@@ -0,0 +1,241 @@
+package window
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// defaultMatchPairs is the default of the matchpairs option: the brackets % jumps between.
+// /*:*/ and #if:#endif may be added for C comments and preprocessor conditionals.
+const defaultMatchPairs = "(:),{:},[:]"
+
+// matchPair is an opening and a closing text % jumps between, ex) ( and )
+type matchPair struct {
+	open, close string
+}
+
+// nested reports whether the pair nests, ex) ( ( ) ); comments do not.
+func (p matchPair) nested() bool {
+	return len(p.open) == 1 && len(p.close) == 1
+}
+
+// directives reports whether the pair is #if:#endif, which matches preprocessor conditionals by line.
+func (p matchPair) directives() bool {
+	return p.open == "#if" && p.close == "#endif"
+}
+
+// parseMatchPairs parses the value of matchpairs, ex) (:),{:},[:]
+func parseMatchPairs(s string) ([]matchPair, error) {
+	var pairs []matchPair
+	if s == "" {
+		return pairs, nil
+	}
+	for _, item := range strings.Split(s, ",") {
+		i := strings.IndexByte(item, ':')
+		if i <= 0 || i == len(item)-1 || item[:i] == item[i+1:] {
+			return nil, fmt.Errorf("E474: Invalid argument: matchpairs=%s", s)
+		}
+		pairs = append(pairs, matchPair{open: item[:i], close: item[i+1:]})
+	}
+	return pairs, nil
+}
+
+// matchPairs returns the pairs of the matchpairs option.
+func (w *Window) matchPairs() []matchPair {
+	pairs, _ := parseMatchPairs(w.stringOption("matchpairs"))
+	return pairs
+}
+
+// moveToMatch is %, it goes from the bracket under the cursor, or the first one after it on the line,
+// to the one matching it, ex) from ( to ). On a preprocessor conditional it goes to the next #else or #endif.
+func (w *Window) moveToMatch(p Position, count int, op bool) (Position, bool) {
+	pairs := w.matchPairs()
+	line := w.FileContents[p.Y-1]
+	for _, pair := range pairs {
+		if pair.directives() && directive(line) != "" {
+			return w.matchDirective(p.Y)
+		}
+	}
+	pair, open, at, ok := w.pairAtOrAfter(p, pairs)
+	if !ok {
+		return p, false
+	}
+	return w.searchPair(pair, open, at, 1, len(w.FileContents))
+}
+
+// pairAtOrAfter returns the text of a pair under p, or the first one after it on the line.
+// open tells which text of the pair it is and at where it starts.
+func (w *Window) pairAtOrAfter(p Position, pairs []matchPair) (pair matchPair, open bool, at Position, ok bool) {
+	line := w.FileContents[p.Y-1]
+	// a text under the cursor may start before it, ex) the * of /*
+	for x := p.X - 1; x < len(line); x++ {
+		for _, pair := range pairs {
+			if pair.directives() {
+				continue
+			}
+			for _, s := range []string{pair.open, pair.close} {
+				start := x
+				if x == p.X-1 {
+					start = x - len(s) + 1
+					if start < 0 {
+						start = 0
+					}
+				}
+				for i := start; i <= x; i++ {
+					if bytes.HasPrefix(line[i:], []byte(s)) {
+						return pair, s == pair.open, Position{X: i + 1, Y: p.Y}, true
+					}
+				}
+			}
+		}
+	}
+	return pair, false, at, false
+}
+
+// searchPair returns where the text matching the one of pair at at is, searching the lines first to last.
+// It is the first character of an opening text and the last one of a closing text.
+func (w *Window) searchPair(pair matchPair, open bool, at Position, first, last int) (Position, bool) {
+	depth := 0
+	if open {
+		y, x := at.Y, at.X-1+len(pair.open)
+		for y <= last {
+			line := w.FileContents[y-1]
+			for ; x < len(line); x++ {
+				rest := line[x:]
+				switch {
+				case bytes.HasPrefix(rest, []byte(pair.close)):
+					if depth == 0 {
+						return Position{X: x + len(pair.close), Y: y}, true
+					}
+					depth--
+					x += len(pair.close) - 1
+				case pair.nested() && bytes.HasPrefix(rest, []byte(pair.open)):
+					depth++
+				}
+			}
+			y, x = y+1, 0
+		}
+		return at, false
+	}
+	y, x := at.Y, at.X-2
+	for y >= first {
+		line := w.FileContents[y-1]
+		for ; x >= 0; x-- {
+			rest := line[x:]
+			switch {
+			case bytes.HasPrefix(rest, []byte(pair.open)):
+				if depth == 0 {
+					return Position{X: x + 1, Y: y}, true
+				}
+				depth--
+			case pair.nested() && bytes.HasPrefix(rest, []byte(pair.close)):
+				depth++
+			}
+		}
+		y--
+		if y >= first {
+			x = len(w.FileContents[y-1]) - 1
+		}
+	}
+	return at, false
+}
+
+// directive returns the preprocessor conditional line is: if, else or endif, ex) #ifdef X is if
+func directive(line []byte) string {
+	line = bytes.TrimLeft(line, " \t")
+	if len(line) == 0 || line[0] != '#' {
+		return ""
+	}
+	line = bytes.TrimLeft(line[1:], " \t")
+	switch {
+	case bytes.HasPrefix(line, []byte("if")):
+		return "if"
+	case bytes.HasPrefix(line, []byte("el")):
+		return "else"
+	case bytes.HasPrefix(line, []byte("endif")):
+		return "endif"
+	}
+	return ""
+}
+
+// matchDirective goes from the conditional on the y-th line to the next #else or #endif of it,
+// or from #endif back to its #if.
+func (w *Window) matchDirective(y int) (Position, bool) {
+	depth := 0
+	if directive(w.FileContents[y-1]) == "endif" {
+		for i := y - 1; i >= 1; i-- {
+			switch directive(w.FileContents[i-1]) {
+			case "endif":
+				depth++
+			case "if":
+				if depth == 0 {
+					return Position{X: w.firstNonBlank(i), Y: i}, true
+				}
+				depth--
+			}
+		}
+		return Position{X: w.firstNonBlank(y), Y: y}, false
+	}
+	for i := y + 1; i <= len(w.FileContents); i++ {
+		switch directive(w.FileContents[i-1]) {
+		case "if":
+			depth++
+		case "else":
+			if depth == 0 {
+				return Position{X: w.firstNonBlank(i), Y: i}, true
+			}
+		case "endif":
+			if depth == 0 {
+				return Position{X: w.firstNonBlank(i), Y: i}, true
+			}
+			depth--
+		}
+	}
+	return Position{X: w.firstNonBlank(y), Y: y}, false
+}
+
+// matchParen returns the bracket under the cursor and the one matching it when both are on the screen,
+// so they are highlighted. In insert mode the bracket may be before the cursor, ex) just after ) is typed.
+// Only brackets of one character are highlighted.
+func (w *Window) matchParen() []Position {
+	if w.mode != normalMode && w.mode != insertMode || w.position.Y > len(w.FileContents) {
+		return nil
+	}
+	var pairs []matchPair
+	for _, pair := range w.matchPairs() {
+		if pair.nested() {
+			pairs = append(pairs, pair)
+		}
+	}
+	first, last := w.top+1, w.top+w.Row-1
+	if last > len(w.FileContents) {
+		last = len(w.FileContents)
+	}
+	line := w.FileContents[w.position.Y-1]
+	at := []int{w.position.X - 1}
+	if w.mode == insertMode && w.position.X > 1 {
+		at = append(at, runesBefore(line, w.position.X-1, 1))
+	}
+	for _, x := range at {
+		if x >= len(line) {
+			continue
+		}
+		for _, pair := range pairs {
+			var open bool
+			switch line[x] {
+			case pair.open[0]:
+				open = true
+			case pair.close[0]:
+			default:
+				continue
+			}
+			p := Position{X: x + 1, Y: w.position.Y}
+			if q, ok := w.searchPair(pair, open, p, first, last); ok {
+				return []Position{p, q}
+			}
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_moveToMatch(t *testing.T) {
+	lines := []string{
+		"func f() {",    // 1
+		"\tif (a[0]) {", // 2
+		"\t}",           // 3
+		"} /* a ( */ x", // 4
+		"#ifdef A",      // 5
+		"#  if B",       // 6
+		"#endif",        // 7
+		"  #else",       // 8
+		"/* comment",    // 9
+		"   end */ #if", // 10
+		"#endif",        // 11
+	}
+	withBlocks := defaultMatchPairs + ",/*:*/,#if:#endif"
+	tests := []struct {
+		name         string
+		matchpairs   string
+		position     Position
+		wantPosition Position
+		wantOK       bool
+	}{
+		{name: "to the closing bracket", matchpairs: defaultMatchPairs, position: Position{X: 10, Y: 1}, wantPosition: Position{X: 1, Y: 4}, wantOK: true},
+		{name: "to the opening bracket", matchpairs: defaultMatchPairs, position: Position{X: 1, Y: 4}, wantPosition: Position{X: 10, Y: 1}, wantOK: true},
+		{name: "nested brackets", matchpairs: defaultMatchPairs, position: Position{X: 5, Y: 2}, wantPosition: Position{X: 10, Y: 2}, wantOK: true},
+		{name: "other brackets are skipped", matchpairs: defaultMatchPairs, position: Position{X: 7, Y: 2}, wantPosition: Position{X: 9, Y: 2}, wantOK: true},
+		{name: "first bracket after the cursor", matchpairs: defaultMatchPairs, position: Position{X: 1, Y: 1}, wantPosition: Position{X: 8, Y: 1}, wantOK: true},
+		{name: "unmatched", matchpairs: defaultMatchPairs, position: Position{X: 7, Y: 4}, wantOK: false},
+		{name: "brackets not in matchpairs", matchpairs: "[:]", position: Position{X: 1, Y: 1}, wantOK: false},
+		{name: "comments are not matched by default", matchpairs: defaultMatchPairs, position: Position{X: 3, Y: 9}, wantOK: false},
+		{name: "start of a comment", matchpairs: withBlocks, position: Position{X: 1, Y: 9}, wantPosition: Position{X: 9, Y: 10}, wantOK: true},
+		{name: "inside the start of a comment", matchpairs: withBlocks, position: Position{X: 2, Y: 9}, wantPosition: Position{X: 9, Y: 10}, wantOK: true},
+		{name: "end of a comment", matchpairs: withBlocks, position: Position{X: 8, Y: 10}, wantPosition: Position{X: 1, Y: 9}, wantOK: true},
+		{name: "comment around brackets", matchpairs: withBlocks, position: Position{X: 3, Y: 4}, wantPosition: Position{X: 11, Y: 4}, wantOK: true},
+		{name: "#if to #else", matchpairs: withBlocks, position: Position{X: 3, Y: 5}, wantPosition: Position{X: 3, Y: 8}, wantOK: true},
+		{name: "nested #if", matchpairs: withBlocks, position: Position{X: 1, Y: 6}, wantPosition: Position{X: 1, Y: 7}, wantOK: true},
+		{name: "#else to #endif", matchpairs: withBlocks, position: Position{X: 3, Y: 8}, wantPosition: Position{X: 1, Y: 11}, wantOK: true},
+		{name: "#endif to #if", matchpairs: withBlocks, position: Position{X: 4, Y: 7}, wantPosition: Position{X: 1, Y: 6}, wantOK: true},
+		{name: "directives are not matched by default", matchpairs: defaultMatchPairs, position: Position{X: 1, Y: 5}, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			for _, l := range lines {
+				w.FileContents = append(w.FileContents, []byte(l))
+			}
+			w.options = optionValues{"matchpairs": {s: tt.matchpairs}}
+			got, ok := w.moveToMatch(tt.position, 0, false)
+			if ok != tt.wantOK {
+				t.Fatalf("got: %v, want: %v", ok, tt.wantOK)
+			}
+			if ok && got != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", got, tt.wantPosition)
+			}
+		})
+	}
+}
+
+func TestParseMatchPairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []matchPair
+		wantErr string
+	}{
+		{name: "default", value: defaultMatchPairs, want: []matchPair{{"(", ")"}, {"{", "}"}, {"[", "]"}}},
+		{name: "blocks", value: "<:>,/*:*/,#if:#endif", want: []matchPair{{"<", ">"}, {"/*", "*/"}, {"#if", "#endif"}}},
+		{name: "empty", value: ""},
+		{name: "no colon", value: "()", wantErr: "E474: Invalid argument: matchpairs=()"},
+		{name: "same text", value: "|:|", wantErr: "E474: Invalid argument: matchpairs=|:|"},
+		{name: "empty text", value: "(:", wantErr: "E474: Invalid argument: matchpairs=(:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMatchPairs(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow_SetMatchPairs(t *testing.T) {
+	w := typeKeys(t, []string{"<a>"}, Position{X: 1, Y: 1}, ":set mps=<:>\r%")
+	if want := (Position{X: 3, Y: 1}); w.position != want {
+		t.Errorf("got: %+v, want: %+v", w.position, want)
+	}
+	if err := w.ExecuteLine("set matchpairs=<>"); err == nil || err.Error() != "E474: Invalid argument: matchpairs=<>" {
+		t.Errorf("got: %v, want: E474", err)
+	}
+	if err := w.ExecuteLine("set matchpairs"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "  matchpairs=<:>"; w.message != want {
+		t.Errorf("got: %q, want: %q", w.message, want)
+	}
+}
+
+func TestWindow_PrintFileContentsMatchParen(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     int
+		position Position
+		want     []Position
+	}{
+		{name: "on a bracket", mode: normalMode, position: Position{X: 2, Y: 1}, want: []Position{{X: 2, Y: 1}, {X: 2, Y: 2}}},
+		{name: "on the closing bracket", mode: normalMode, position: Position{X: 2, Y: 2}, want: []Position{{X: 2, Y: 2}, {X: 2, Y: 1}}},
+		{name: "not on a bracket", mode: normalMode, position: Position{X: 1, Y: 1}},
+		{name: "after a bracket in insert mode", mode: insertMode, position: Position{X: 3, Y: 2}, want: []Position{{X: 2, Y: 2}, {X: 2, Y: 1}}},
+		{name: "after a bracket in normal mode", mode: normalMode, position: Position{X: 3, Y: 2}},
+		{name: "match off the screen", mode: normalMode, position: Position{X: 4, Y: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(20, 4)
+			w := NewWindow(term)
+			w.SetSize()
+			w.FileContents = [][]byte{[]byte("a(b"), []byte("c)d["), []byte(""), []byte("]")}
+			w.mode = tt.mode
+			w.position = tt.position
+			w.PrintFileContents()
+			var got []Position
+			paren := w.style("MatchParen").Over(w.style("Normal"))
+			for row := 0; row < 2; row++ {
+				for col := 0; col < 4; col++ {
+					if term.Cell(row, col).Style == paren {
+						got = append(got, Position{X: col + 1, Y: row + 1})
+					}
+				}
+			}
+			want := append([]Position(nil), tt.want...)
+			if len(want) == 2 && want[1].Y < want[0].Y {
+				want[0], want[1] = want[1], want[0]
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got: %v, want: %v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package window
+
+import (
+	"strings"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+const hitEnterPrompt = "Press ENTER or type command to continue"
+
+// showMessage shows msg on the last line in the highlight group, ex) ErrorMsg.
+// A message of more than one line scrolls up from the last line and waits for a key.
+func (w *Window) showMessage(msg, group string) {
+	w.message = msg
+	w.messageGroup = group
+	w.hitEnter = strings.Contains(msg, "\n")
+}
+
+// showError shows err, it stops the macro being executed too.
+func (w *Window) showError(err error) {
+	w.showMessage(err.Error(), "ErrorMsg")
+	w.fail()
+}
+
+// IsWaitingForKey reports whether a long message waits for a key before the screen is redrawn.
+func (w *Window) IsWaitingForKey() bool {
+	return w.hitEnter
+}
+
+// DismissMessage removes a long message from the screen.
+// It reports whether the key b was used up: Enter, space and Escape only dismiss the message,
+// other keys are handled as usual.
+func (w *Window) DismissMessage(b []byte) bool {
+	w.hitEnter = false
+	w.message = ""
+	k := w.GetKey(b)
+	return k == prompt.Enter || k == prompt.Escape || string(b) == " "
+}
@@ -0,0 +1,445 @@
+package window
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// motion moves the cursor, ex) w. After an operator the text it moves over is what the operator changes.
+type motion struct {
+	// move returns where the motion goes from p. count is 0 when none was typed.
+	// op is true after an operator, some motions stop earlier then, ex) w at the end of a line.
+	move func(w *Window, p Position, count int, op bool) (Position, bool)
+	// linewise motions make operators work on whole lines, ex) j
+	linewise bool
+	// inclusive motions make operators work on the character they stop on too, ex) e
+	inclusive bool
+	// jump motions add the position they leave to the jump list, ex) G
+	jump bool
+}
+
+var motions map[string]motion
+
+// argMotions are the motions followed by a character, ex) 'a goes to the mark a.
+var argMotions map[string]func(arg []byte) motion
+
+func init() {
+	motions = map[string]motion{
+		"h": {move: (*Window).moveLeft},
+		"l": {move: (*Window).moveRight},
+		"j": {move: (*Window).moveDown, linewise: true},
+		"k": {move: (*Window).moveUp, linewise: true},
+		"0": {move: (*Window).moveToStart},
+		"^": {move: (*Window).moveToFirstNonBlank},
+		"$": {move: (*Window).moveToEnd, inclusive: true},
+		"w": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.forwardWord(p, count, op, false)
+		}},
+		"W": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.forwardWord(p, count, op, true)
+		}},
+		"b": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.backwardWord(p, count, false)
+		}},
+		"B": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.backwardWord(p, count, true)
+		}},
+		"e": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.endOfWord(p, count, false, false)
+		}, inclusive: true},
+		"E": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.endOfWord(p, count, true, false)
+		}, inclusive: true},
+		"gg": {move: (*Window).moveToLineOrFirst, linewise: true, jump: true},
+		"G":  {move: (*Window).moveToLineOrLast, linewise: true, jump: true},
+		";": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.repeatFind(p, count, false)
+		}},
+		",": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.repeatFind(p, count, true)
+		}},
+		"%": {move: (*Window).moveToMatch, inclusive: true, jump: true},
+		"n": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.searchNext(p, count, false)
+		}, jump: true},
+		"N": {move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+			return w.searchNext(p, count, true)
+		}, jump: true},
+	}
+	argMotions = map[string]func(arg []byte) motion{
+		"'": func(arg []byte) motion {
+			return motion{move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+				return w.moveToMark(arg, true, op)
+			}, linewise: true, jump: true}
+		},
+		"`": func(arg []byte) motion {
+			return motion{move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+				return w.moveToMark(arg, false, op)
+			}, jump: true}
+		},
+	}
+	for _, name := range []string{"f", "F", "t", "T"} {
+		name := name
+		argMotions[name] = func(arg []byte) motion {
+			return motion{move: func(w *Window, p Position, count int, op bool) (Position, bool) {
+				w.lastFind = findKeys{name: name, arg: arg}
+				return w.findChar(p, count, name, arg, false)
+			}, inclusive: name == "f" || name == "t"}
+		}
+	}
+}
+
+// motionOf returns the motion name, arg is the character typed after it for argMotions.
+func (w *Window) motionOf(name string, arg []byte) (motion, bool) {
+	if m, ok := motions[name]; ok {
+		if name == ";" || name == "," {
+			// as inclusive as the search repeated
+			find := w.lastFind.name
+			if name == "," {
+				find = reverseFind[find]
+			}
+			m.inclusive = find == "f" || find == "t"
+		}
+		return m, true
+	}
+	if f, ok := argMotions[name]; ok {
+		return f(arg), true
+	}
+	return motion{}, false
+}
+
+func orOne(count int) int {
+	if count == 0 {
+		return 1
+	}
+	return count
+}
+
+// moveCursor moves the cursor with the motion typed in normal mode.
+func (w *Window) moveCursor(k normalKeys) {
+	m, _ := w.motionOf(k.name, k.arg)
+	p, ok := m.move(w, w.position, k.count, false)
+	if !ok {
+		w.fail()
+		return
+	}
+	if m.jump {
+		w.pushJump(w.position)
+	}
+	w.position = p
+	w.clampCursor()
+}
+
+func (w *Window) moveLeft(p Position, count int, op bool) (Position, bool) {
+	if p.X == 1 {
+		return p, false
+	}
+	p.X = runesBefore(w.FileContents[p.Y-1], p.X-1, orOne(count)) + 1
+	return p, true
+}
+
+// moveRight moves to after the last character of the line at most, which is the end of the text an operator changes.
+func (w *Window) moveRight(p Position, count int, op bool) (Position, bool) {
+	line := w.FileContents[p.Y-1]
+	if p.X > len(line) {
+		return p, false
+	}
+	p.X = runesAfter(line, p.X-1, orOne(count)) + 1
+	return p, true
+}
+
+func (w *Window) moveDown(p Position, count int, op bool) (Position, bool) {
+	if p.Y+orOne(count) > len(w.FileContents) {
+		return p, false
+	}
+	p.Y += orOne(count)
+	return p, true
+}
+
+func (w *Window) moveUp(p Position, count int, op bool) (Position, bool) {
+	if p.Y-orOne(count) < 1 {
+		return p, false
+	}
+	p.Y -= orOne(count)
+	return p, true
+}
+
+func (w *Window) moveToStart(p Position, count int, op bool) (Position, bool) {
+	return Position{X: 1, Y: p.Y}, true
+}
+
+func (w *Window) moveToFirstNonBlank(p Position, count int, op bool) (Position, bool) {
+	return Position{X: w.firstNonBlank(p.Y), Y: p.Y}, true
+}
+
+// firstNonBlank returns the column of the first character of the y-th line that is not a space or a tab.
+func (w *Window) firstNonBlank(y int) int {
+	line := w.FileContents[y-1]
+	return len(line) - len(bytes.TrimLeft(line, " \t")) + 1
+}
+
+// moveToEnd moves to the last character of the line, count-1 lines below.
+func (w *Window) moveToEnd(p Position, count int, op bool) (Position, bool) {
+	y := p.Y + orOne(count) - 1
+	if y > len(w.FileContents) {
+		return p, false
+	}
+	line := w.FileContents[y-1]
+	return Position{X: runesBefore(line, len(line), 1) + 1, Y: y}, true
+}
+
+// moveToLineOrFirst moves to the count-th line, or the first one.
+func (w *Window) moveToLineOrFirst(p Position, count int, op bool) (Position, bool) {
+	return w.moveToLineNumber(orOne(count)), true
+}
+
+// moveToLineOrLast moves to the count-th line, or the last one.
+func (w *Window) moveToLineOrLast(p Position, count int, op bool) (Position, bool) {
+	if count == 0 {
+		count = len(w.FileContents)
+	}
+	return w.moveToLineNumber(count), true
+}
+
+func (w *Window) moveToLineNumber(y int) Position {
+	if y > len(w.FileContents) {
+		y = len(w.FileContents)
+	}
+	return Position{X: w.firstNonBlank(y), Y: y}
+}
+
+// charClass returns the class of characters a word is made of: 0 for blanks,
+// 2 for letters, digits and _ and 1 for the others. A WORD is made of any characters but blanks.
+func charClass(r rune, bigWord bool) int {
+	switch {
+	case r == ' ' || r == '\t':
+		return 0
+	case bigWord:
+		return 1
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 2
+	}
+	return 1
+}
+
+// classAt returns the class of the character at p, the end of a line is a blank.
+func (w *Window) classAt(p Position, bigWord bool) int {
+	line := w.FileContents[p.Y-1]
+	if p.X > len(line) {
+		return 0
+	}
+	r, _ := utf8.DecodeRune(line[p.X-1:])
+	return charClass(r, bigWord)
+}
+
+func (w *Window) isEmptyLine(p Position) bool {
+	return len(w.FileContents[p.Y-1]) == 0
+}
+
+// next returns the position of the character after p, it is on the next line after the last one.
+func (w *Window) next(p Position) (Position, bool) {
+	line := w.FileContents[p.Y-1]
+	if x := runesAfter(line, p.X-1, 1) + 1; x <= len(line) {
+		return Position{X: x, Y: p.Y}, true
+	}
+	if p.Y < len(w.FileContents) {
+		return Position{X: 1, Y: p.Y + 1}, true
+	}
+	return p, false
+}
+
+// prev returns the position of the character before p, it is on the line above before the first one.
+func (w *Window) prev(p Position) (Position, bool) {
+	if p.X > 1 {
+		return Position{X: runesBefore(w.FileContents[p.Y-1], p.X-1, 1) + 1, Y: p.Y}, true
+	}
+	if p.Y > 1 {
+		line := w.FileContents[p.Y-2]
+		return Position{X: runesBefore(line, len(line), 1) + 1, Y: p.Y - 1}, true
+	}
+	return p, false
+}
+
+// forwardWord moves to the start of the count-th next word, ex) w
+// An empty line is a word. After an operator the last word does not go on to the next line.
+func (w *Window) forwardWord(p Position, count int, op, bigWord bool) (Position, bool) {
+	last := len(w.FileContents)
+	endOfText := Position{X: len(w.FileContents[last-1]) + 1, Y: last}
+	endOfLine := func(p Position) Position {
+		return Position{X: len(w.FileContents[p.Y-1]) + 1, Y: p.Y}
+	}
+	for i := 0; i < orOne(count); i++ {
+		stopAtEnd := op && i == orOne(count)-1
+		if _, ok := w.next(p); !ok {
+			if i == 0 && !op {
+				return p, false
+			}
+			return endOfText, true
+		}
+		// the rest of the word
+		class := w.classAt(p, bigWord)
+		for class != 0 || w.isEmptyLine(p) {
+			q, ok := w.next(p)
+			if !ok {
+				return endOfText, true
+			}
+			if q.Y != p.Y {
+				if stopAtEnd {
+					return endOfLine(p), true
+				}
+				p = q
+				break
+			}
+			p = q
+			if w.classAt(p, bigWord) != class {
+				break
+			}
+		}
+		// the blanks after it, an empty line is a word
+		for w.classAt(p, bigWord) == 0 && !w.isEmptyLine(p) {
+			q, ok := w.next(p)
+			if !ok {
+				return endOfText, true
+			}
+			if q.Y != p.Y && stopAtEnd {
+				return endOfLine(p), true
+			}
+			p = q
+		}
+	}
+	return p, true
+}
+
+// backwardWord moves to the start of the count-th word before p, ex) b
+func (w *Window) backwardWord(p Position, count int, bigWord bool) (Position, bool) {
+	for i := 0; i < orOne(count); i++ {
+		q, ok := w.prev(p)
+		if !ok {
+			return p, i > 0
+		}
+		p = q
+		for w.classAt(p, bigWord) == 0 && !w.isEmptyLine(p) {
+			q, ok := w.prev(p)
+			if !ok {
+				return p, true
+			}
+			p = q
+		}
+		if w.isEmptyLine(p) {
+			continue
+		}
+		class := w.classAt(p, bigWord)
+		for {
+			q, ok := w.prev(p)
+			if !ok || q.Y != p.Y || w.classAt(q, bigWord) != class {
+				break
+			}
+			p = q
+		}
+	}
+	return p, true
+}
+
+// endOfWord moves to the end of the count-th word, ex) e
+// With stop the word under the cursor counts when the cursor is on its end, ex) cw
+func (w *Window) endOfWord(p Position, count int, bigWord, stop bool) (Position, bool) {
+	for i := 0; i < orOne(count); i++ {
+		class := w.classAt(p, bigWord)
+		if i == 0 && stop && class != 0 {
+			if q, ok := w.next(p); !ok || q.Y != p.Y || w.classAt(q, bigWord) != class {
+				continue
+			}
+		}
+		q, ok := w.next(p)
+		if !ok {
+			return p, i > 0
+		}
+		p = q
+		for w.classAt(p, bigWord) == 0 {
+			q, ok := w.next(p)
+			if !ok {
+				return p, true
+			}
+			p = q
+		}
+		class = w.classAt(p, bigWord)
+		for {
+			q, ok := w.next(p)
+			if !ok || q.Y != p.Y || w.classAt(q, bigWord) != class {
+				break
+			}
+			p = q
+		}
+	}
+	return p, true
+}
+
+// findKeys is a search for a character in the line, ex) fx
+type findKeys struct {
+	name string // f, F, t or T
+	arg  []byte // the character searched for
+}
+
+// reverseFind is the search , makes of the last one.
+var reverseFind = map[string]string{"f": "F", "F": "f", "t": "T", "T": "t"}
+
+// findChar moves to the count-th c in the line of p: f and t search after p, F and T before it.
+// t and T stop next to c. With again they do not stop next to the c they stopped next to, ex) ;
+func (w *Window) findChar(p Position, count int, name string, c []byte, again bool) (Position, bool) {
+	line := w.FileContents[p.Y-1]
+	forward := name == "f" || name == "t"
+	till := name == "t" || name == "T"
+	step := func(x int) (int, bool) {
+		if forward {
+			x = runesAfter(line, x, 1)
+			return x, x < len(line)
+		}
+		if x == 0 {
+			return x, false
+		}
+		return runesBefore(line, x, 1), true
+	}
+	x := p.X - 1
+	if len(c) == 0 || x >= len(line) {
+		return p, false
+	}
+	if till && again {
+		var ok bool
+		if x, ok = step(x); !ok {
+			return p, false
+		}
+	}
+	for n := orOne(count); n > 0; n-- {
+		for {
+			var ok bool
+			if x, ok = step(x); !ok {
+				return p, false
+			}
+			if bytes.HasPrefix(line[x:], c) {
+				break
+			}
+		}
+	}
+	if till {
+		if forward {
+			x = runesBefore(line, x, 1)
+		} else {
+			x = runesAfter(line, x, 1)
+		}
+	}
+	return Position{X: x + 1, Y: p.Y}, true
+}
+
+// repeatFind is ; and , which repeat the last search in the line, , in the other direction.
+func (w *Window) repeatFind(p Position, count int, reverse bool) (Position, bool) {
+	f := w.lastFind
+	if f.name == "" {
+		return p, false
+	}
+	name := f.name
+	if reverse {
+		name = reverseFind[name]
+	}
+	return w.findChar(p, count, name, f.arg, true)
+}
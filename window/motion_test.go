@@ -0,0 +1,73 @@
+package window
+
+import "testing"
+
+func TestWindow_Motions(t *testing.T) {
+	lines := []string{"foo.bar(baz)  qux", "", "  indented line", "last"}
+	tests := []struct {
+		name         string
+		position     Position
+		input        string
+		wantPosition Position
+	}{
+		{name: "h", position: Position{X: 3, Y: 1}, input: "h", wantPosition: Position{X: 2, Y: 1}},
+		{name: "h with a count stops at the start", position: Position{X: 3, Y: 1}, input: "5h", wantPosition: Position{X: 1, Y: 1}},
+		{name: "l with a count", position: Position{X: 1, Y: 1}, input: "3l", wantPosition: Position{X: 4, Y: 1}},
+		{name: "l stops on the last character", position: Position{X: 16, Y: 1}, input: "5l", wantPosition: Position{X: 17, Y: 1}},
+		{name: "j", position: Position{X: 5, Y: 1}, input: "2j", wantPosition: Position{X: 5, Y: 3}},
+		{name: "j onto a short line", position: Position{X: 5, Y: 3}, input: "j", wantPosition: Position{X: 4, Y: 4}},
+		{name: "j past the last line", position: Position{X: 1, Y: 3}, input: "5j", wantPosition: Position{X: 1, Y: 3}},
+		{name: "k", position: Position{X: 1, Y: 4}, input: "3k", wantPosition: Position{X: 1, Y: 1}},
+		{name: "0", position: Position{X: 8, Y: 3}, input: "0", wantPosition: Position{X: 1, Y: 3}},
+		{name: "^", position: Position{X: 8, Y: 3}, input: "^", wantPosition: Position{X: 3, Y: 3}},
+		{name: "$", position: Position{X: 1, Y: 1}, input: "$", wantPosition: Position{X: 17, Y: 1}},
+		{name: "$ with a count", position: Position{X: 1, Y: 1}, input: "3$", wantPosition: Position{X: 15, Y: 3}},
+		{name: "w stops at punctuation", position: Position{X: 1, Y: 1}, input: "w", wantPosition: Position{X: 4, Y: 1}},
+		{name: "w with a count", position: Position{X: 1, Y: 1}, input: "3w", wantPosition: Position{X: 8, Y: 1}},
+		{name: "w over blanks", position: Position{X: 9, Y: 1}, input: "2w", wantPosition: Position{X: 15, Y: 1}},
+		{name: "w stops on an empty line", position: Position{X: 15, Y: 1}, input: "w", wantPosition: Position{X: 1, Y: 2}},
+		{name: "w skips the indent", position: Position{X: 1, Y: 2}, input: "w", wantPosition: Position{X: 3, Y: 3}},
+		{name: "w on the last word", position: Position{X: 1, Y: 4}, input: "w", wantPosition: Position{X: 4, Y: 4}},
+		{name: "W", position: Position{X: 1, Y: 1}, input: "W", wantPosition: Position{X: 15, Y: 1}},
+		{name: "b", position: Position{X: 8, Y: 1}, input: "b", wantPosition: Position{X: 5, Y: 1}},
+		{name: "b to the previous line", position: Position{X: 3, Y: 3}, input: "2b", wantPosition: Position{X: 15, Y: 1}},
+		{name: "b at the start", position: Position{X: 1, Y: 1}, input: "b", wantPosition: Position{X: 1, Y: 1}},
+		{name: "B", position: Position{X: 15, Y: 1}, input: "B", wantPosition: Position{X: 1, Y: 1}},
+		{name: "e", position: Position{X: 1, Y: 1}, input: "e", wantPosition: Position{X: 3, Y: 1}},
+		{name: "e from the end of a word", position: Position{X: 3, Y: 1}, input: "e", wantPosition: Position{X: 4, Y: 1}},
+		{name: "e skips empty lines", position: Position{X: 15, Y: 1}, input: "2e", wantPosition: Position{X: 10, Y: 3}},
+		{name: "E", position: Position{X: 1, Y: 1}, input: "E", wantPosition: Position{X: 12, Y: 1}},
+		{name: "gg", position: Position{X: 5, Y: 4}, input: "gg", wantPosition: Position{X: 1, Y: 1}},
+		{name: "gg with a count", position: Position{X: 1, Y: 1}, input: "3gg", wantPosition: Position{X: 3, Y: 3}},
+		{name: "G", position: Position{X: 5, Y: 1}, input: "G", wantPosition: Position{X: 1, Y: 4}},
+		{name: "G with a count", position: Position{X: 1, Y: 1}, input: "3G", wantPosition: Position{X: 3, Y: 3}},
+		{name: "G with a count too large", position: Position{X: 1, Y: 1}, input: "9G", wantPosition: Position{X: 1, Y: 4}},
+		{name: "f", position: Position{X: 1, Y: 1}, input: "fa", wantPosition: Position{X: 6, Y: 1}},
+		{name: "f with a count", position: Position{X: 1, Y: 1}, input: "2fa", wantPosition: Position{X: 10, Y: 1}},
+		{name: "f not found", position: Position{X: 1, Y: 1}, input: "fy", wantPosition: Position{X: 1, Y: 1}},
+		{name: "f with a count too large", position: Position{X: 1, Y: 1}, input: "3fa", wantPosition: Position{X: 1, Y: 1}},
+		{name: "f stays in the line", position: Position{X: 15, Y: 1}, input: "fl", wantPosition: Position{X: 15, Y: 1}},
+		{name: "t", position: Position{X: 1, Y: 1}, input: "ta", wantPosition: Position{X: 5, Y: 1}},
+		{name: "F", position: Position{X: 8, Y: 1}, input: "Fo", wantPosition: Position{X: 3, Y: 1}},
+		{name: "T", position: Position{X: 8, Y: 1}, input: "To", wantPosition: Position{X: 4, Y: 1}},
+		{name: ";", position: Position{X: 1, Y: 1}, input: "fa;", wantPosition: Position{X: 10, Y: 1}},
+		{name: ",", position: Position{X: 1, Y: 1}, input: "2fa,", wantPosition: Position{X: 6, Y: 1}},
+		{name: "; after t does not stop next to the character", position: Position{X: 1, Y: 1}, input: "ta;", wantPosition: Position{X: 9, Y: 1}},
+		{name: "; after T", position: Position{X: 17, Y: 1}, input: "Ta;", wantPosition: Position{X: 7, Y: 1}},
+		{name: ", after T", position: Position{X: 8, Y: 1}, input: "Ta,", wantPosition: Position{X: 9, Y: 1}},
+		{name: "; with a count", position: Position{X: 1, Y: 1}, input: "fo2;", wantPosition: Position{X: 2, Y: 1}},
+		{name: "; without a search", position: Position{X: 1, Y: 1}, input: ";", wantPosition: Position{X: 1, Y: 1}},
+		{name: "%", position: Position{X: 8, Y: 1}, input: "%", wantPosition: Position{X: 12, Y: 1}},
+		{name: "% back", position: Position{X: 12, Y: 1}, input: "%", wantPosition: Position{X: 8, Y: 1}},
+		{name: "% on the first bracket after the cursor", position: Position{X: 1, Y: 1}, input: "%", wantPosition: Position{X: 12, Y: 1}},
+		{name: "% without a bracket", position: Position{X: 13, Y: 1}, input: "%", wantPosition: Position{X: 13, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, lines, tt.position, tt.input)
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+		})
+	}
+}
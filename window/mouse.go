@@ -0,0 +1,148 @@
+package window
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type MouseButton int
+
+const (
+	MouseLeft MouseButton = iota
+	MouseMiddle
+	MouseRight
+	MouseNone // motion or a release without a button
+	MouseWheelUp
+	MouseWheelDown
+)
+
+type MouseAction int
+
+const (
+	MousePress MouseAction = iota
+	MouseRelease
+	MouseDrag
+)
+
+// MouseEvent is a mouse click, drag or wheel turn at a cell (0-indexed) of the terminal.
+type MouseEvent struct {
+	Button MouseButton
+	Action MouseAction
+	Row    int
+	Col    int
+}
+
+const (
+	// mouseOn makes the terminal report presses, drags and releases in the SGR format.
+	mouseOn  = "\033[?1000h\033[?1002h\033[?1006h"
+	mouseOff = "\033[?1006l\033[?1002l\033[?1000l"
+)
+
+// decodeMouse decodes the button byte and the 1-indexed position the terminal reports.
+func decodeMouse(cb, x, y int, release bool) (MouseEvent, Mod) {
+	ev := MouseEvent{Button: MouseButton(cb & 3), Row: y - 1, Col: x - 1}
+	mod := Mod(0)
+	if cb&4 != 0 {
+		mod |= ModShift
+	}
+	if cb&8 != 0 {
+		mod |= ModAlt
+	}
+	if cb&16 != 0 {
+		mod |= ModCtrl
+	}
+	switch {
+	case cb&64 != 0:
+		ev.Button = MouseWheelUp + MouseButton(cb&1)
+	case release || ev.Button == MouseNone:
+		ev.Action = MouseRelease
+	case cb&32 != 0:
+		ev.Action = MouseDrag
+	}
+	return ev, mod
+}
+
+// mouseEnabled reports whether the mouse option enables the mouse in the current mode.
+func (w *Window) mouseEnabled() bool {
+	modes := map[int]string{normalMode: "n", visualMode: "v", insertMode: "i", commandMode: "c"}
+	mouse := w.stringOption("mouse")
+	return strings.Contains(mouse, "a") || strings.Contains(mouse, modes[w.mode])
+}
+
+const scrollLines = 3
+
+// handleMouse places the cursor on a click, selects by dragging and scrolls with the wheel.
+func (w *Window) handleMouse(ev MouseEvent) {
+	if !w.mouseEnabled() || w.IsCommandMode() {
+		return
+	}
+	switch ev.Button {
+	case MouseWheelUp:
+		w.scroll(-scrollLines)
+		return
+	case MouseWheelDown:
+		w.scroll(scrollLines)
+		return
+	case MouseLeft:
+	default:
+		return
+	}
+	pos, ok := w.positionAt(ev.Row, ev.Col)
+	if !ok {
+		return
+	}
+	switch ev.Action {
+	case MousePress:
+		if w.mode == visualMode {
+			w.SetNormalMode()
+		}
+		w.position = pos
+	case MouseDrag:
+		if w.mode == normalMode {
+			w.setVisualMode()
+		}
+		if w.mode == visualMode {
+			w.position = pos
+		}
+	}
+}
+
+// positionAt returns the position of the text shown at a cell of the terminal.
+func (w *Window) positionAt(row, col int) (Position, bool) {
+	if row < 0 || row >= w.textRows() || len(w.FileContents) == 0 {
+		return Position{}, false
+	}
+	// the line shown on the row, and the rows of it above
+	y, above := w.top, 0
+	for y < len(w.FileContents)-1 && above+w.lineRows(y) <= row {
+		above += w.lineRows(y)
+		y++
+	}
+	if row-above >= w.lineRows(y) {
+		// below the last line
+		row = above + w.lineRows(y) - 1
+	}
+	col -= w.gutterWidth()
+	if w.boolOption("wrap") {
+		col += (row - above) * w.textColumns()
+	} else {
+		col += w.leftCol
+	}
+	line := w.FileContents[y]
+	x, width := 1, 0
+	for b := 0; b < len(line); {
+		r, size := utf8.DecodeRune(line[b:])
+		width += w.charWidth(r, size, line[b], width)
+		if width > col {
+			return Position{X: x, Y: y + 1}, true
+		}
+		b += size
+		x += size
+	}
+	// past the end of the line
+	if !w.IsInsertMode() && x > 1 {
+		_, size := utf8.DecodeLastRune(line)
+		x -= size
+	}
+	return Position{X: x, Y: y + 1}, true
+}
@@ -0,0 +1,211 @@
+package window
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func click(row, col int) string {
+	return fmt.Sprintf("\033[<0;%d;%dM\033[<0;%d;%dm", col+1, row+1, col+1, row+1)
+}
+
+func TestWindow_handleMouse(t *testing.T) {
+	lines := [][]byte{[]byte("hello"), []byte("\tあい"), []byte("x"), []byte("4"), []byte("5"), []byte("6")}
+	tests := []struct {
+		name         string
+		mouse        string
+		mode         int
+		events       []MouseEvent
+		wantPosition Position
+		wantMode     int
+		wantTop      int
+		wantVisual   Position
+	}{
+		{
+			name:         "click places the cursor",
+			mouse:        "a",
+			events:       []MouseEvent{{Button: MouseLeft, Row: 0, Col: 3}},
+			wantPosition: Position{X: 4, Y: 1},
+		},
+		{
+			name:         "click on a tab and a wide character",
+			mouse:        "a",
+			events:       []MouseEvent{{Button: MouseLeft, Row: 1, Col: 11}},
+			wantPosition: Position{X: 5, Y: 2},
+		},
+		{
+			name:         "click past the end of the line",
+			mouse:        "a",
+			events:       []MouseEvent{{Button: MouseLeft, Row: 0, Col: 20}},
+			wantPosition: Position{X: 5, Y: 1},
+		},
+		{
+			name:         "click past the end of the line in insert mode",
+			mouse:        "a",
+			mode:         insertMode,
+			events:       []MouseEvent{{Button: MouseLeft, Row: 0, Col: 20}},
+			wantPosition: Position{X: 6, Y: 1},
+			wantMode:     insertMode,
+		},
+		{
+			name:         "click on the command line",
+			mouse:        "a",
+			events:       []MouseEvent{{Button: MouseLeft, Row: 3, Col: 0}},
+			wantPosition: Position{X: 1, Y: 1},
+		},
+		{
+			name:         "mouse not enabled in insert mode",
+			mouse:        "n",
+			mode:         insertMode,
+			events:       []MouseEvent{{Button: MouseLeft, Row: 0, Col: 3}},
+			wantPosition: Position{X: 1, Y: 1},
+			wantMode:     insertMode,
+		},
+		{
+			name:  "drag selects",
+			mouse: "a",
+			events: []MouseEvent{
+				{Button: MouseLeft, Row: 0, Col: 1},
+				{Button: MouseLeft, Action: MouseDrag, Row: 2, Col: 0},
+				{Button: MouseLeft, Action: MouseRelease, Row: 2, Col: 0},
+			},
+			wantPosition: Position{X: 1, Y: 3},
+			wantMode:     visualMode,
+			wantVisual:   Position{X: 2, Y: 1},
+		},
+		{
+			name:         "wheel scrolls and keeps the cursor on the screen",
+			mouse:        "a",
+			events:       []MouseEvent{{Button: MouseWheelDown}},
+			wantPosition: Position{X: 1, Y: 4},
+			wantTop:      3,
+		},
+		{
+			name:         "wheel stops at the top",
+			mouse:        "a",
+			events:       []MouseEvent{{Button: MouseWheelDown}, {Button: MouseWheelUp}, {Button: MouseWheelUp}},
+			wantPosition: Position{X: 1, Y: 3},
+			wantTop:      0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.SetSize()
+			w.FileContents = lines
+			w.position = Position{X: 1, Y: 1}
+			w.options = optionValues{"mouse": {s: tt.mouse}}
+			w.mode = tt.mode
+			for _, ev := range tt.events {
+				w.handleMouse(ev)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.mode != tt.wantMode {
+				t.Errorf("got: mode %d, want: %d", w.mode, tt.wantMode)
+			}
+			if w.top != tt.wantTop {
+				t.Errorf("got: top %d, want: %d", w.top, tt.wantTop)
+			}
+			if tt.wantMode == visualMode && w.visualStart != tt.wantVisual {
+				t.Errorf("got: selection from %+v, want: %+v", w.visualStart, tt.wantVisual)
+			}
+		})
+	}
+}
+
+func TestWindow_operatorAfterDrag(t *testing.T) {
+	tests := []struct {
+		name         string
+		keys         string
+		wantLines    []string
+		wantRegister string
+	}{
+		{name: "d", keys: "d", wantLines: []string{"h", "4"}, wantRegister: "ello\n\tあい\nx"},
+		{name: "y", keys: "y", wantLines: []string{"hello", "\tあい", "x", "4"}, wantRegister: "ello\n\tあい\nx"},
+		{name: "motion extends the selection", keys: "jd", wantLines: []string{"h"}, wantRegister: "ello\n\tあい\nx\n4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.SetSize()
+			w.FileContents = [][]byte{[]byte("hello"), []byte("\tあい"), []byte("x"), []byte("4")}
+			w.position = Position{X: 1, Y: 1}
+			w.options = optionValues{"mouse": {s: "a"}}
+			for _, ev := range []MouseEvent{
+				{Button: MouseLeft, Row: 0, Col: 1},
+				{Button: MouseLeft, Action: MouseDrag, Row: 2, Col: 0},
+				{Button: MouseLeft, Action: MouseRelease, Row: 2, Col: 0},
+			} {
+				w.handleMouse(ev)
+			}
+			for _, k := range tt.keys {
+				w.handleKey(KeyEvent{Key: prompt.NotDefined, Data: []byte(string(k))})
+			}
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if r, _ := w.getRegister('"'); string(r.text) != tt.wantRegister {
+				t.Errorf("got: register %q, want: %q", r.text, tt.wantRegister)
+			}
+			if w.mode != normalMode {
+				t.Errorf("got: mode %d, want: normal mode", w.mode)
+			}
+		})
+	}
+}
+
+func TestWindow_PrintFileContentsVisual(t *testing.T) {
+	term := NewSimTerminal(20, 4)
+	w := NewWindow(term)
+	w.SetSize()
+	w.FileContents = [][]byte{[]byte("abc"), []byte("def")}
+	w.position = Position{X: 2, Y: 1}
+	w.setVisualMode()
+	w.position = Position{X: 1, Y: 2}
+	w.PrintFileContents()
+	visual := w.style("Visual")
+	for _, c := range []struct {
+		row, col int
+		selected bool
+	}{{0, 0, false}, {0, 1, true}, {0, 2, true}, {1, 0, true}, {1, 1, false}} {
+		if got := term.Cell(c.row, c.col).Style == visual.Over(w.style("Normal")); got != c.selected {
+			t.Errorf("got: cell %d, %d selected %v, want: %v", c.row, c.col, got, c.selected)
+		}
+	}
+}
+
+func TestWindow_SetMouse(t *testing.T) {
+	term := NewSimTerminal(40, 4)
+	w := NewWindow(term)
+	w.SetSize()
+	w.FileContents = [][]byte{[]byte("hello"), []byte("world")}
+	for _, line := range []string{"set mouse=a"} {
+		if err := w.ExecuteLine(line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !term.Mouse {
+		t.Errorf("got: mouse off, want: on")
+	}
+	w.HandleKey(w.KeyDecoder().Feed([]byte(click(1, 2)))[0])
+	if w.position != (Position{X: 3, Y: 2}) {
+		t.Errorf("got: %+v, want: %+v", w.position, Position{X: 3, Y: 2})
+	}
+	if err := w.ExecuteLine("set mouse="); err != nil {
+		t.Fatal(err)
+	}
+	if term.Mouse {
+		t.Errorf("got: mouse on, want: off")
+	}
+	if err := w.ExecuteLine("set mouse=z"); err == nil || err.Error() != "E474: Invalid argument: mouse=z" {
+		t.Errorf("got: %v, want: E474", err)
+	}
+	if err := w.ExecuteLine("set nosuch"); err == nil || err.Error() != "E518: Unknown option: nosuch" {
+		t.Errorf("got: %v, want: E518", err)
+	}
+}
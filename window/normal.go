@@ -0,0 +1,799 @@
+package window
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+// normalKeys is a command of normal mode as typed, ex) 3rx
+type normalKeys struct {
+	count  int    // 0 when no count was typed
+	name   string // ex) r
+	arg    []byte // the character typed after the commands or motions that take one, ex) x of rx or a of d'a
+	motion string // the motion typed after an operator, ex) w of dw
+	// register is the register typed before the command, ex) a of "ap, 0 when none was typed
+	register byte
+}
+
+// times returns the count, which is 1 when none was typed.
+func (k normalKeys) times() int {
+	if k.count == 0 {
+		return 1
+	}
+	return k.count
+}
+
+// normalCommand is a command of normal mode, ex) x
+type normalCommand struct {
+	run func(w *Window, k normalKeys)
+	// arg is true for the commands followed by a character, ex) r
+	arg bool
+	// change is true for the commands that change the buffer, the last one is recorded to be repeated
+	change bool
+	// operator is true for the commands followed by a motion, ex) d
+	operator bool
+}
+
+var normalCommands map[string]normalCommand
+
+func init() {
+	normalCommands = map[string]normalCommand{
+		":":  {run: (*Window).colonCommand},
+		"u":  {run: (*Window).undoCommand},
+		"v":  {run: func(w *Window, k normalKeys) { w.setVisualMode() }},
+		"i":  {run: (*Window).insertCommand, change: true},
+		"a":  {run: (*Window).insertCommand, change: true},
+		"A":  {run: (*Window).insertCommand, change: true},
+		"I":  {run: (*Window).insertCommand, change: true},
+		"o":  {run: (*Window).openCommand, change: true},
+		"O":  {run: (*Window).openCommand, change: true},
+		"s":  {run: (*Window).substituteCommand, change: true},
+		"S":  {run: (*Window).substituteCommand, change: true},
+		"C":  {run: (*Window).substituteCommand, change: true},
+		"R":  {run: (*Window).insertCommand, change: true},
+		"r":  {run: (*Window).replaceCommand, arg: true, change: true},
+		"~":  {run: (*Window).tildeCommand, change: true},
+		"J":  {run: (*Window).joinCommand, change: true},
+		"gJ": {run: (*Window).joinCommand, change: true},
+		"x":  {run: (*Window).deleteCommand, change: true},
+		"X":  {run: (*Window).deleteCommand, change: true},
+		"d":  {run: (*Window).operatorCommand, change: true, operator: true},
+		">":  {run: (*Window).operatorCommand, change: true, operator: true},
+		"<":  {run: (*Window).operatorCommand, change: true, operator: true},
+		"=":  {run: (*Window).operatorCommand, change: true, operator: true},
+		"c":  {run: (*Window).operatorCommand, change: true, operator: true},
+		".":  {run: (*Window).repeatCommand},
+		"y":  {run: (*Window).operatorCommand, operator: true},
+		"Y":  {run: func(w *Window, k normalKeys) { k.name, k.motion = "y", "y"; w.operatorCommand(k) }},
+		"p":  {run: (*Window).putCommand, change: true},
+		"P":  {run: (*Window).putCommand, change: true},
+		"q":  {run: (*Window).recordCommand, arg: true},
+		"@":  {run: (*Window).executeRegisterCommand, arg: true},
+		"m":  {run: (*Window).markCommand, arg: true},
+		"/":  {run: (*Window).startSearch},
+		"?":  {run: (*Window).startSearch},
+	}
+	// Ctrl-O and Ctrl-I
+	normalCommands["\x0f"] = normalCommand{run: (*Window).jumpCommand}
+	normalCommands["\t"] = normalCommand{run: (*Window).jumpCommand}
+}
+
+// change is a change of the buffer recorded to be repeated.
+type change struct {
+	keys normalKeys
+	// insert is the keys typed in insert mode after the command, ex) the text after o
+	insert []KeyEvent
+}
+
+// insertion is the insert or replace mode started by a command, ex) 3o
+type insertion struct {
+	keys    normalKeys
+	replace bool // typed characters overwrite the text
+	open    bool // each time the text is inserted again a line is opened for it, ex) o
+	// typed is the keys typed, they are inserted again count-1 times when the insert ends
+	typed []KeyEvent
+	count int
+	// replaced is the text overwritten by each character typed in replace mode, for Backspace.
+	// It is nil for a character typed at the end of a line and \n for a line break.
+	replaced [][]byte
+	// indented is the line autoindent indented while nothing is typed after the indent, 0 when none
+	indented int
+}
+
+// typeNormal handles a character typed in normal mode: a count, a command, a motion or a part of one.
+func (w *Window) typeNormal(b []byte) {
+	s := string(b)
+	if w.pending == "" && (s >= "1" && s <= "9" || s == "0" && w.count > 0) {
+		w.count = w.count*10 + int(s[0]-'0')
+		return
+	}
+	if w.pending == `"` {
+		// the register of the command, ex) "a
+		w.pending = ""
+		if len(b) != 1 || !isRegister(b[0]) {
+			w.cancelNormal()
+			return
+		}
+		w.register = b[0]
+		return
+	}
+	if cmd, ok := normalCommands[w.pending]; ok && cmd.arg && w.operator == "" {
+		w.runNormal(normalKeys{count: w.count, name: w.pending, arg: b, register: w.register})
+		return
+	}
+	if _, ok := argMotions[w.pending]; ok {
+		if w.operator != "" {
+			w.runNormal(w.operatorKeys(w.pending, b))
+			return
+		}
+		k := normalKeys{count: w.count, name: w.pending, arg: b}
+		w.cancelNormal()
+		w.moveCursor(k)
+		return
+	}
+	name := w.pending + s
+	if w.operator != "" {
+		// the motion of the operator, or the operator again for lines, ex) dd
+		if _, ok := motions[name]; ok || name == w.operator {
+			w.runNormal(w.operatorKeys(name, nil))
+		} else if _, ok := argMotions[name]; ok || isNormalPrefix(name) {
+			w.pending = name
+		} else {
+			w.cancelNormal()
+			w.fail()
+		}
+		return
+	}
+	cmd, ok := normalCommands[name]
+	switch {
+	case name == `"`:
+		w.pending = name
+	case name == "q" && w.recording != 0:
+		w.cancelNormal()
+		w.stopRecording()
+	case ok && cmd.operator:
+		w.operator, w.operatorCount = name, w.count
+		w.count, w.pending = 0, ""
+	case ok && cmd.arg:
+		w.pending = name
+	case ok:
+		w.runNormal(normalKeys{count: w.count, name: name, register: w.register})
+	case motions[name].move != nil:
+		k := normalKeys{count: w.count, name: name}
+		w.cancelNormal()
+		w.moveCursor(k)
+	case argMotions[name] != nil:
+		w.pending = name
+	case isNormalPrefix(name):
+		w.pending = name
+	default:
+		w.cancelNormal()
+		w.fail()
+	}
+}
+
+// visualOperators are the commands that work on the text selected in visual mode, x is d.
+var visualOperators = map[string]string{"d": "d", "x": "d", "y": "y", "c": "c", ">": ">", "<": "<", "=": "="}
+
+// typeVisual handles a character typed in visual mode: a motion moves the end of the selection
+// and an operator works on the selected text.
+func (w *Window) typeVisual(b []byte) {
+	s := string(b)
+	if w.pending == "" && (s >= "1" && s <= "9" || s == "0" && w.count > 0) {
+		w.count = w.count*10 + int(s[0]-'0')
+		return
+	}
+	if w.pending == `"` {
+		w.pending = ""
+		if len(b) != 1 || !isRegister(b[0]) {
+			w.cancelNormal()
+			return
+		}
+		w.register = b[0]
+		return
+	}
+	if _, ok := argMotions[w.pending]; ok {
+		k := normalKeys{count: w.count, name: w.pending, arg: b}
+		w.cancelNormal()
+		w.moveCursor(k)
+		return
+	}
+	name := w.pending + s
+	switch op, ok := visualOperators[name]; {
+	case name == "v":
+		w.cancelNormal()
+		w.SetNormalMode()
+	case name == "o":
+		// the cursor goes to the other end of the selection
+		w.cancelNormal()
+		w.visualStart, w.position = w.position, w.visualStart
+	case name == `"`:
+		w.pending = name
+	case ok:
+		w.operateVisual(op)
+	case motions[name].move != nil:
+		k := normalKeys{count: w.count, name: name}
+		w.cancelNormal()
+		w.moveCursor(k)
+	case argMotions[name] != nil || isNormalPrefix(name):
+		w.pending = name
+	default:
+		w.cancelNormal()
+		w.fail()
+	}
+}
+
+// operateVisual runs the operator on the text selected in visual mode, which includes both ends.
+func (w *Window) operateVisual(op string) {
+	k := normalKeys{name: op, register: w.register}
+	w.cancelNormal()
+	from, to := w.visualStart, w.position
+	if before(to, from) {
+		from, to = to, from
+	}
+	w.SetNormalMode()
+	if line := w.FileContents[to.Y-1]; to.X > len(line) && to.Y < len(w.FileContents) {
+		// the line break at the end of the selection
+		to = Position{X: 1, Y: to.Y + 1}
+	} else {
+		to.X = runesAfter(line, to.X-1, 1) + 1
+	}
+	w.position = from
+	w.operate(k, from, to, false)
+	if w.insertion == nil {
+		w.changing = false
+	}
+}
+
+// operatorKeys returns the operator typed with its motion and the character typed after the motion, if any.
+func (w *Window) operatorKeys(motion string, arg []byte) normalKeys {
+	count := w.count
+	if w.operatorCount > 0 {
+		// ex) 2d3w deletes 6 words
+		count = w.operatorCount * orOne(w.count)
+	}
+	return normalKeys{count: count, name: w.operator, motion: motion, arg: arg, register: w.register}
+}
+
+// isNormalPrefix reports whether s is the start of a command or a motion, ex) g of gJ
+func isNormalPrefix(s string) bool {
+	for name := range normalCommands {
+		if len(name) > len(s) && strings.HasPrefix(name, s) {
+			return true
+		}
+	}
+	for name := range motions {
+		if len(name) > len(s) && strings.HasPrefix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cancelNormal forgets the count and the command typed so far, ex) after Escape
+func (w *Window) cancelNormal() {
+	w.count = 0
+	w.pending = ""
+	w.operator = ""
+	w.operatorCount = 0
+	w.register = 0
+}
+
+func (w *Window) runNormal(k normalKeys) {
+	w.cancelNormal()
+	if len(w.FileContents) == 0 {
+		w.FileContents = [][]byte{{}}
+	}
+	cmd := normalCommands[k.name]
+	cmd.run(w, k)
+	if w.insertion != nil {
+		// recorded when the insert ends
+		return
+	}
+	w.changing = false
+	if cmd.change {
+		w.lastChange = change{keys: k}
+	}
+}
+
+// beginChange saves the buffer for undo before the first change of a command.
+func (w *Window) beginChange() {
+	if !w.changing {
+		w.saveUndo()
+		w.changing = true
+	}
+}
+
+// startInsert enters insert mode for the command k, the text typed is inserted count times.
+func (w *Window) startInsert(k normalKeys) {
+	w.SetInsertMode()
+	w.insertion = &insertion{keys: k, count: k.times()}
+}
+
+// colonCommand is :, it starts typing an ex command. A count is the range of as many lines, ex) :.,.+2
+func (w *Window) colonCommand(k normalKeys) {
+	w.startCommandLine(':')
+	if k.count > 1 {
+		w.AddCommand([]byte(fmt.Sprintf(".,.+%d", k.count-1)))
+	} else if k.count == 1 {
+		w.AddCommand([]byte("."))
+	}
+}
+
+// undoCommand is u, it reverts count changes.
+func (w *Window) undoCommand(k normalKeys) {
+	for i := 0; i < k.times(); i++ {
+		w.Undo()
+	}
+}
+
+// insertCommand is i, a, A, I and R.
+func (w *Window) insertCommand(k normalKeys) {
+	line := w.FileContents[w.position.Y-1]
+	w.startInsert(k)
+	switch k.name {
+	case "a":
+		w.position.X = runesAfter(line, w.position.X-1, 1) + 1
+	case "A":
+		w.position.X = len(line) + 1
+	case "I":
+		w.position.X = len(line) - len(bytes.TrimLeft(line, " \t")) + 1
+	case "R":
+		w.insertion.replace = true
+	}
+}
+
+// openCommand is o and O, they insert on a new line below or above the cursor.
+func (w *Window) openCommand(k normalKeys) {
+	w.startInsert(k)
+	w.insertion.open = true
+	w.beginChange()
+	y := w.position.Y
+	if k.name == "O" {
+		y--
+	}
+	w.insertLines(y, [][]byte{{}})
+	w.position = Position{X: 1, Y: y + 1}
+	if k.name == "O" {
+		w.autoIndent(y + 2)
+	} else {
+		w.autoIndent(y)
+	}
+}
+
+// openLine opens a line below the cursor, for repeating o and O.
+func (w *Window) openLine() {
+	w.insertLines(w.position.Y, [][]byte{{}})
+	w.position = Position{X: 1, Y: w.position.Y + 1}
+	w.autoIndent(w.position.Y - 1)
+}
+
+// substituteCommand is s, S and C: they delete count characters, count lines
+// or up to the end of the line and start insert mode.
+func (w *Window) substituteCommand(k normalKeys) {
+	y := w.position.Y
+	line := w.FileContents[y-1]
+	w.startInsert(k)
+	// the count is what is deleted, the text is inserted once
+	w.insertion.count = 1
+	w.beginChange()
+	last := y + k.times() - 1
+	if last > len(w.FileContents) {
+		last = len(w.FileContents)
+	}
+	switch k.name {
+	case "s":
+		w.deleteRegister(k, w.position, Position{X: runesAfter(line, w.position.X-1, k.times()) + 1, Y: y})
+	case "S":
+		w.setRegister(k.register, w.linesBetween(y, last), false)
+		w.deleteText(Position{X: 1, Y: y}, Position{X: len(w.FileContents[last-1]) + 1, Y: last})
+		w.position.X = 1
+	case "C":
+		if w.position.X > len(line) {
+			w.position.X = len(line) + 1
+		}
+		w.deleteRegister(k, w.position, Position{X: len(w.FileContents[last-1]) + 1, Y: last})
+	}
+}
+
+// replaceCommand is r, it replaces count characters with the one typed.
+// A line break replaces them with one line break.
+func (w *Window) replaceCommand(k normalKeys) {
+	y := w.position.Y
+	line := w.FileContents[y-1]
+	from := w.position.X - 1
+	to := runesAfter(line, from, k.times())
+	if from >= len(line) || utf8.RuneCount(line[from:to]) < k.times() {
+		w.fail()
+		return
+	}
+	w.beginChange()
+	if string(k.arg) == "\r" || string(k.arg) == "\n" {
+		w.deleteText(w.position, Position{X: to + 1, Y: y})
+		w.position = w.insertText(w.position, []byte("\n"))
+		return
+	}
+	w.setLine(y-1, concat(line[:from], bytes.Repeat(k.arg, k.times()), line[to:]))
+	w.position.X = from + len(k.arg)*(k.times()-1) + 1
+}
+
+// tildeCommand is ~, it switches the case of count characters and moves the cursor after them.
+func (w *Window) tildeCommand(k normalKeys) {
+	y := w.position.Y
+	line := w.FileContents[y-1]
+	from := w.position.X - 1
+	if from >= len(line) {
+		return
+	}
+	to := runesAfter(line, from, k.times())
+	switched := bytes.Map(func(r rune) rune {
+		if unicode.IsUpper(r) {
+			return unicode.ToLower(r)
+		}
+		return unicode.ToUpper(r)
+	}, line[from:to])
+	w.beginChange()
+	w.setLine(y-1, concat(line[:from], switched, line[to:]))
+	w.position.X = from + len(switched) + 1
+	w.clampCursor()
+}
+
+// joinCommand is J and gJ, they join count lines, at least two.
+// J removes the indent of the joined lines and puts a space between them.
+func (w *Window) joinCommand(k normalKeys) {
+	y := w.position.Y
+	n := k.times()
+	if n < 2 {
+		n = 2
+	}
+	if y+n-1 > len(w.FileContents) {
+		if n == 2 {
+			w.fail()
+			return
+		}
+		n = len(w.FileContents) - y + 1
+	}
+	w.beginChange()
+	line := w.FileContents[y-1]
+	col := 0
+	for _, next := range w.FileContents[y : y+n-1] {
+		col = len(line)
+		if k.name == "J" {
+			next = bytes.TrimLeft(next, " \t")
+			if len(line) > 0 && len(next) > 0 && !isBlank(line[len(line)-1]) && next[0] != ')' {
+				line = concat(line, []byte(" "))
+			}
+		}
+		line = concat(line, next)
+	}
+	w.setLine(y-1, line)
+	w.deleteLines(y, n-1)
+	w.position.X = col + 1
+	w.clampCursor()
+}
+
+func isBlank(c byte) bool {
+	return c == ' ' || c == '\t'
+}
+
+// deleteCommand is x and X, they delete count characters under and after or before the cursor.
+func (w *Window) deleteCommand(k normalKeys) {
+	y := w.position.Y
+	line := w.FileContents[y-1]
+	from, to := w.position.X-1, runesAfter(line, w.position.X-1, k.times())
+	if k.name == "X" {
+		from, to = runesBefore(line, w.position.X-1, k.times()), w.position.X-1
+	}
+	if from >= to {
+		w.fail()
+		return
+	}
+	w.beginChange()
+	w.deleteRegister(k, Position{X: from + 1, Y: y}, Position{X: to + 1, Y: y})
+	w.position.X = from + 1
+	w.clampCursor()
+}
+
+// operatorCommand is d, c, y, >, < and = with the motion typed after them, ex) dw
+// The operator typed twice works on count lines, ex) dd
+func (w *Window) operatorCommand(k normalKeys) {
+	start := w.position
+	end := Position{X: 1, Y: start.Y + k.times() - 1}
+	linewise, inclusive := true, false
+	if end.Y > len(w.FileContents) {
+		end.Y = len(w.FileContents)
+	}
+	if k.motion != k.name {
+		m, ok := w.motionOf(k.motion, k.arg)
+		if !ok {
+			// ex) repeating a change of visual mode
+			w.fail()
+			return
+		}
+		move := m.move
+		linewise, inclusive = m.linewise, m.inclusive
+		if k.name == "c" && (k.motion == "w" || k.motion == "W") && w.classAt(start, k.motion == "W") != 0 {
+			// cw changes the word up to its end, like ce
+			big := k.motion == "W"
+			move = func(w *Window, p Position, count int, op bool) (Position, bool) {
+				return w.endOfWord(p, count, big, true)
+			}
+			inclusive = true
+		}
+		if end, ok = move(w, start, k.count, true); !ok {
+			w.fail()
+			return
+		}
+	}
+	from, to := start, end
+	if to.Y < from.Y || to.Y == from.Y && to.X < from.X {
+		from, to = to, from
+	}
+	if inclusive {
+		to.X = runesAfter(w.FileContents[to.Y-1], to.X-1, 1) + 1
+	}
+	w.operate(k, from, to, linewise)
+}
+
+// operate runs the operator of k on the text from from up to to, or on the lines from from to to.
+func (w *Window) operate(k normalKeys, from, to Position, linewise bool) {
+	if k.name == "y" {
+		w.yank(k, from, to, linewise)
+		return
+	}
+	if k.name == ">" || k.name == "<" || k.name == "=" {
+		// they work on the lines the motion is in
+		w.beginChange()
+		if k.name == "=" {
+			w.indentLines(from.Y, to.Y)
+		} else {
+			w.shiftLines(k.name == ">", from.Y, to.Y)
+		}
+		w.position = Position{X: w.firstNonBlank(from.Y), Y: from.Y}
+		w.clampCursor()
+		return
+	}
+	if k.name == "c" {
+		w.startInsert(k)
+		// the count is what is changed, the text is inserted once
+		w.insertion.count = 1
+	}
+	w.beginChange()
+	if linewise {
+		w.setRegister(k.register, w.linesBetween(from.Y, to.Y), false)
+		w.operateLines(k.name, from.Y, to.Y)
+		return
+	}
+	w.deleteRegister(k, from, to)
+	w.position = from
+	if k.name == "d" {
+		w.clampCursor()
+	}
+}
+
+// yank keeps the text from from up to to, or the lines from from to to, in the register of k.
+func (w *Window) yank(k normalKeys, from, to Position, linewise bool) {
+	if linewise {
+		last := w.FileContents[to.Y-1]
+		w.putMark('[', Position{X: 1, Y: from.Y})
+		w.putMark(']', Position{X: runesBefore(last, len(last), 1) + 1, Y: to.Y})
+		w.setRegister(k.register, w.linesBetween(from.Y, to.Y), true)
+		w.position.Y = from.Y
+		w.clampCursor()
+		return
+	}
+	w.setRegister(k.register, register{text: w.textBetween(from, to)}, true)
+	w.putMark('[', from)
+	if p, ok := w.prev(to); ok && before(from, to) {
+		w.putMark(']', p)
+	} else {
+		w.putMark(']', from)
+	}
+	w.position = from
+	w.clampCursor()
+}
+
+// deleteRegister deletes the text from from up to to and keeps it in the register of k.
+func (w *Window) deleteRegister(k normalKeys, from, to Position) {
+	w.setRegister(k.register, register{text: w.textBetween(from, to)}, false)
+	w.deleteText(from, to)
+}
+
+// operateLines deletes the lines from first to last, or makes them one empty line for c.
+func (w *Window) operateLines(operator string, first, last int) {
+	if operator == "c" {
+		w.deleteText(Position{X: 1, Y: first}, Position{X: len(w.FileContents[last-1]) + 1, Y: last})
+		w.position = Position{X: 1, Y: first}
+		return
+	}
+	w.deleteLines(first-1, last-first+1)
+	if len(w.FileContents) == 0 {
+		w.insertLines(0, [][]byte{{}})
+	}
+	if first > len(w.FileContents) {
+		first = len(w.FileContents)
+	}
+	w.position = Position{X: w.firstNonBlank(first), Y: first}
+}
+
+// repeatCommand is ., it makes the last change again. A count replaces the count of the change.
+func (w *Window) repeatCommand(k normalKeys) {
+	c := w.lastChange
+	if c.keys.name == "" {
+		w.fail()
+		return
+	}
+	if k.count > 0 {
+		c.keys.count = k.count
+	}
+	w.runNormal(c.keys)
+	if w.insertion == nil {
+		return
+	}
+	for _, key := range c.insert {
+		w.recordInsert(key)
+		w.insertKey(key)
+	}
+	w.finishInsert()
+	w.SetNormalMode()
+}
+
+// clampCursor moves the cursor onto the last character when it is past the end of the line in normal mode.
+func (w *Window) clampCursor() {
+	line := w.FileContents[w.position.Y-1]
+	if w.position.X > len(line) {
+		_, size := utf8.DecodeLastRune(line)
+		w.position.X = len(line) - size + 1
+	}
+	if w.position.X < 1 {
+		w.position.X = 1
+	}
+}
+
+// typeText inserts text typed in insert mode, or overwrites the text under the cursor in replace mode.
+func (w *Window) typeText(text []byte) {
+	w.beginChange()
+	if w.insertion == nil || !w.insertion.replace {
+		w.position = w.insertText(w.position, text)
+		return
+	}
+	for _, r := range string(text) {
+		line := w.FileContents[w.position.Y-1]
+		from := w.position.X - 1
+		to := runesAfter(line, from, 1)
+		var old []byte
+		if to > from {
+			old = line[from:to]
+		}
+		s := []byte(string(r))
+		w.setLine(w.position.Y-1, concat(line[:from], s, line[to:]))
+		w.position.X += len(s)
+		w.insertion.replaced = append(w.insertion.replaced, old)
+	}
+}
+
+// newLine breaks the line at the cursor in insert mode. With autoindent the blanks
+// after the cursor are dropped and the new line is indented.
+func (w *Window) newLine() {
+	w.beginChange()
+	indented := w.takeIndented() == w.position.Y
+	if w.insertion != nil && w.insertion.replace {
+		w.position = w.insertText(w.position, []byte("\n"))
+		w.insertion.replaced = append(w.insertion.replaced, []byte("\n"))
+		return
+	}
+	if w.indenting() {
+		line := w.FileContents[w.position.Y-1]
+		rest := line[w.position.X-1:]
+		w.deleteText(w.position, Position{X: w.position.X + len(rest) - len(bytes.TrimLeft(rest, " \t")), Y: w.position.Y})
+	}
+	w.position = w.insertText(w.position, []byte("\n"))
+	w.autoIndent(w.position.Y - 1)
+	if indented {
+		w.clearIndent(w.position.Y - 1)
+	}
+}
+
+// backspace deletes the character before the cursor in insert mode, or joins the line to the line above.
+// In replace mode it puts back the text overwritten, it only moves the cursor over the other text.
+func (w *Window) backspace() {
+	y, x := w.position.Y, w.position.X
+	if in := w.insertion; in != nil && in.replace {
+		if len(in.replaced) == 0 {
+			if x > 1 {
+				w.position.X = runesBefore(w.FileContents[y-1], x-1, 1) + 1
+			}
+			return
+		}
+		old := in.replaced[len(in.replaced)-1]
+		in.replaced = in.replaced[:len(in.replaced)-1]
+		w.beginChange()
+		if string(old) == "\n" {
+			w.joinBack()
+			return
+		}
+		line := w.FileContents[y-1]
+		from := runesBefore(line, x-1, 1)
+		w.setLine(y-1, concat(line[:from], old, line[x-1:]))
+		w.position.X = from + 1
+		return
+	}
+	if w.backspaceBlanks() {
+		return
+	}
+	if x == 1 {
+		if y > 1 {
+			w.beginChange()
+			w.joinBack()
+		}
+		return
+	}
+	w.beginChange()
+	from := runesBefore(w.FileContents[y-1], x-1, 1)
+	w.deleteText(Position{X: from + 1, Y: y}, w.position)
+	w.position.X = from + 1
+}
+
+// joinBack joins the line of the cursor to the end of the line above.
+func (w *Window) joinBack() {
+	y := w.position.Y
+	above := w.FileContents[y-2]
+	w.deleteText(Position{X: len(above) + 1, Y: y - 1}, Position{X: 1, Y: y})
+	w.position = Position{X: len(above) + 1, Y: y - 1}
+}
+
+// insertKey handles a key typed in insert mode other than the cursor keys and Escape.
+func (w *Window) insertKey(k KeyEvent) {
+	switch k.Key {
+	case prompt.NotDefined:
+		w.takeIndented()
+		w.typeText(k.Data)
+		w.reindentTyped()
+	case prompt.Enter:
+		w.newLine()
+	case prompt.Tab:
+		w.takeIndented()
+		w.insertTab()
+	case prompt.Backspace, prompt.ControlH:
+		w.backspace()
+	case prompt.BracketedPaste:
+		w.beginChange()
+		w.position = w.insertText(w.position, k.Data)
+	}
+}
+
+// recordInsert records a key typed in insert mode so the insert can be repeated.
+func (w *Window) recordInsert(k KeyEvent) {
+	if w.insertion != nil {
+		w.insertion.typed = append(w.insertion.typed, k)
+	}
+}
+
+// finishInsert inserts the text typed again count-1 times, records the change
+// and moves the cursor back onto the last character inserted, ex) when Escape is typed.
+func (w *Window) finishInsert() {
+	if w.takeIndented() == w.position.Y {
+		w.clearIndent(w.position.Y)
+	}
+	w.putMark('^', w.position)
+	if in := w.insertion; in != nil {
+		for i := 1; i < in.count; i++ {
+			if in.open {
+				w.openLine()
+			}
+			for _, k := range in.typed {
+				w.insertKey(k)
+			}
+		}
+		w.lastChange = change{keys: in.keys, insert: in.typed}
+		w.insertion = nil
+	}
+	if w.position.X > 1 {
+		w.position.X = runesBefore(w.FileContents[w.position.Y-1], w.position.X-1, 1) + 1
+	}
+}
@@ -0,0 +1,213 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+
+	prompt "github.com/c-bata/go-prompt"
+)
+
+func TestWindow_NormalCommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+	}{
+		{name: "a", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: "ax\033", wantLines: []string{"axbc"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "a on an empty line", lines: []string{""}, position: Position{X: 1, Y: 1}, input: "ax\033", wantLines: []string{"x"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "A", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: "Ax\033", wantLines: []string{"abcx"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "I", lines: []string{"  abc"}, position: Position{X: 4, Y: 1}, input: "I-\033", wantLines: []string{"  -abc"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "insert with a count", lines: []string{"123"}, position: Position{X: 1, Y: 1}, input: "3ia\033", wantLines: []string{"aaa123"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "count dropped after the cursor moves", lines: []string{"123"}, position: Position{X: 1, Y: 1}, input: "3ia\033[Cb\033", wantLines: []string{"a1b23"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "Enter and Backspace in insert mode", lines: []string{"123"}, position: Position{X: 1, Y: 1}, input: "ia\rb\x7f\x7f\x7fc\033", wantLines: []string{"c123"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "Enter in insert mode", lines: []string{"123"}, position: Position{X: 2, Y: 1}, input: "i\rx\033", wantLines: []string{"1", "x23"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "o with a count", lines: []string{"abc", "def"}, position: Position{X: 2, Y: 1}, input: "3ox\033", wantLines: []string{"abc", "x", "x", "x", "def"}, wantPosition: Position{X: 1, Y: 4}},
+		{name: "O", lines: []string{"abc", "def"}, position: Position{X: 2, Y: 2}, input: "Ox\033", wantLines: []string{"abc", "x", "def"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "s with a count", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "2sX\033", wantLines: []string{"Xcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "S with a count", lines: []string{"abc", "def", "ghi"}, position: Position{X: 2, Y: 1}, input: "2Sx\033", wantLines: []string{"x", "ghi"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "C", lines: []string{"abcd", "efg"}, position: Position{X: 2, Y: 1}, input: "Cz\033", wantLines: []string{"az", "efg"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "C with a count", lines: []string{"abcd", "efg", "hij"}, position: Position{X: 2, Y: 1}, input: "2Cz\033", wantLines: []string{"az", "hij"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "R", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "Rxy\033", wantLines: []string{"xycd"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "R and Backspace", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "Rxyz\x7f\x7f\033", wantLines: []string{"xbcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "R past the end and Backspace", lines: []string{"ab"}, position: Position{X: 1, Y: 1}, input: "Rxyz\x7f\033", wantLines: []string{"xy"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "R and Backspace over a line break", lines: []string{"abcd"}, position: Position{X: 2, Y: 1}, input: "Rx\ry\x7f\x7f\033", wantLines: []string{"axcd"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "Backspace before the replaced text", lines: []string{"abcd"}, position: Position{X: 3, Y: 1}, input: "R\x7fx\033", wantLines: []string{"axcd"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "R with a count", lines: []string{"abcdefg"}, position: Position{X: 1, Y: 1}, input: "2Rxy\033", wantLines: []string{"xyxyefg"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "r", lines: []string{"abcd"}, position: Position{X: 2, Y: 1}, input: "rx", wantLines: []string{"axcd"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "r with a count", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "3rあ", wantLines: []string{"あああd"}, wantPosition: Position{X: 7, Y: 1}},
+		{name: "r with a count too large", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "5rx", wantLines: []string{"abcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "r and Enter", lines: []string{"ab cd"}, position: Position{X: 3, Y: 1}, input: "r\r", wantLines: []string{"ab", "cd"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "r and Escape", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "r\033x", wantLines: []string{"bcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "~", lines: []string{"aBc"}, position: Position{X: 1, Y: 1}, input: "2~", wantLines: []string{"Abc"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "~ stops at the end of the line", lines: []string{"aBc"}, position: Position{X: 2, Y: 1}, input: "5~", wantLines: []string{"abC"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "J", lines: []string{"foo", "  bar", "baz"}, position: Position{X: 1, Y: 1}, input: "J", wantLines: []string{"foo bar", "baz"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "J with a count", lines: []string{"foo", "  bar", "", "baz"}, position: Position{X: 1, Y: 1}, input: "4J", wantLines: []string{"foo bar baz"}, wantPosition: Position{X: 8, Y: 1}},
+		{name: "J with a count too large", lines: []string{"foo", "bar", "baz"}, position: Position{X: 1, Y: 2}, input: "5J", wantLines: []string{"foo", "bar baz"}, wantPosition: Position{X: 4, Y: 2}},
+		{name: "J before a parenthesis", lines: []string{"f(", ")"}, position: Position{X: 1, Y: 1}, input: "J", wantLines: []string{"f()"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "J after a space", lines: []string{"foo ", "bar"}, position: Position{X: 1, Y: 1}, input: "J", wantLines: []string{"foo bar"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "J on the last line", lines: []string{"foo", "bar"}, position: Position{X: 1, Y: 2}, input: "J", wantLines: []string{"foo", "bar"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "gJ", lines: []string{"foo", "  bar"}, position: Position{X: 1, Y: 1}, input: "gJ", wantLines: []string{"foo  bar"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "x with a count", lines: []string{"abcd"}, position: Position{X: 3, Y: 1}, input: "3x", wantLines: []string{"ab"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "x a multibyte character", lines: []string{"あいう"}, position: Position{X: 4, Y: 1}, input: "x", wantLines: []string{"あう"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "x on an empty line", lines: []string{""}, position: Position{X: 1, Y: 1}, input: "x", wantLines: []string{""}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "X with a count", lines: []string{"abcd"}, position: Position{X: 3, Y: 1}, input: "2X", wantLines: []string{"cd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "X at the start of the line", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "X", wantLines: []string{"abcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "Escape cancels the count", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "3\033x", wantLines: []string{"bcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "a count is undone at once", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "3xu", wantLines: []string{"abcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "o and the text are undone at once", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "oxy\033u", wantLines: []string{"abcd"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dw", lines: []string{"foo bar baz"}, position: Position{X: 1, Y: 1}, input: "dw", wantLines: []string{"bar baz"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dw on the last word of a line", lines: []string{"foo bar", "baz"}, position: Position{X: 5, Y: 1}, input: "dw", wantLines: []string{"foo ", "baz"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "dw on the last character", lines: []string{"foo"}, position: Position{X: 3, Y: 1}, input: "dw", wantLines: []string{"fo"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "counts of operator and motion multiply", lines: []string{"a b c d e f g h"}, position: Position{X: 1, Y: 1}, input: "2d3w", wantLines: []string{"g h"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "de", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "de", wantLines: []string{" bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "db", lines: []string{"foo bar"}, position: Position{X: 5, Y: 1}, input: "db", wantLines: []string{"bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "d$", lines: []string{"foo bar"}, position: Position{X: 3, Y: 1}, input: "d$", wantLines: []string{"fo"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "d0", lines: []string{"foo bar"}, position: Position{X: 5, Y: 1}, input: "d0", wantLines: []string{"bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dd with a count", lines: []string{"1", "  2", "3", "4"}, position: Position{X: 1, Y: 1}, input: "2dd", wantLines: []string{"3", "4"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dd on the last line", lines: []string{"1", "  2", "3"}, position: Position{X: 1, Y: 3}, input: "dd", wantLines: []string{"1", "  2"}, wantPosition: Position{X: 3, Y: 2}},
+		{name: "dd of every line", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "5dd", wantLines: []string{""}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dj", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 2}, input: "dk", wantLines: []string{"3"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dj on the last line", lines: []string{"1", "2"}, position: Position{X: 1, Y: 2}, input: "dj", wantLines: []string{"1", "2"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "dG", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 2}, input: "dG", wantLines: []string{"1"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dgg", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 2}, input: "dgg", wantLines: []string{"3"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "df", lines: []string{"foo(bar)"}, position: Position{X: 1, Y: 1}, input: "df(", wantLines: []string{"bar)"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dt", lines: []string{"foo(bar)"}, position: Position{X: 1, Y: 1}, input: "dt(", wantLines: []string{"(bar)"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "dF", lines: []string{"foo(bar)"}, position: Position{X: 7, Y: 1}, input: "dF(", wantLines: []string{"foor)"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "d;", lines: []string{"a,b,c,d"}, position: Position{X: 1, Y: 1}, input: "f,d;", wantLines: []string{"ac,d"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "d, is not inclusive after f", lines: []string{"a,b,c,d"}, position: Position{X: 1, Y: 1}, input: "2f,d,", wantLines: []string{"a,c,d"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "repeat dt", lines: []string{"a,b,c"}, position: Position{X: 1, Y: 1}, input: "dt,x.", wantLines: []string{",c"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "d%", lines: []string{"f(a, (b)) c"}, position: Position{X: 2, Y: 1}, input: "d%", wantLines: []string{"f c"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "c%", lines: []string{"x = {", "  a", "}"}, position: Position{X: 5, Y: 1}, input: "c%{}\033", wantLines: []string{"x = {}"}, wantPosition: Position{X: 6, Y: 1}},
+		{name: "d and Escape", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: "d\033x", wantLines: []string{"bc"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "cw changes to the end of the word", lines: []string{"foo bar"}, position: Position{X: 2, Y: 1}, input: "cwx\033", wantLines: []string{"fx bar"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "cw on the last character of a word", lines: []string{"foo bar"}, position: Position{X: 3, Y: 1}, input: "cwx\033", wantLines: []string{"fox bar"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "cw on blanks", lines: []string{"foo  bar"}, position: Position{X: 4, Y: 1}, input: "cwx\033", wantLines: []string{"fooxbar"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "c2w", lines: []string{"a b c"}, position: Position{X: 1, Y: 1}, input: "c2wx\033", wantLines: []string{"x c"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "cc", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "2ccx\033", wantLines: []string{"x", "3"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "cw is undone at once", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "cwxyz\033u", wantLines: []string{"foo bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: ". repeats x", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "2x.", wantLines: []string{"ef"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: ". with a count replaces the count", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "2x3.", wantLines: []string{"f"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: ". repeats cw and the text", lines: []string{"foo bar baz"}, position: Position{X: 1, Y: 1}, input: "cwxy\033w.", wantLines: []string{"xy xy baz"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: ". repeats a count of words", lines: []string{"a b c d e f"}, position: Position{X: 1, Y: 1}, input: "d2w.", wantLines: []string{"e f"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: ". repeats an insert", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: "Ax\033.", wantLines: []string{"abcxx"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: ". repeats an insert with a new count", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: "2ix\0333.", wantLines: []string{"xxxxxabc"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: ". repeats o", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "ox\033j.", wantLines: []string{"1", "x", "2", "x"}, wantPosition: Position{X: 1, Y: 4}},
+		{name: ". repeats r", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "rxl.", wantLines: []string{"xxcd"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: ". repeats J", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: "J.", wantLines: []string{"a b c"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: ". repeats R", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "Rxy\033l.", wantLines: []string{"xyxyef"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: ". is undone at once", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "cwxy\033w.u", wantLines: []string{"xy bar"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: ". without a change", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: ".", wantLines: []string{"abc"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "u with a count", lines: []string{"abcd"}, position: Position{X: 1, Y: 1}, input: "xxx2u", wantLines: []string{"bcd"}, wantPosition: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if !w.IsNormalMode() {
+				t.Errorf("got: mode %d, want: normal mode", w.mode)
+			}
+		})
+	}
+}
+
+func TestWindow_visualMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantRegister string
+		wantMode     int
+	}{
+		{name: "d", lines: []string{"abcdef"}, position: Position{X: 2, Y: 1}, input: "vlld", wantLines: []string{"aef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bcd"},
+		{name: "x", lines: []string{"abcdef"}, position: Position{X: 2, Y: 1}, input: "vlx", wantLines: []string{"adef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bc"},
+		{name: "d backwards", lines: []string{"abcdef"}, position: Position{X: 4, Y: 1}, input: "vhhd", wantLines: []string{"aef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bcd"},
+		{name: "d over lines", lines: []string{"abc", "def", "ghi"}, position: Position{X: 2, Y: 1}, input: "vjd", wantLines: []string{"af", "ghi"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bc\nde"},
+		{name: "d with a count", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "v3ld", wantLines: []string{"ef"}, wantPosition: Position{X: 1, Y: 1}, wantRegister: "abcd"},
+		{name: "d a word", lines: []string{"foo bar baz"}, position: Position{X: 5, Y: 1}, input: "ved", wantLines: []string{"foo  baz"}, wantPosition: Position{X: 5, Y: 1}, wantRegister: "bar"},
+		{name: "d an empty line", lines: []string{"abc", "", "def"}, position: Position{X: 3, Y: 1}, input: "vjd", wantLines: []string{"abdef"}, wantPosition: Position{X: 3, Y: 1}, wantRegister: "c\n\n"},
+		{name: "y", lines: []string{"abcdef"}, position: Position{X: 4, Y: 1}, input: "vhhy", wantLines: []string{"abcdef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bcd"},
+		{name: "y into a register", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "vl\"ay\"ap", wantLines: []string{"aabbcdef"}, wantPosition: Position{X: 3, Y: 1}, wantRegister: "ab"},
+		{name: "c", lines: []string{"abcdef"}, position: Position{X: 2, Y: 1}, input: "vlcx\033", wantLines: []string{"axdef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bc"},
+		{name: ">", lines: []string{"a", "b", "c"}, position: Position{X: 1, Y: 1}, input: "vj>", wantLines: []string{"\ta", "\tb", "c"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "o swaps the ends", lines: []string{"abcdef"}, position: Position{X: 3, Y: 1}, input: "vlohd", wantLines: []string{"aef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bcd"},
+		{name: "v ends", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "vlvx", wantLines: []string{"acdef"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "Escape ends", lines: []string{"abcdef"}, position: Position{X: 1, Y: 1}, input: "vl\033x", wantLines: []string{"acdef"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "undo", lines: []string{"abcdef"}, position: Position{X: 2, Y: 1}, input: "vlldu", wantLines: []string{"abcdef"}, wantPosition: Position{X: 2, Y: 1}, wantRegister: "bcd"},
+		{name: "stays in visual mode after a motion", lines: []string{"abc"}, position: Position{X: 1, Y: 1}, input: "vl", wantLines: []string{"abc"}, wantPosition: Position{X: 2, Y: 1}, wantMode: visualMode},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if tt.wantRegister != "" {
+				if r, _ := w.getRegister('"'); string(r.text) != tt.wantRegister {
+					t.Errorf("got: register %q, want: %q", r.text, tt.wantRegister)
+				}
+			}
+			if w.mode != tt.wantMode {
+				t.Errorf("got: mode %d, want: %d", w.mode, tt.wantMode)
+			}
+		})
+	}
+}
+
+// typeKeys runs a window on lines with the cursor at position until input is handled.
+func typeKeys(t *testing.T, lines []string, position Position, input string) *Window {
+	t.Helper()
+	term := NewSimTerminal(20, 8)
+	w := NewWindow(term)
+	for _, l := range lines {
+		w.FileContents = append(w.FileContents, []byte(l))
+	}
+	w.position = position
+	term.Type([]byte(input))
+	runTyped(t, term, w)
+	return w
+}
+
+func TestWindow_lastChange(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  change
+	}{
+		{name: "command", input: "3x", want: change{keys: normalKeys{count: 3, name: "x"}}},
+		{name: "command with a character", input: "rz", want: change{keys: normalKeys{name: "r", arg: []byte("z")}}},
+		{name: "insert", input: "2oa\r\033", want: change{
+			keys:   normalKeys{count: 2, name: "o"},
+			insert: []KeyEvent{{Key: prompt.NotDefined, Data: []byte("a")}, {Key: prompt.Enter, Data: []byte("\r")}},
+		}},
+		{name: "operator and motion", input: "2d3w", want: change{keys: normalKeys{count: 6, name: "d", motion: "w"}}},
+		{name: "operator twice", input: "dd", want: change{keys: normalKeys{name: "d", motion: "d"}}},
+		{name: "not a change", input: "xv", want: change{keys: normalKeys{name: "x"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.FileContents = [][]byte{[]byte("abcd")}
+			for _, k := range w.KeyDecoder().Feed([]byte(tt.input)) {
+				w.HandleKey(k)
+			}
+			w.HandleKey(KeyEvent{Key: prompt.Escape})
+			if !reflect.DeepEqual(w.lastChange, tt.want) {
+				t.Errorf("got: %+v, want: %+v", w.lastChange, tt.want)
+			}
+		})
+	}
+}
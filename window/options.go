@@ -0,0 +1,394 @@
+package window
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options change how the editor works, ex) :set tabstop=4
+// A global option has one value. A buffer or window option has a global value and a local one:
+// :set sets both, :setlocal only the local one, and a new buffer starts with the global values.
+
+// The types of the values of options.
+const (
+	boolOption = iota
+	numberOption
+	stringOption
+)
+
+// What an option belongs to.
+const (
+	globalScope = iota
+	bufferScope
+	windowScope
+)
+
+// optionValue is the value of an option, in the field of its type.
+type optionValue struct {
+	b bool
+	n int
+	s string
+}
+
+// optionValues are the values of options set, by name.
+type optionValues map[string]optionValue
+
+type option struct {
+	name  string
+	short string // ex) ts of tabstop
+	kind  int    // ex) numberOption
+	scope int    // ex) bufferScope
+	def   optionValue
+	// list is true for a comma separated list, += and -= add and remove an item, ex) matchpairs
+	list bool
+	// check returns an error when the option cannot have the value v
+	check func(v optionValue) error
+	// changed makes the editor use the new value as soon as it is set
+	changed func(w *Window)
+}
+
+var options []option
+
+func init() {
+	// sorted by name, :set all lists them in this order
+	options = []option{
+		{name: "autoindent", short: "ai", kind: boolOption, scope: bufferScope},
+		{name: "backup", short: "bk", kind: boolOption, scope: globalScope},
+		{name: "backupdir", short: "bdir", kind: stringOption, scope: globalScope, def: optionValue{s: ".,~/tmp,~/"}, list: true},
+		{name: "binary", short: "bin", kind: boolOption, scope: bufferScope, changed: (*Window).fileFormatChanged},
+		{name: "bomb", kind: boolOption, scope: bufferScope, changed: (*Window).fileFormatChanged},
+		{name: "endofline", short: "eol", kind: boolOption, scope: bufferScope, def: optionValue{b: true}, changed: (*Window).fileFormatChanged},
+		{name: "expandtab", short: "et", kind: boolOption, scope: bufferScope},
+		{name: "fileformat", short: "ff", kind: stringOption, scope: bufferScope, def: optionValue{s: "unix"}, check: checkFileFormat, changed: (*Window).fileFormatChanged},
+		{name: "ignorecase", short: "ic", kind: boolOption, scope: globalScope},
+		{name: "mapleader", kind: stringOption, scope: globalScope, def: optionValue{s: "\\"}},
+		{name: "matchpairs", short: "mps", kind: stringOption, scope: bufferScope, def: optionValue{s: defaultMatchPairs}, list: true, check: checkMatchPairs},
+		{name: "mouse", kind: stringOption, scope: globalScope, check: checkMouse, changed: (*Window).mouseChanged},
+		{name: "number", short: "nu", kind: boolOption, scope: windowScope},
+		{name: "shiftround", short: "sr", kind: boolOption, scope: globalScope},
+		{name: "shiftwidth", short: "sw", kind: numberOption, scope: bufferScope, def: optionValue{n: 8}, check: checkNotNegative},
+		{name: "smartcase", short: "scs", kind: boolOption, scope: globalScope},
+		{name: "smartindent", short: "si", kind: boolOption, scope: bufferScope},
+		{name: "softtabstop", short: "sts", kind: numberOption, scope: bufferScope},
+		{name: "tabstop", short: "ts", kind: numberOption, scope: bufferScope, def: optionValue{n: 8}, check: checkTabStop},
+		{name: "timeoutlen", short: "tm", kind: numberOption, scope: globalScope, def: optionValue{n: 1000}, check: checkNotNegative},
+		{name: "ttimeoutlen", short: "ttm", kind: numberOption, scope: globalScope, def: optionValue{n: 50}, check: checkNotNegative},
+		{name: "wrap", kind: boolOption, scope: windowScope, def: optionValue{b: true}, changed: (*Window).wrapChanged},
+		{name: "writebackup", short: "wb", kind: boolOption, scope: globalScope, def: optionValue{b: true}},
+	}
+}
+
+// findOption returns the option of a name or a short name.
+func findOption(name string) *option {
+	for i, o := range options {
+		if o.name == name || o.short != "" && o.short == name {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+func checkMatchPairs(v optionValue) error {
+	_, err := parseMatchPairs(v.s)
+	return err
+}
+
+func checkMouse(v optionValue) error {
+	if strings.Trim(v.s, "anvich") != "" {
+		return fmt.Errorf("E474: Invalid argument: mouse=%s", v.s)
+	}
+	return nil
+}
+
+func checkPositive(v optionValue) error {
+	if v.n <= 0 {
+		return errors.New("E487: Argument must be positive")
+	}
+	return nil
+}
+
+// checkNotNegative is for the numbers whose 0 means another option, ex) shiftwidth=0 is tabstop
+func checkNotNegative(v optionValue) error {
+	if v.n < 0 {
+		return errors.New("E487: Argument must be positive")
+	}
+	return nil
+}
+
+// maxTabStop is the widest tab, a tab is drawn as that many cells at most.
+const maxTabStop = 9999
+
+func checkTabStop(v optionValue) error {
+	if v.n > maxTabStop {
+		return fmt.Errorf("E475: Invalid argument: tabstop=%d", v.n)
+	}
+	return checkPositive(v)
+}
+
+func (w *Window) mouseChanged() {
+	if w.Terminal != nil {
+		w.Terminal.SetMouse(w.stringOption("mouse") != "")
+	}
+}
+
+func (w *Window) wrapChanged() {
+	w.leftCol = 0
+}
+
+// localOptions returns the local values of the options of a scope, nil for global options.
+func (w *Window) localOptions(scope int) optionValues {
+	switch scope {
+	case bufferScope:
+		if w.bufferOptions == nil {
+			w.bufferOptions = make(optionValues)
+		}
+		return w.bufferOptions
+	case windowScope:
+		if w.windowOptions == nil {
+			w.windowOptions = make(optionValues)
+		}
+		return w.windowOptions
+	}
+	return nil
+}
+
+// optionValue returns the value of the option o for the buffer and the window: the local value,
+// or else the global one.
+func (w *Window) optionValue(o *option) optionValue {
+	if v, ok := w.localOptions(o.scope)[o.name]; ok {
+		return v
+	}
+	if v, ok := w.options[o.name]; ok {
+		return v
+	}
+	return o.def
+}
+
+func (w *Window) boolOption(name string) bool {
+	return w.optionValue(findOption(name)).b
+}
+
+func (w *Window) numberOption(name string) int {
+	return w.optionValue(findOption(name)).n
+}
+
+func (w *Window) stringOption(name string) string {
+	return w.optionValue(findOption(name)).s
+}
+
+// setOption sets the option o to v, only the local value when local.
+func (w *Window) setOption(o *option, v optionValue, local bool) error {
+	if o.check != nil {
+		if err := o.check(v); err != nil {
+			return err
+		}
+	}
+	if !local || o.scope == globalScope {
+		if w.options == nil {
+			w.options = make(optionValues)
+		}
+		w.options[o.name] = v
+	}
+	if o.scope != globalScope {
+		w.localOptions(o.scope)[o.name] = v
+	}
+	if o.changed != nil {
+		o.changed(w)
+	}
+	return nil
+}
+
+// formatOption returns how :set shows the option, ex) tabstop=8 or nonumber
+func formatOption(o *option, v optionValue) string {
+	switch o.kind {
+	case boolOption:
+		if v.b {
+			return o.name
+		}
+		return "no" + o.name
+	case numberOption:
+		return o.name + "=" + strconv.Itoa(v.n)
+	}
+	return o.name + "=" + v.s
+}
+
+// setCommand is :set, it sets options and shows their values, ex) :set ts=4 nowrap mouse?
+// Without arguments it lists the options changed, :set all lists them all.
+func (w *Window) setCommand(c exArgs) error {
+	return w.setOptions(c.args, false)
+}
+
+// setlocalCommand is :setlocal, it sets only the values of the buffer or the window.
+func (w *Window) setlocalCommand(c exArgs) error {
+	return w.setOptions(c.args, true)
+}
+
+func (w *Window) setOptions(args string, local bool) error {
+	if args == "" || args == "all" {
+		out := []string{"--- Options ---"}
+		for i := range options {
+			o := &options[i]
+			v := w.optionValue(o)
+			if args == "all" || v != o.def {
+				out = append(out, "  "+formatOption(o, v))
+			}
+		}
+		w.showMessage(strings.Join(out, "\n"), "")
+		return nil
+	}
+	var shown []string
+	for _, arg := range strings.Fields(args) {
+		s, err := w.setArgument(arg, local)
+		if err != nil {
+			return err
+		}
+		if s != "" {
+			shown = append(shown, "  "+s)
+		}
+	}
+	if len(shown) > 0 {
+		w.showMessage(strings.Join(shown, "\n"), "")
+	}
+	return nil
+}
+
+// setArgument runs one argument of :set and returns the value to show, if any.
+// ex) number, nonumber, invnumber, number!, number?, number&, ts=4, ts+=2, mps-=<:>
+func (w *Window) setArgument(arg string, local bool) (string, error) {
+	end := 0
+	for end < len(arg) && 'a' <= arg[end] && arg[end] <= 'z' {
+		end++
+	}
+	name, rest := arg[:end], arg[end:]
+	o := findOption(name)
+	value := func() optionValue { return w.optionValue(o) }
+	if o == nil {
+		// ex) nonumber or invnumber, nomouse empties a string option
+		for _, prefix := range []string{"no", "inv"} {
+			p := findOption(strings.TrimPrefix(name, prefix))
+			if !strings.HasPrefix(name, prefix) || p == nil || p.kind == numberOption || p.kind == stringOption && prefix == "inv" {
+				continue
+			}
+			if rest != "" {
+				return "", fmt.Errorf("E474: Invalid argument: %s", arg)
+			}
+			v := w.optionValue(p)
+			v.b = prefix == "inv" && !v.b
+			v.s = ""
+			return "", w.setOption(p, v, local)
+		}
+		return "", fmt.Errorf("E518: Unknown option: %s", name)
+	}
+	switch rest {
+	case "":
+		if o.kind != boolOption {
+			return formatOption(o, value()), nil
+		}
+		return "", w.setOption(o, optionValue{b: true}, local)
+	case "?":
+		return formatOption(o, value()), nil
+	case "!":
+		if o.kind != boolOption {
+			return "", fmt.Errorf("E474: Invalid argument: %s", arg)
+		}
+		return "", w.setOption(o, optionValue{b: !value().b}, local)
+	case "&":
+		return "", w.setOption(o, o.def, local)
+	}
+	op := rest[:1]
+	if strings.HasPrefix(rest, "+=") || strings.HasPrefix(rest, "-=") || strings.HasPrefix(rest, "^=") {
+		op = rest[:2]
+	} else if op != "=" && op != ":" {
+		return "", fmt.Errorf("E474: Invalid argument: %s", arg)
+	}
+	text := rest[len(op):]
+	if o.kind == boolOption {
+		return "", fmt.Errorf("E474: Invalid argument: %s", arg)
+	}
+	v := value()
+	if o.kind == numberOption {
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return "", fmt.Errorf("E521: Number required after =: %s", arg)
+		}
+		switch op {
+		case "+=":
+			v.n += n
+		case "-=":
+			v.n -= n
+		case "^=":
+			v.n *= n
+		default:
+			v.n = n
+		}
+		return "", w.setOption(o, v, local)
+	}
+	switch op {
+	case "+=":
+		v.s = joinOption(o, v.s, text)
+	case "^=":
+		v.s = joinOption(o, text, v.s)
+	case "-=":
+		v.s = removeOption(o, v.s, text)
+	default:
+		v.s = text
+	}
+	return "", w.setOption(o, v, local)
+}
+
+// joinOption returns the string value a followed by b, separated by a comma for a list.
+func joinOption(o *option, a, b string) string {
+	if o.list && a != "" && b != "" {
+		return a + "," + b
+	}
+	return a + b
+}
+
+// removeOption returns the string value s without the item, or without its first occurrence
+// for an option that is not a list.
+func removeOption(o *option, s, item string) string {
+	if !o.list {
+		return strings.Replace(s, item, "", 1)
+	}
+	var items []string
+	for _, i := range strings.Split(s, ",") {
+		if i != item {
+			items = append(items, i)
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// completeOptions returns the options that start with word, or the value of the option of word after =,
+// ex) mouse=a for mouse=
+func (w *Window) completeOptions(word string) []string {
+	if i := strings.IndexByte(word, '='); i >= 0 {
+		o := findOption(word[:i])
+		if o == nil || o.kind == boolOption {
+			return nil
+		}
+		value := strings.TrimPrefix(formatOption(o, w.optionValue(o)), o.name+"=")
+		if !strings.HasPrefix(value, word[i+1:]) {
+			return nil
+		}
+		return []string{word[:i+1] + value}
+	}
+	var names []string
+	for _, o := range options {
+		names = append(names, o.name)
+		if o.kind == boolOption {
+			names = append(names, "no"+o.name)
+		}
+	}
+	sort.Strings(names)
+	var found []string
+	for _, name := range names {
+		if strings.HasPrefix(name, word) {
+			found = append(found, name)
+		}
+	}
+	return found
+}
@@ -0,0 +1,215 @@
+package window
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWindow_setCommand(t *testing.T) {
+	tests := []struct {
+		name        string
+		commands    []string
+		option      string
+		want        optionValue
+		wantErr     string
+		wantMessage string
+	}{
+		{name: "number", commands: []string{"set ts=4"}, option: "tabstop", want: optionValue{n: 4}},
+		{name: "add to a number", commands: []string{"set ts=4 ts+=2"}, option: "tabstop", want: optionValue{n: 6}},
+		{name: "multiply a number", commands: []string{"set ts^=2"}, option: "tabstop", want: optionValue{n: 16}},
+		{name: "not a number", commands: []string{"set ts=x"}, option: "tabstop", want: optionValue{n: 8}, wantErr: "E521: Number required after =: ts=x"},
+		{name: "not positive", commands: []string{"set ts=0"}, option: "tabstop", want: optionValue{n: 8}, wantErr: "E487: Argument must be positive"},
+		{name: "widest tab", commands: []string{"set ts=9999"}, option: "tabstop", want: optionValue{n: 9999}},
+		{name: "too wide a tab", commands: []string{"set ts=10000"}, option: "tabstop", want: optionValue{n: 8}, wantErr: "E475: Invalid argument: tabstop=10000"},
+		{name: "negative", commands: []string{"set tm=-1"}, option: "timeoutlen", want: optionValue{n: 1000}, wantErr: "E487: Argument must be positive"},
+		{name: "bool", commands: []string{"set nu"}, option: "number", want: optionValue{b: true}},
+		{name: "no", commands: []string{"set nowrap"}, option: "wrap", want: optionValue{}},
+		{name: "inv", commands: []string{"set invwrap invwrap invwrap"}, option: "wrap", want: optionValue{}},
+		{name: "toggle", commands: []string{"set et!"}, option: "expandtab", want: optionValue{b: true}},
+		{name: "value of a bool", commands: []string{"set et=1"}, option: "expandtab", want: optionValue{}, wantErr: "E474: Invalid argument: et=1"},
+		{name: "default", commands: []string{"set ts=2", "set ts&"}, option: "tabstop", want: optionValue{n: 8}},
+		{name: "add to a list", commands: []string{"set mps+=<:>"}, option: "matchpairs", want: optionValue{s: "(:),{:},[:],<:>"}},
+		{name: "prepend to a list", commands: []string{"set mps^=<:>"}, option: "matchpairs", want: optionValue{s: "<:>,(:),{:},[:]"}},
+		{name: "remove from a list", commands: []string{"set mps-={:}"}, option: "matchpairs", want: optionValue{s: "(:),[:]"}},
+		{name: "invalid", commands: []string{"set mps=("}, option: "matchpairs", want: optionValue{s: defaultMatchPairs}, wantErr: "E474: Invalid argument: matchpairs=("},
+		{name: "nomouse", commands: []string{"set mouse=a", "set nomouse"}, option: "mouse", want: optionValue{}},
+		{name: "unknown", commands: []string{"set foo"}, option: "tabstop", want: optionValue{n: 8}, wantErr: "E518: Unknown option: foo"},
+		{name: "show", commands: []string{"set ts=4", "set ts? nu? mouse"}, option: "tabstop", want: optionValue{n: 4}, wantMessage: "  tabstop=4\n  nonumber\n  mouse="},
+		{name: "show changed", commands: []string{"set ts=4 nu", "set"}, option: "tabstop", want: optionValue{n: 4}, wantMessage: "--- Options ---\n  number\n  tabstop=4"},
+		{name: "show all", commands: []string{"set all"}, option: "tabstop", want: optionValue{n: 8}, wantMessage: strings.Join([]string{
+			"--- Options ---",
+			"  noautoindent",
+			"  nobackup",
+			"  backupdir=.,~/tmp,~/",
+			"  nobinary",
+			"  nobomb",
+			"  endofline",
+			"  noexpandtab",
+			"  fileformat=unix",
+			"  noignorecase",
+			"  mapleader=\\",
+			"  matchpairs=(:),{:},[:]",
+			"  mouse=",
+			"  nonumber",
+			"  noshiftround",
+			"  shiftwidth=8",
+			"  nosmartcase",
+			"  nosmartindent",
+			"  softtabstop=0",
+			"  tabstop=8",
+			"  timeoutlen=1000",
+			"  ttimeoutlen=50",
+			"  wrap",
+			"  writebackup",
+		}, "\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(80, 24))
+			var err error
+			for _, c := range tt.commands {
+				if err = w.ExecuteLine(c); err != nil {
+					break
+				}
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("got: %v, want: %s", err, tt.wantErr)
+			}
+			if got := w.optionValue(findOption(tt.option)); got != tt.want {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			if tt.wantMessage != "" && w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_setlocalCommand(t *testing.T) {
+	w := NewWindow(NewSimTerminal(80, 24))
+	w.FileContents = [][]byte{[]byte("a")}
+	for _, c := range []string{"set ts=4", "setl ts=2 mouse=a"} {
+		if err := w.ExecuteLine(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := w.numberOption("tabstop"); got != 2 {
+		t.Errorf("got: %d, want: 2 in the buffer", got)
+	}
+	if got := w.stringOption("mouse"); got != "a" {
+		t.Errorf("got: %q, want: a global option set by :setlocal", got)
+	}
+	// another buffer starts with the global value
+	b := w.takeBuffer()
+	if got := w.numberOption("tabstop"); got != 4 {
+		t.Errorf("got: %d, want: 4 in another buffer", got)
+	}
+	w.setBuffer(b)
+	if got := w.numberOption("tabstop"); got != 2 {
+		t.Errorf("got: %d, want: 2 back in the buffer", got)
+	}
+}
+
+func TestWindow_PrintFileContentsOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		lines    []string
+		position Position
+		wantRows []string
+		wantRow  int
+		wantCol  int
+	}{
+		{name: "tabstop", commands: []string{"set ts=4"}, lines: []string{"\ta", "ab\tc"}, position: Position{X: 2, Y: 1}, wantRows: []string{"    a", "ab  c", "", ""}, wantRow: 0, wantCol: 4},
+		{name: "number", commands: []string{"set nu"}, lines: []string{"a", "b"}, position: Position{X: 1, Y: 2}, wantRows: []string{"  1 a", "  2 b", "", ""}, wantRow: 1, wantCol: 4},
+		{name: "wrap", lines: []string{"abcdefghijklm", "n"}, position: Position{X: 12, Y: 1}, wantRows: []string{"abcdefghij", "klm", "n", ""}, wantRow: 1, wantCol: 1},
+		{name: "wrap with number", commands: []string{"set nu"}, lines: []string{"abcdefghijklm"}, position: Position{X: 1, Y: 1}, wantRows: []string{"  1 abcdef", "    ghijkl", "    m", ""}, wantRow: 0, wantCol: 4},
+		{name: "nowrap", commands: []string{"set nowrap"}, lines: []string{"abcdefghijklm", "n"}, position: Position{X: 12, Y: 1}, wantRows: []string{"cdefghijkl", "", "", ""}, wantRow: 0, wantCol: 9},
+		{name: "wrapped lines scroll", lines: []string{"abcdefghijklmnopqrst", "u", "v", "w"}, position: Position{X: 1, Y: 3}, wantRows: []string{"abcdefghij", "klmnopqrst", "u", "v"}, wantRow: 3, wantCol: 0},
+		{name: "scroll past a wrapped line", lines: []string{"abcdefghijklmnopqrst", "u", "v", "w"}, position: Position{X: 1, Y: 4}, wantRows: []string{"u", "v", "w", ""}, wantRow: 2, wantCol: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(10, 5)
+			w := NewWindow(term)
+			w.SetSize()
+			for _, l := range tt.lines {
+				w.FileContents = append(w.FileContents, []byte(l))
+			}
+			for _, c := range tt.commands {
+				if err := w.ExecuteLine(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			w.position = tt.position
+			w.PrintFileContents()
+			var rows []string
+			for row := 0; row < 4; row++ {
+				rows = append(rows, term.Row(row))
+			}
+			if !reflect.DeepEqual(rows, tt.wantRows) {
+				t.Errorf("got: %q, want: %q", rows, tt.wantRows)
+			}
+			if row, col := term.Cursor(); row != tt.wantRow || col != tt.wantCol {
+				t.Errorf("got: cursor %d, %d, want: %d, %d", row, col, tt.wantRow, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestWindow_positionAtOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		row, col int
+		want     Position
+	}{
+		{name: "wrapped row", row: 1, col: 2, want: Position{X: 13, Y: 1}},
+		{name: "after a wrapped line", row: 2, col: 0, want: Position{X: 1, Y: 2}},
+		{name: "number", commands: []string{"set nu"}, row: 0, col: 5, want: Position{X: 2, Y: 1}},
+		{name: "on the number", commands: []string{"set nu"}, row: 0, col: 1, want: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(10, 5))
+			w.SetSize()
+			w.FileContents = [][]byte{[]byte("abcdefghijklm"), []byte("n")}
+			for _, c := range tt.commands {
+				if err := w.ExecuteLine(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got, ok := w.positionAt(tt.row, tt.col); !ok || got != tt.want {
+				t.Errorf("got: %+v, %v, want: %+v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow_optionsTakeEffect(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		input        string
+		wantLines    []string
+		wantPosition Position
+	}{
+		{name: "tab", lines: []string{"ab"}, input: "A\t\033", wantLines: []string{"ab\t"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "expandtab", lines: []string{"ab"}, input: ":set et ts=4\rA\t\033", wantLines: []string{"ab  "}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "search", lines: []string{"Foo", "foo"}, input: "/foo\r", wantLines: []string{"Foo", "foo"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "ignorecase", lines: []string{"x", "Foo", "foo"}, input: ":set ic\r/foo\r", wantLines: []string{"x", "Foo", "foo"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "smartcase", lines: []string{"x", "foo", "Foo"}, input: ":set ic scs\r/Foo\r", wantLines: []string{"x", "foo", "Foo"}, wantPosition: Position{X: 1, Y: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, Position{X: 1, Y: 1}, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+		})
+	}
+}
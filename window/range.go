@@ -0,0 +1,131 @@
+package window
+
+import (
+	"errors"
+	"strconv"
+)
+
+// lineRange is the lines (1-indexed) a command line works on, ex) 1,5 of :1,5normal x
+// Without a range it is the line of the cursor.
+type lineRange struct {
+	first, last int
+	given       bool
+}
+
+var errInvalidRange = errors.New("E16: Invalid range")
+
+// parseRange reads the range at the start of a command line and returns the rest of it.
+// Addresses are a line number, . for the cursor line, $ for the last line or 'x for the line of a mark,
+// each with offsets, ex) .,$-1; % is every line. After ; the cursor line is the first address.
+func (w *Window) parseRange(s string) (lineRange, string, error) {
+	cur := w.position.Y
+	r := lineRange{first: cur, last: cur}
+	if len(s) > 0 && s[0] == '%' {
+		r = lineRange{first: 1, last: len(w.FileContents), given: true}
+		if r.last == 0 {
+			r.last = 1
+		}
+		return r, s[1:], nil
+	}
+	var addresses []int
+	for {
+		n, rest, ok, err := w.parseAddress(s, cur)
+		if err != nil {
+			return r, s, err
+		}
+		s = rest
+		if s == "" || s[0] != ',' && s[0] != ';' {
+			if ok {
+				addresses = append(addresses, n)
+			}
+			break
+		}
+		// a missing address is the cursor line, ex) :,5
+		addresses = append(addresses, n)
+		if s[0] == ';' {
+			cur = n
+		}
+		s = s[1:]
+	}
+	switch len(addresses) {
+	case 0:
+		return r, s, nil
+	case 1:
+		r.first, r.last = addresses[0], addresses[0]
+	default:
+		r.first, r.last = addresses[len(addresses)-2], addresses[len(addresses)-1]
+	}
+	r.given = true
+	if r.first > r.last {
+		r.first, r.last = r.last, r.first
+	}
+	lines := len(w.FileContents)
+	if lines == 0 {
+		lines = 1
+	}
+	if r.first < 0 || r.last > lines {
+		return r, s, errInvalidRange
+	}
+	if r.first == 0 {
+		r.first = 1
+	}
+	if r.last == 0 {
+		r.last = 1
+	}
+	return r, s, nil
+}
+
+// parseAddress reads one address from s, it is cur when s has none.
+func (w *Window) parseAddress(s string, cur int) (n int, rest string, ok bool, err error) {
+	n = cur
+	switch {
+	case s == "":
+		return n, s, false, nil
+	case s[0] == '.':
+		s, ok = s[1:], true
+	case s[0] == '$':
+		n, s, ok = len(w.FileContents), s[1:], true
+	case s[0] == '\'':
+		// the line of a mark, ex) 'a or '< of a selection
+		if len(s) < 2 {
+			return n, s, false, errMarkNotSet
+		}
+		p, err := w.markPosition(s[1])
+		if err != nil {
+			return n, s, false, err
+		}
+		n, s, ok = p.Y, s[2:], true
+	case isDigit(s[0]):
+		end := 0
+		for end < len(s) && isDigit(s[end]) {
+			end++
+		}
+		n, err = strconv.Atoi(s[:end])
+		if err != nil {
+			return n, s, false, errInvalidRange
+		}
+		s, ok = s[end:], true
+	}
+	// offsets, ex) +2 or - which is -1
+	for len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		end := 1
+		for end < len(s) && isDigit(s[end]) {
+			end++
+		}
+		offset := 1
+		if end > 1 {
+			offset, _ = strconv.Atoi(s[1:end])
+		}
+		n += sign * offset
+		s, ok = s[end:], true
+	}
+	return n, s, ok, nil
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
@@ -0,0 +1,84 @@
+package window
+
+import "testing"
+
+func TestWindow_parseRange(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		want     lineRange
+		wantRest string
+		wantErr  string
+	}{
+		{name: "no range", input: "normal x", want: lineRange{first: 2, last: 2}, wantRest: "normal x"},
+		{name: "every line", input: "%normal x", want: lineRange{first: 1, last: 5, given: true}, wantRest: "normal x"},
+		{name: "one line", input: "3", want: lineRange{first: 3, last: 3, given: true}},
+		{name: "line numbers", input: "1,3d", want: lineRange{first: 1, last: 3, given: true}, wantRest: "d"},
+		{name: "cursor line to last line", input: ".,$", want: lineRange{first: 2, last: 5, given: true}},
+		{name: "offsets", input: ".+1,$-", want: lineRange{first: 3, last: 4, given: true}},
+		{name: "offset from the cursor line", input: "+2", want: lineRange{first: 4, last: 4, given: true}},
+		{name: "missing address", input: ",4", want: lineRange{first: 2, last: 4, given: true}},
+		{name: "semicolon sets the cursor line", input: "4;+1", want: lineRange{first: 4, last: 5, given: true}},
+		{name: "backwards", input: "4,1", want: lineRange{first: 1, last: 4, given: true}},
+		{name: "line zero", input: "0", want: lineRange{first: 1, last: 1, given: true}},
+		{name: "after the last line", input: "1,6", wantErr: "E16: Invalid range"},
+		{name: "before the first line", input: "-3", wantErr: "E16: Invalid range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			for i := 0; i < 5; i++ {
+				w.FileContents = append(w.FileContents, []byte("x"))
+			}
+			w.position = Position{X: 1, Y: 2}
+			got, rest, err := w.parseRange(tt.input)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got: %+v, want: %+v", got, tt.want)
+			}
+			if rest != tt.wantRest {
+				t.Errorf("got: %q, want: %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestWindow_ExecuteLine_Range(t *testing.T) {
+	tests := []struct {
+		name         string
+		line         string
+		wantPosition Position
+		wantErr      string
+	}{
+		{name: "jump to the line", line: "3", wantPosition: Position{X: 3, Y: 3}},
+		{name: "jump to the last line of the range", line: "1,$-1", wantPosition: Position{X: 1, Y: 4}},
+		{name: "no range allowed", line: "1,2set mouse=a", wantPosition: Position{X: 1, Y: 1}, wantErr: "E481: No range allowed"},
+		{name: "invalid range", line: "9", wantPosition: Position{X: 1, Y: 1}, wantErr: "E16: Invalid range"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := NewWindow(NewSimTerminal(20, 4))
+			w.FileContents = [][]byte{[]byte("1"), []byte("2"), []byte("  3"), []byte("4"), []byte("5")}
+			w.position = Position{X: 1, Y: 1}
+			err := w.ExecuteLine(tt.line)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("got: %v, want: %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+		})
+	}
+}
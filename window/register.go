@@ -0,0 +1,169 @@
+package window
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// register is the text of a register, ex) "a
+type register struct {
+	text     []byte     // lines are separated by \n
+	linewise bool       // the text is whole lines, ex) yanked with yy
+	keys     []KeyEvent // the keys of a macro recorded in it, nil when the text was not recorded
+}
+
+// isRegister reports whether c names a register: " is the unnamed register, 0 holds the last yank,
+// 1 the last deletion of lines and - of less than a line, a to z are for the user
+// and A to Z append to them. Nothing is kept in the black hole register _.
+func isRegister(c byte) bool {
+	return c == '"' || c == '-' || c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// setRegister keeps text yanked, or deleted when yank is false, in the register name,
+// 0 when none was given. The unnamed register gets it too.
+func (w *Window) setRegister(name byte, r register, yank bool) {
+	if w.registers == nil {
+		w.registers = make(map[byte]register)
+	}
+	r.text = append([]byte(nil), r.text...)
+	switch {
+	case name == '_':
+		return
+	case 'A' <= name && name <= 'Z':
+		name += 'a' - 'A'
+		if old, ok := w.registers[name]; ok {
+			sep := []byte{}
+			if old.linewise || r.linewise {
+				sep = []byte("\n")
+			}
+			r = register{text: concat(old.text, sep, r.text), linewise: old.linewise || r.linewise}
+		}
+	case name == 0 || name == '"':
+		switch {
+		case yank:
+			name = '0'
+		case r.linewise || bytes.IndexByte(r.text, '\n') >= 0:
+			for i := byte('9'); i > '1'; i-- {
+				if old, ok := w.registers[i-1]; ok {
+					w.registers[i] = old
+				}
+			}
+			name = '1'
+		default:
+			name = '-'
+		}
+	}
+	w.registers[name] = r
+	w.registers['"'] = r
+}
+
+// getRegister returns the register name, the unnamed one when it is 0.
+func (w *Window) getRegister(name byte) (register, bool) {
+	if name == 0 {
+		name = '"'
+	}
+	if 'A' <= name && name <= 'Z' {
+		name += 'a' - 'A'
+	}
+	r, ok := w.registers[name]
+	return r, ok
+}
+
+// textBetween returns the text from from up to to, which is not included.
+func (w *Window) textBetween(from, to Position) []byte {
+	if from.Y == to.Y {
+		return w.FileContents[from.Y-1][from.X-1 : to.X-1]
+	}
+	parts := [][]byte{w.FileContents[from.Y-1][from.X-1:]}
+	parts = append(parts, w.FileContents[from.Y:to.Y-1]...)
+	parts = append(parts, w.FileContents[to.Y-1][:to.X-1])
+	return bytes.Join(parts, []byte("\n"))
+}
+
+// linesBetween returns the lines from first to last as the text of a linewise register.
+func (w *Window) linesBetween(first, last int) register {
+	return register{text: bytes.Join(w.FileContents[first-1:last], []byte("\n")), linewise: true}
+}
+
+// putCommand is p and P, they put the text of the register count times after or before the cursor.
+// Lines are put below or above the line of the cursor.
+func (w *Window) putCommand(k normalKeys) {
+	r, ok := w.getRegister(k.register)
+	if !ok {
+		name := k.register
+		if name == 0 {
+			name = '"'
+		}
+		w.showError(fmt.Errorf("E353: Nothing in register %c", name))
+		return
+	}
+	w.beginChange()
+	lines := bytes.Split(r.text, []byte("\n"))
+	if r.linewise {
+		var put [][]byte
+		for i := 0; i < k.times(); i++ {
+			put = append(put, lines...)
+		}
+		y := w.position.Y
+		if k.name == "P" {
+			y--
+		}
+		w.insertLines(y, put)
+		w.position = Position{X: w.firstNonBlank(y + 1), Y: y + 1}
+		return
+	}
+	text := bytes.Repeat(r.text, k.times())
+	pos := w.position
+	if line := w.FileContents[pos.Y-1]; k.name == "p" && len(line) > 0 {
+		pos.X = runesAfter(line, pos.X-1, 1) + 1
+	}
+	end := w.insertParts(pos, bytes.Split(text, []byte("\n")))
+	if len(lines) > 1 {
+		w.position = pos
+		return
+	}
+	// on the last character put
+	w.position = end
+	w.position.X = runesBefore(w.FileContents[end.Y-1], end.X-1, 1) + 1
+}
+
+// registersCommand is :registers, it shows the registers.
+func (w *Window) registersCommand(c exArgs) error {
+	out := []string{"Type Name Content"}
+	for _, name := range []byte("\"0123456789-abcdefghijklmnopqrstuvwxyz") {
+		r, ok := w.registers[name]
+		if !ok || c.args != "" && strings.IndexByte(c.args, name) < 0 {
+			continue
+		}
+		kind := "c"
+		if r.linewise {
+			kind = "l"
+		}
+		out = append(out, fmt.Sprintf("  %s  \"%c   %s", kind, name, printable(r.text, r.linewise)))
+	}
+	w.showMessage(strings.Join(out, "\n"), "")
+	return nil
+}
+
+// printable shows the control characters of text as ^X, ex) ^[ for Escape, and line breaks as ^J.
+func printable(text []byte, linewise bool) string {
+	var b strings.Builder
+	for _, r := range string(text) {
+		switch {
+		case r == '\n':
+			b.WriteString("^J")
+		case r < ' ':
+			b.WriteByte('^')
+			b.WriteRune(r + '@')
+		case r == 0x7f:
+			b.WriteString("^?")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	if linewise {
+		b.WriteString("^J")
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_YankAndPut(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "yy and p", lines: []string{"  a", "b"}, position: Position{X: 1, Y: 1}, input: "yyp", wantLines: []string{"  a", "  a", "b"}, wantPosition: Position{X: 3, Y: 2}},
+		{name: "yy with a count and P", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 2}, input: "2yyP", wantLines: []string{"1", "2", "3", "2", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "Y and p with a count", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "Y2p", wantLines: []string{"1", "1", "1", "2"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "yw and P", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "ywP", wantLines: []string{"foo foo bar"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "yank moves to the start", lines: []string{"foo bar"}, position: Position{X: 5, Y: 1}, input: "yb", wantLines: []string{"foo bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "yk moves up", lines: []string{"1", "2"}, position: Position{X: 1, Y: 2}, input: "yk", wantLines: []string{"1", "2"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "p with a count", lines: []string{"ab"}, position: Position{X: 1, Y: 1}, input: "yl3p", wantLines: []string{"aaaab"}, wantPosition: Position{X: 4, Y: 1}},
+		{name: "p on an empty line", lines: []string{"ab", ""}, position: Position{X: 1, Y: 1}, input: "yljp", wantLines: []string{"ab", "a"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "x and p", lines: []string{"ab"}, position: Position{X: 1, Y: 1}, input: "xp", wantLines: []string{"ba"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "dd and p", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "ddp", wantLines: []string{"2", "1", "3"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "cw and P", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "cwx\033P", wantLines: []string{"foox bar"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "named register", lines: []string{"1", "2", "3"}, position: Position{X: 1, Y: 1}, input: "\"ayyjdd\"ap", wantLines: []string{"1", "3", "1"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "upper case register appends", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "\"ayyj\"Ayy\"ap", wantLines: []string{"1", "2", "1", "2"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "count after the register", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "\"a2yyG\"ap", wantLines: []string{"1", "2", "1", "2"}, wantPosition: Position{X: 1, Y: 3}},
+		{name: "yank register", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "yyjdd\"0p", wantLines: []string{"1", "1"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "black hole register", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "yyj\"_ddp", wantLines: []string{"1", "1"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "nothing in the register", lines: []string{"1"}, position: Position{X: 1, Y: 1}, input: "\"bp", wantLines: []string{"1"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E353: Nothing in register b"},
+		{name: "put is undone at once", lines: []string{"1", "2"}, position: Position{X: 1, Y: 1}, input: "yy3pu", wantLines: []string{"1", "2"}, wantPosition: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestWindow_setRegister(t *testing.T) {
+	w := NewWindow(NewSimTerminal(20, 4))
+	w.setRegister(0, register{text: []byte("x")}, false)
+	w.setRegister(0, register{text: []byte("line"), linewise: true}, false)
+	w.setRegister(0, register{text: []byte("a\nb")}, false)
+	w.setRegister(0, register{text: []byte("yanked")}, true)
+	w.setRegister('b', register{text: []byte("b")}, true)
+	w.setRegister('B', register{text: []byte("c"), linewise: true}, true)
+	want := map[byte]register{
+		'"': {text: []byte("b\nc"), linewise: true},
+		'-': {text: []byte("x")},
+		'0': {text: []byte("yanked")},
+		'1': {text: []byte("a\nb")},
+		'2': {text: []byte("line"), linewise: true},
+		'b': {text: []byte("b\nc"), linewise: true},
+	}
+	if !reflect.DeepEqual(w.registers, want) {
+		t.Errorf("got: %+v, want: %+v", w.registers, want)
+	}
+}
+
+func TestWindow_registersCommand(t *testing.T) {
+	w := NewWindow(NewSimTerminal(20, 4))
+	w.setRegister('a', register{text: []byte("A!\033")}, true)
+	w.setRegister('b', register{text: []byte("x"), linewise: true}, true)
+	if err := w.ExecuteLine("registers a"); err != nil {
+		t.Fatal(err)
+	}
+	want := "Type Name Content\n  c  \"a   A!^["
+	if w.message != want {
+		t.Errorf("got: %q, want: %q", w.message, want)
+	}
+}
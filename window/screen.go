@@ -0,0 +1,316 @@
+package window
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"gim/syntax"
+	"gim/terminfo"
+
+	runewidth "github.com/mattn/go-runewidth"
+)
+
+// Cell is one character cell of the terminal.
+// The cell on the right of a wide character has Ch 0.
+type Cell struct {
+	Ch    rune
+	Style syntax.Style
+}
+
+var blankCell = Cell{Ch: ' '}
+
+// Screen keeps a copy of what the terminal shows. A frame is drawn into a back buffer
+// and Flush writes only the cells that differ from the terminal.
+type Screen struct {
+	Output    io.Writer
+	ColorMode syntax.ColorMode
+	// SyncUpdate wraps each frame in the synchronized update sequences,
+	// so the terminal shows it at once.
+	SyncUpdate bool
+	// Caps has the escape sequences of the terminal.
+	Caps *terminfo.Terminfo
+
+	width, height int
+	front, back   [][]Cell
+	// valid is false when the terminal contents are unknown, ex) after a resize.
+	valid      bool
+	cursorRow  int
+	cursorCol  int
+	shownRow   int
+	shownCol   int
+	shownStyle syntax.Style
+}
+
+func NewScreen(output io.Writer, width, height int) *Screen {
+	s := &Screen{Output: output, Caps: terminfo.Xterm()}
+	s.Resize(width, height)
+	return s
+}
+
+// Resize changes the size of the screen and redraws everything on the next Flush.
+func (s *Screen) Resize(width, height int) {
+	if width < 0 {
+		width = 0
+	}
+	if height < 0 {
+		height = 0
+	}
+	s.width, s.height = width, height
+	s.front = newCells(width, height)
+	s.back = newCells(width, height)
+	s.valid = false
+}
+
+func newCells(width, height int) [][]Cell {
+	cells := make([][]Cell, height)
+	for i := range cells {
+		cells[i] = make([]Cell, width)
+		for j := range cells[i] {
+			cells[i][j] = blankCell
+		}
+	}
+	return cells
+}
+
+func (s *Screen) Size() (width, height int) {
+	return s.width, s.height
+}
+
+// Invalidate makes the next Flush redraw the whole screen.
+func (s *Screen) Invalidate() {
+	s.valid = false
+}
+
+// Clear fills the frame with blanks drawn in style.
+func (s *Screen) Clear(style syntax.Style) {
+	for _, row := range s.back {
+		for j := range row {
+			row[j] = Cell{Ch: ' ', Style: style}
+		}
+	}
+}
+
+// SetCell puts c at row, col (0-indexed). A wide character takes the next cell too.
+func (s *Screen) SetCell(row, col int, c Cell) {
+	if row < 0 || row >= s.height || col < 0 || col >= s.width {
+		return
+	}
+	if isControl(c.Ch) {
+		// control characters would move the terminal cursor
+		c.Ch = '?'
+	}
+	line := s.back[row]
+	// drawing over half of a wide character blanks the other half
+	if col > 0 && runewidth.RuneWidth(line[col-1].Ch) == 2 {
+		line[col-1] = Cell{Ch: ' ', Style: line[col-1].Style}
+	}
+	if col+1 < s.width && line[col+1].Ch == 0 {
+		line[col+1] = Cell{Ch: ' ', Style: line[col+1].Style}
+	}
+	if runewidth.RuneWidth(c.Ch) == 2 {
+		if col+1 >= s.width {
+			c.Ch = ' '
+		} else {
+			if col+2 < s.width && line[col+2].Ch == 0 {
+				line[col+2] = Cell{Ch: ' ', Style: line[col+2].Style}
+			}
+			line[col+1] = Cell{Ch: 0, Style: c.Style}
+		}
+	}
+	line[col] = c
+}
+
+// SetString puts text from row, col and returns the column after it.
+func (s *Screen) SetString(row, col int, text string, style syntax.Style) int {
+	for _, r := range text {
+		s.SetCell(row, col, Cell{Ch: r, Style: style})
+		col += cellWidth(r)
+	}
+	return col
+}
+
+func isControl(r rune) bool {
+	return r < ' ' || r == 0x7f
+}
+
+// cellWidth returns the number of cells r takes on the screen.
+func cellWidth(r rune) int {
+	if isControl(r) {
+		return 1
+	}
+	return runewidth.RuneWidth(r)
+}
+
+// ShowCursor places the cursor at row, col (0-indexed) when the frame is flushed.
+func (s *Screen) ShowCursor(row, col int) {
+	s.cursorRow, s.cursorCol = row, col
+}
+
+// Row returns the text of a row of the frame, for tests and debugging.
+func (s *Screen) Row(row int) string {
+	return rowText(s.back[row])
+}
+
+func rowText(cells []Cell) string {
+	var sb strings.Builder
+	for _, c := range cells {
+		if c.Ch != 0 {
+			sb.WriteRune(c.Ch)
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// Flush writes the difference between the frame and the terminal and moves the cursor.
+func (s *Screen) Flush() error {
+	var buf bytes.Buffer
+	if !s.valid {
+		buf.WriteString(s.styleSequence(syntax.Style{}))
+		buf.WriteString(s.Caps.String("clear"))
+		s.front = newCells(s.width, s.height)
+		s.shownRow, s.shownCol = 0, 0
+		s.shownStyle = syntax.Style{}
+		s.valid = true
+	}
+	for r := 0; r < s.height; r++ {
+		for c := 0; c < s.width; c++ {
+			cell := s.back[r][c]
+			if cell == s.front[r][c] || cell.Ch == 0 {
+				continue
+			}
+			s.moveTo(&buf, r, c)
+			if cell.Style != s.shownStyle {
+				buf.WriteString(s.styleSequence(cell.Style))
+				s.shownStyle = cell.Style
+			}
+			buf.WriteRune(cell.Ch)
+			s.front[r][c] = cell
+			w := runewidth.RuneWidth(cell.Ch)
+			if w == 2 {
+				s.front[r][c+1] = s.back[r][c+1]
+			}
+			s.shownCol += w
+			if s.shownCol >= s.width {
+				// the terminal cursor waits at the last column, its position is unknown
+				s.shownRow = -1
+			}
+		}
+	}
+	if s.shownStyle != (syntax.Style{}) {
+		buf.WriteString(s.styleSequence(syntax.Style{}))
+		s.shownStyle = syntax.Style{}
+	}
+	if buf.Len() == 0 && s.shownRow == s.cursorRow && s.shownCol == s.cursorCol {
+		return nil
+	}
+	s.moveTo(&buf, s.cursorRow, s.cursorCol)
+	out := buf.Bytes()
+	if s.SyncUpdate {
+		begin, end := "\033[?2026h", "\033[?2026l"
+		if s.Caps.Has("Sync") {
+			begin, end = s.Caps.String("Sync", 1), s.Caps.String("Sync", 2)
+		}
+		out = append(append([]byte(begin), out...), end...)
+	}
+	_, err := s.Output.Write(out)
+	return err
+}
+
+// moveTo moves the terminal cursor to row, col. Moving right over a few unchanged cells
+// is cheaper done by writing them again than with an escape sequence.
+func (s *Screen) moveTo(buf *bytes.Buffer, row, col int) {
+	if row == s.shownRow && col == s.shownCol {
+		return
+	}
+	if row == s.shownRow && col > s.shownCol && col-s.shownCol <= 3 && s.canRewrite(row, s.shownCol, col) {
+		for c := s.shownCol; c < col; c++ {
+			buf.WriteRune(s.front[row][c].Ch)
+		}
+		s.shownCol = col
+		return
+	}
+	buf.WriteString(s.Caps.String("cup", row, col))
+	s.shownRow, s.shownCol = row, col
+}
+
+// styleSequence returns the escape sequence that switches the terminal to style.
+// Terminals that take the ANSI SGR sequences get them in one sequence,
+// others get the terminfo capability of each attribute and colour.
+func (s *Screen) styleSequence(style syntax.Style) string {
+	if s.isANSI() {
+		if style == (syntax.Style{}) {
+			return "\033[0m"
+		}
+		return style.Sequence(s.ColorMode)
+	}
+	seq := s.Caps.String("sgr0")
+	for _, a := range []struct {
+		attr syntax.Attr
+		cap  string
+	}{{syntax.Bold, "bold"}, {syntax.Italic, "sitm"}, {syntax.Underline, "smul"}, {syntax.Reverse, "rev"}} {
+		if style.Attr&a.attr != 0 {
+			seq += s.Caps.String(a.cap)
+		}
+	}
+	seq += s.colorSequence("setaf", style.Fg)
+	seq += s.colorSequence("setab", style.Bg)
+	return seq
+}
+
+func (s *Screen) colorSequence(cap string, c syntax.Color) string {
+	if c.IsDefault() || !s.Caps.Has(cap) {
+		return ""
+	}
+	mode := s.ColorMode
+	if mode == syntax.TrueColor {
+		mode = syntax.Color256
+	}
+	n := c.Index(mode)
+	if colors := s.Caps.Number("colors"); colors > 0 && n >= colors {
+		// ex) bright colours on a terminal with 8 colours
+		n %= colors
+	}
+	return s.Caps.String(cap, n)
+}
+
+// isANSI reports whether the terminal sets colours with the ANSI SGR sequences.
+func (s *Screen) isANSI() bool {
+	return s.Caps.String("setaf", 1) == "\033[31m" && s.Caps.String("setab", 1) == "\033[41m"
+}
+
+// canRewrite reports whether the cells [from, to) of row can be written again
+// in the current style without changing what the terminal shows.
+func (s *Screen) canRewrite(row, from, to int) bool {
+	for c := from; c < to; c++ {
+		cell := s.front[row][c]
+		if runewidth.RuneWidth(cell.Ch) != 1 {
+			return false
+		}
+		if cell.Style == s.shownStyle {
+			continue
+		}
+		// the foreground colour, bold and italic do not show on a space
+		visible := syntax.Underline | syntax.Reverse
+		if cell.Ch != ' ' || cell.Style.Bg != s.shownStyle.Bg || cell.Style.Attr&visible != s.shownStyle.Attr&visible {
+			return false
+		}
+	}
+	return true
+}
+
+// SyncUpdateSupported guesses from the environment whether the terminal
+// understands the synchronized update sequences.
+func SyncUpdateSupported(getenv func(string) string) bool {
+	switch getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty":
+		return true
+	}
+	term := getenv("TERM")
+	for _, prefix := range []string{"xterm-kitty", "alacritty", "foot", "wezterm", "contour", "tmux", "xterm-ghostty"} {
+		if strings.HasPrefix(term, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package window
+
+import (
+	"bytes"
+	"testing"
+
+	"gim/syntax"
+	"gim/terminfo"
+)
+
+func TestScreen_Flush(t *testing.T) {
+	red := syntax.Style{Fg: syntax.Indexed(1)}
+	tests := []struct {
+		name  string
+		sync  bool
+		first func(s *Screen)
+		next  func(s *Screen)
+		want  string
+	}{
+		{
+			name:  "first frame clears the terminal",
+			first: nil,
+			next: func(s *Screen) {
+				s.SetString(0, 0, "ab", syntax.Style{})
+				s.SetString(1, 2, "c", syntax.Style{})
+				s.ShowCursor(1, 3)
+			},
+			want: "\033[0m\033[H\033[2Jab\033[2;3Hc",
+		},
+		{
+			name:  "unchanged frame writes nothing",
+			first: func(s *Screen) { s.SetString(0, 0, "ab", syntax.Style{}) },
+			next:  func(s *Screen) { s.SetString(0, 0, "ab", syntax.Style{}) },
+			want:  "",
+		},
+		{
+			name:  "only the changed cell is written",
+			first: func(s *Screen) { s.SetString(1, 0, "hello", syntax.Style{}) },
+			next:  func(s *Screen) { s.SetString(1, 0, "hallo", syntax.Style{}) },
+			want:  "\033[2;2Ha\033[1;1H",
+		},
+		{
+			name:  "near cells are written again instead of moving the cursor",
+			first: func(s *Screen) { s.SetString(0, 0, "abcdef", syntax.Style{}) },
+			next:  func(s *Screen) { s.SetString(0, 0, "Xbcdef", syntax.Style{}); s.SetCell(0, 3, Cell{Ch: 'Y'}) },
+			want:  "XbcY\033[1;1H",
+		},
+		{
+			name:  "cursor only",
+			first: func(s *Screen) {},
+			next:  func(s *Screen) { s.ShowCursor(2, 4) },
+			want:  "\033[3;5H",
+		},
+		{
+			name:  "style change",
+			first: func(s *Screen) { s.SetString(0, 0, "ab", syntax.Style{}) },
+			next:  func(s *Screen) { s.SetString(0, 0, "ab", red) },
+			want:  "\033[0;38;5;1mab\033[0m\033[1;1H",
+		},
+		{
+			name:  "wide character",
+			first: func(s *Screen) {},
+			next:  func(s *Screen) { s.SetString(0, 0, "日本", syntax.Style{}); s.ShowCursor(0, 4) },
+			want:  "日本",
+		},
+		{
+			name:  "character over half of a wide character",
+			first: func(s *Screen) { s.SetString(0, 0, "日本", syntax.Style{}) },
+			next:  func(s *Screen) { s.SetString(0, 0, "日本", syntax.Style{}); s.SetCell(0, 3, Cell{Ch: 'x'}) },
+			want:  "\033[1;3H x\033[1;1H",
+		},
+		{
+			name:  "synchronized update",
+			sync:  true,
+			first: func(s *Screen) {},
+			next:  func(s *Screen) { s.SetString(0, 0, "a", syntax.Style{}) },
+			want:  "\033[?2026ha\033[1;1H\033[?2026l",
+		},
+		{
+			name:  "control characters are not sent",
+			first: func(s *Screen) {},
+			next:  func(s *Screen) { s.SetString(0, 0, "a\033b", syntax.Style{}) },
+			want:  "a?b\033[1;1H",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			s := NewScreen(out, 8, 3)
+			s.ColorMode = syntax.Color256
+			s.SyncUpdate = tt.sync
+			if tt.first != nil {
+				tt.first(s)
+				if err := s.Flush(); err != nil {
+					t.Fatal(err)
+				}
+				out.Reset()
+			}
+			s.Clear(syntax.Style{})
+			s.ShowCursor(0, 0)
+			tt.next(s)
+			if err := s.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got: %q, want: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScreen_FlushTerminfo(t *testing.T) {
+	caps := &terminfo.Terminfo{
+		Numbers: map[string]int{"colors": 8},
+		Strings: map[string]string{
+			"clear": "\033[H\033[J",
+			"cup":   "\033[%i%p1%d;%p2%dH",
+			"sgr0":  "\033[m\017",
+			"bold":  "\033[1m",
+			"setaf": "\033[3%p1%dm",
+			"Sync":  "\033[?2026%?%p1%{1}%-%tl%eh%;",
+		},
+	}
+	tests := []struct {
+		name string
+		sync bool
+		text string
+		want string
+	}{
+		{
+			name: "capabilities of the terminal",
+			text: "a",
+			want: "\033[m\017\033[H\033[Ja\033[1;1H",
+		},
+		{
+			name: "synchronized update from terminfo",
+			sync: true,
+			text: "a",
+			want: "\033[?2026h\033[m\017\033[H\033[Ja\033[1;1H\033[?2026l",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			s := NewScreen(out, 8, 3)
+			s.Caps = caps
+			s.SyncUpdate = tt.sync
+			s.SetString(0, 0, tt.text, syntax.Style{})
+			if err := s.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("got: %q, want: %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScreen_styleSequence(t *testing.T) {
+	tests := []struct {
+		name  string
+		caps  *terminfo.Terminfo
+		style syntax.Style
+		want  string
+	}{
+		{
+			name:  "ANSI terminal",
+			caps:  terminfo.Xterm(),
+			style: syntax.Style{Fg: syntax.Indexed(9), Attr: syntax.Bold},
+			want:  "\033[0;1;38;5;9m",
+		},
+		{
+			name: "bright colour on 8 colours",
+			caps: &terminfo.Terminfo{
+				Numbers: map[string]int{"colors": 8},
+				Strings: map[string]string{"sgr0": "\033[m\017", "bold": "\033[1m", "setaf": "\033[3%p1%dm"},
+			},
+			style: syntax.Style{Fg: syntax.Indexed(9), Bg: syntax.Indexed(4), Attr: syntax.Bold | syntax.Italic},
+			want:  "\033[m\017\033[1m\033[31m",
+		},
+		{
+			name:  "no colours",
+			caps:  &terminfo.Terminfo{Strings: map[string]string{"sgr0": "\033[m", "rev": "\033[7m"}},
+			style: syntax.Style{Fg: syntax.Indexed(1), Attr: syntax.Reverse},
+			want:  "\033[m\033[7m",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScreen(new(bytes.Buffer), 1, 1)
+			s.Caps = tt.caps
+			s.ColorMode = syntax.Color256
+			if got := s.styleSequence(tt.style); got != tt.want {
+				t.Errorf("got: %q, want: %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScreen_Resize(t *testing.T) {
+	out := new(bytes.Buffer)
+	s := NewScreen(out, 4, 2)
+	s.SetString(0, 0, "ab", syntax.Style{})
+	s.Flush()
+	out.Reset()
+
+	s.Resize(6, 3)
+	s.SetString(0, 0, "ab", syntax.Style{})
+	s.Flush()
+	if want := "\033[0m\033[H\033[2Jab\033[1;1H"; out.String() != want {
+		t.Errorf("got: %q, want: %q", out.String(), want)
+	}
+	if width, height := s.Size(); width != 6 || height != 3 {
+		t.Errorf("Size() = %d, %d, want 6, 3", width, height)
+	}
+}
+
+func TestScreen_Row(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "ascii", text: "abc", want: "abc"},
+		{name: "wide", text: "日本", want: "日本"},
+		{name: "clipped", text: "abcdefghij", want: "abcdefgh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScreen(new(bytes.Buffer), 8, 1)
+			s.SetString(0, 0, tt.text, syntax.Style{})
+			if got := s.Row(0); got != tt.want {
+				t.Errorf("Row() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncUpdateSupported(t *testing.T) {
+	tests := []struct {
+		name        string
+		term        string
+		termProgram string
+		want        bool
+	}{
+		{name: "kitty", term: "xterm-kitty", want: true},
+		{name: "tmux", term: "tmux-256color", want: true},
+		{name: "iTerm2", term: "xterm-256color", termProgram: "iTerm.app", want: true},
+		{name: "xterm", term: "xterm-256color", want: false},
+		{name: "linux console", term: "linux", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := map[string]string{"TERM": tt.term, "TERM_PROGRAM": tt.termProgram}
+			if got := SyncUpdateSupported(func(k string) string { return env[k] }); got != tt.want {
+				t.Errorf("SyncUpdateSupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
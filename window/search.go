@@ -0,0 +1,125 @@
+package window
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var errNoPreviousPattern = errors.New("E35: No previous regular expression")
+
+// startSearch is / and ?, they start typing a pattern to search forward or backward.
+func (w *Window) startSearch(k normalKeys) {
+	w.searchCount = k.count
+	w.startCommandLine(k.name[0])
+}
+
+// searchCommand searches for the pattern typed after / or ?, an empty pattern searches for the last one again.
+func (w *Window) searchCommand(typ byte, pattern string) {
+	pattern = cutPattern(pattern, typ)
+	if pattern == "" {
+		pattern = w.lastSearch
+	}
+	if pattern == "" {
+		w.showError(errNoPreviousPattern)
+		return
+	}
+	w.lastSearch = pattern
+	w.searchBackward = typ == '?'
+	count := w.searchCount
+	w.searchCount = 0
+	if len(w.FileContents) == 0 {
+		return
+	}
+	p, ok := w.searchNext(w.position, count, false)
+	if !ok {
+		return
+	}
+	w.pushJump(w.position)
+	w.position = p
+	w.clampCursor()
+}
+
+// cutPattern returns pattern up to the first typ not escaped with a backslash, ex) foo of foo/e
+func cutPattern(pattern string, typ byte) string {
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case typ:
+			return pattern[:i]
+		}
+	}
+	return pattern
+}
+
+// searchNext returns where the count-th match of the last pattern is from p, in the direction
+// of the last search or the other one for reverse, ex) N
+func (w *Window) searchNext(p Position, count int, reverse bool) (Position, bool) {
+	if w.lastSearch == "" {
+		w.showError(errNoPreviousPattern)
+		return p, false
+	}
+	re, err := w.compileSearch(w.lastSearch)
+	if err != nil {
+		w.showError(fmt.Errorf("E383: Invalid search string: %s", w.lastSearch))
+		return p, false
+	}
+	backward := w.searchBackward != reverse
+	wrapped := false
+	for i := 0; i < orOne(count); i++ {
+		q, wrap, ok := w.searchFrom(re, p, backward)
+		if !ok {
+			w.showError(fmt.Errorf("E486: Pattern not found: %s", w.lastSearch))
+			w.fail()
+			return p, false
+		}
+		p, wrapped = q, wrapped || wrap
+	}
+	if wrapped && backward {
+		w.showMessage("search hit TOP, continuing at BOTTOM", "")
+	} else if wrapped {
+		w.showMessage("search hit BOTTOM, continuing at TOP", "")
+	}
+	return p, true
+}
+
+// compileSearch compiles a search pattern, ignoring case with the ignorecase option unless
+// smartcase is set and the pattern has a capital.
+func (w *Window) compileSearch(pattern string) (*regexp.Regexp, error) {
+	if w.boolOption("ignorecase") && !(w.boolOption("smartcase") && strings.ToLower(pattern) != pattern) {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// searchFrom returns the first match of re after p, or the last one before it when backward.
+// The search wraps around the end of the buffer.
+func (w *Window) searchFrom(re *regexp.Regexp, p Position, backward bool) (q Position, wrapped, ok bool) {
+	n := len(w.FileContents)
+	for i := 0; i <= n; i++ {
+		y := p.Y + i
+		if backward {
+			y = p.Y - i
+		}
+		wrapped = y < 1 || y > n
+		y = (y-1+n)%n + 1
+		line := w.FileContents[y-1]
+		matches := re.FindAllIndex(line, -1)
+		if backward {
+			for j := len(matches) - 1; j >= 0; j-- {
+				if x := matches[j][0] + 1; i > 0 || x < p.X {
+					return Position{X: x, Y: y}, wrapped, true
+				}
+			}
+			continue
+		}
+		for _, m := range matches {
+			if x := m[0] + 1; i > 0 && i < n || i == 0 && x > p.X || i == n && x <= p.X {
+				return Position{X: x, Y: y}, wrapped, true
+			}
+		}
+	}
+	return p, false, false
+}
@@ -0,0 +1,48 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_searchCommand(t *testing.T) {
+	tests := []struct {
+		name         string
+		lines        []string
+		position     Position
+		input        string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "forward", lines: []string{"foo", "bar", "foo bar"}, position: Position{X: 1, Y: 1}, input: "/bar\r", wantLines: []string{"foo", "bar", "foo bar"}, wantPosition: Position{X: 1, Y: 2}},
+		{name: "next", lines: []string{"foo", "bar", "foo bar"}, position: Position{X: 1, Y: 1}, input: "/bar\rn", wantLines: []string{"foo", "bar", "foo bar"}, wantPosition: Position{X: 5, Y: 3}},
+		{name: "wrap around the end", lines: []string{"foo", "bar", "foo bar"}, position: Position{X: 1, Y: 1}, input: "/bar\rnn", wantLines: []string{"foo", "bar", "foo bar"}, wantPosition: Position{X: 1, Y: 2}, wantMessage: "search hit BOTTOM, continuing at TOP"},
+		{name: "backward", lines: []string{"foo", "bar", "foo bar"}, position: Position{X: 1, Y: 2}, input: "?foo\r", wantLines: []string{"foo", "bar", "foo bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "wrap around the start", lines: []string{"foo", "bar", "foo bar"}, position: Position{X: 1, Y: 1}, input: "?foo\r", wantLines: []string{"foo", "bar", "foo bar"}, wantPosition: Position{X: 1, Y: 3}, wantMessage: "search hit TOP, continuing at BOTTOM"},
+		{name: "next in the other direction", lines: []string{"foo", "bar", "foo bar"}, position: Position{X: 1, Y: 1}, input: "/foo\rN", wantLines: []string{"foo", "bar", "foo bar"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "the only match", lines: []string{"foo"}, position: Position{X: 1, Y: 1}, input: "/foo\r", wantLines: []string{"foo"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "search hit BOTTOM, continuing at TOP"},
+		{name: "count", lines: []string{"foo"}, position: Position{X: 1, Y: 1}, input: "2/o\r", wantLines: []string{"foo"}, wantPosition: Position{X: 3, Y: 1}},
+		{name: "last pattern", lines: []string{"a b b"}, position: Position{X: 1, Y: 1}, input: "/b\r/\r", wantLines: []string{"a b b"}, wantPosition: Position{X: 5, Y: 1}},
+		{name: "terminated pattern", lines: []string{"a/b ab"}, position: Position{X: 1, Y: 1}, input: "/\\/b/e\r", wantLines: []string{"a/b ab"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "not found", lines: []string{"foo"}, position: Position{X: 2, Y: 1}, input: "/baz\r", wantLines: []string{"foo"}, wantPosition: Position{X: 2, Y: 1}, wantMessage: "E486: Pattern not found: baz"},
+		{name: "invalid pattern", lines: []string{"foo"}, position: Position{X: 1, Y: 1}, input: "/(\r", wantLines: []string{"foo"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E383: Invalid search string: ("},
+		{name: "no previous pattern", lines: []string{"foo"}, position: Position{X: 1, Y: 1}, input: "n", wantLines: []string{"foo"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "E35: No previous regular expression"},
+		{name: "jump back", lines: []string{"foo", "bar"}, position: Position{X: 2, Y: 1}, input: "/bar\r``", wantLines: []string{"foo", "bar"}, wantPosition: Position{X: 2, Y: 1}},
+		{name: "delete to the next match", lines: []string{"foo bar"}, position: Position{X: 1, Y: 1}, input: "/bar\r0dn", wantLines: []string{"bar"}, wantPosition: Position{X: 1, Y: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := typeKeys(t, tt.lines, tt.position, tt.input)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package window
+
+import (
+	"bytes"
+	"io"
+
+	"gim/syntax"
+	"gim/terminfo"
+)
+
+// SimTerminal is a terminal in memory, so the editor can run without a TTY, ex) in tests.
+// Row and Cell return what a real terminal would show after the last Flush.
+type SimTerminal struct {
+	*Screen
+	// Out holds everything written to the simulated terminal.
+	Out *bytes.Buffer
+	// Mouse is true when mouse events are reported.
+	Mouse  bool
+	events chan Event
+}
+
+func NewSimTerminal(columns, rows int) *SimTerminal {
+	out := new(bytes.Buffer)
+	s := &SimTerminal{
+		Screen: NewScreen(out, columns, rows),
+		Out:    out,
+		events: make(chan Event, 128),
+	}
+	s.ColorMode = syntax.Color256
+	return s
+}
+
+func (s *SimTerminal) Size() (Size, error) {
+	width, height := s.Screen.Size()
+	return Size{Row: height, Column: width}, nil
+}
+
+func (s *SimTerminal) Colors() syntax.ColorMode {
+	return s.ColorMode
+}
+
+func (s *SimTerminal) Terminfo() *terminfo.Terminfo {
+	return s.Caps
+}
+
+func (s *SimTerminal) Events() <-chan Event {
+	return s.events
+}
+
+func (s *SimTerminal) SetMouse(on bool) {
+	s.Mouse = on
+}
+
+// Type sends keyboard input.
+func (s *SimTerminal) Type(b []byte) {
+	s.events <- Event{Type: EventInput, Data: b}
+}
+
+// Hangup ends the input as a terminal that was closed, Run returns io.EOF after the keys typed before.
+func (s *SimTerminal) Hangup() {
+	s.events <- Event{Type: EventError, Err: io.EOF}
+}
+
+// Resize changes the size of the terminal and sends a resize event.
+func (s *SimTerminal) Resize(columns, rows int) {
+	s.Screen.Resize(columns, rows)
+	s.events <- Event{Type: EventResize}
+}
+
+// Row returns the text shown on a row (0-indexed) without trailing blanks.
+func (s *SimTerminal) Row(row int) string {
+	return rowText(s.front[row])
+}
+
+// Cell returns the cell shown at row, col (0-indexed).
+func (s *SimTerminal) Cell(row, col int) Cell {
+	return s.front[row][col]
+}
+
+// Cursor returns where the cursor is shown (0-indexed).
+func (s *SimTerminal) Cursor() (row, col int) {
+	return s.cursorRow, s.cursorCol
+}
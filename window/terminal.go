@@ -0,0 +1,172 @@
+package window
+
+import (
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gim/syntax"
+	"gim/terminfo"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+type EventType int
+
+const (
+	// EventInput carries bytes typed on the keyboard.
+	EventInput EventType = iota
+	// EventResize tells the terminal size changed.
+	EventResize
+	// EventError tells the input could not be read any more.
+	EventError
+)
+
+type Event struct {
+	Type EventType
+	Data []byte
+	Err  error
+}
+
+// Terminal is where the window draws and where its input comes from.
+// Drawing is done into a frame which Flush shows at once.
+type Terminal interface {
+	Size() (Size, error)
+	// Colors returns the number of colours the terminal can show.
+	Colors() syntax.ColorMode
+	// Terminfo returns the description of the terminal, ex) the sequences of its keys.
+	Terminfo() *terminfo.Terminfo
+	// Clear fills the frame with blanks drawn in style.
+	Clear(style syntax.Style)
+	// SetCell puts c at row, col (0-indexed) of the frame.
+	SetCell(row, col int, c Cell)
+	// ShowCursor places the cursor at row, col (0-indexed).
+	ShowCursor(row, col int)
+	Flush() error
+	// Events returns the channel of keyboard input and resizes.
+	Events() <-chan Event
+	// SetMouse turns the reports of mouse events on or off.
+	SetMouse(on bool)
+}
+
+const (
+	bracketedPasteOn  = "\033[?2004h"
+	bracketedPasteOff = "\033[?2004l"
+)
+
+// TTY is a real terminal, ex) os.Stdin and os.Stdout.
+// It draws with a Screen, so only the changes of each frame are written.
+type TTY struct {
+	*Screen
+	Input   *os.File // Adopts os.File to use Fd () , ex) Stdin
+	events  chan Event
+	signals chan os.Signal
+	state   *terminal.State
+	mouse   bool
+}
+
+func NewTTY(input *os.File, output io.Writer) *TTY {
+	return &TTY{
+		Screen: NewScreen(output, 0, 0),
+		Input:  input,
+		events: make(chan Event, 128),
+	}
+}
+
+// Start puts the terminal in raw mode and starts reading the input.
+func (t *TTY) Start() error {
+	state, err := terminal.MakeRaw(int(t.Input.Fd()))
+	if err != nil {
+		return err
+	}
+	t.state = state
+	// make the keypad send the sequences described by terminfo,
+	// and mark pasted text so it is not taken as typed keys
+	io.WriteString(t.Output, t.Caps.String("smkx")+bracketedPasteOn)
+	t.signals = make(chan os.Signal, 1)
+	signal.Notify(t.signals, syscall.SIGWINCH)
+	go t.watchResize()
+	go t.readInput()
+	return nil
+}
+
+// Close restores the terminal to the mode it had before Start.
+func (t *TTY) Close() error {
+	if t.signals != nil {
+		signal.Stop(t.signals)
+	}
+	if t.state == nil {
+		return nil
+	}
+	t.SetMouse(false)
+	io.WriteString(t.Output, bracketedPasteOff+t.Caps.String("rmkx"))
+	return terminal.Restore(int(t.Input.Fd()), t.state)
+}
+
+func (t *TTY) watchResize() {
+	for range t.signals {
+		t.events <- Event{Type: EventResize}
+	}
+}
+
+func (t *TTY) readInput() {
+	for {
+		// a new buffer for each read, the previous one may still be in use
+		buf := make([]byte, 1024)
+		n, err := t.Input.Read(buf)
+		if err != nil {
+			t.events <- Event{Type: EventError, Err: err}
+			return
+		}
+		t.events <- Event{Type: EventInput, Data: buf[:n]}
+	}
+}
+
+// Size returns the size of the terminal and resizes the screen when it changed.
+func (t *TTY) Size() (Size, error) {
+	column, row, err := terminal.GetSize(int(t.Input.Fd()))
+	if err != nil {
+		return Size{}, err
+	}
+	if width, height := t.Screen.Size(); width != column || height != row {
+		t.Screen.Resize(column, row)
+	}
+	return Size{Row: row, Column: column}, nil
+}
+
+func (t *TTY) Colors() syntax.ColorMode {
+	return t.ColorMode
+}
+
+func (t *TTY) Terminfo() *terminfo.Terminfo {
+	return t.Caps
+}
+
+func (t *TTY) Events() <-chan Event {
+	return t.events
+}
+
+func (t *TTY) SetMouse(on bool) {
+	if on == t.mouse {
+		return
+	}
+	t.mouse = on
+	if on {
+		io.WriteString(t.Output, mouseOn)
+	} else {
+		io.WriteString(t.Output, mouseOff)
+	}
+}
+
+// DetectColorMode guesses the colours the terminal supports from its terminfo and the environment.
+func DetectColorMode(ti *terminfo.Terminfo, getenv func(string) string) syntax.ColorMode {
+	mode := syntax.DetectColorMode(getenv)
+	switch colors := ti.Number("colors"); {
+	case colors >= 1<<24 || ti.Bools["RGB"] || ti.Bools["Tc"]:
+		return syntax.TrueColor
+	case colors >= 256 && mode < syntax.Color256:
+		return syntax.Color256
+	}
+	return mode
+}
@@ -0,0 +1,122 @@
+package window
+
+import (
+	"os"
+	"testing"
+
+	"gim/syntax"
+	"gim/terminfo"
+)
+
+func TestSimTerminal(t *testing.T) {
+	tests := []struct {
+		name     string
+		draw     func(s *SimTerminal)
+		wantRows []string
+		wantRow  int
+		wantCol  int
+	}{
+		{
+			name: "nothing is shown before Flush",
+			draw: func(s *SimTerminal) {
+				s.SetCell(0, 0, Cell{Ch: 'a'})
+			},
+			wantRows: []string{"", ""},
+		},
+		{
+			name: "cells and cursor",
+			draw: func(s *SimTerminal) {
+				s.SetCell(0, 0, Cell{Ch: 'a'})
+				s.SetCell(1, 2, Cell{Ch: '日'})
+				s.ShowCursor(1, 4)
+				s.Flush()
+			},
+			wantRows: []string{"a", "  日"},
+			wantRow:  1,
+			wantCol:  4,
+		},
+		{
+			name: "clear",
+			draw: func(s *SimTerminal) {
+				s.SetCell(0, 0, Cell{Ch: 'a'})
+				s.Flush()
+				s.Clear(syntax.Style{})
+				s.Flush()
+			},
+			wantRows: []string{"", ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSimTerminal(10, 2)
+			tt.draw(s)
+			for i, want := range tt.wantRows {
+				if got := s.Row(i); got != want {
+					t.Errorf("got: row %d = %q, want: %q", i, got, want)
+				}
+			}
+			if row, col := s.Cursor(); row != tt.wantRow || col != tt.wantCol {
+				t.Errorf("got: cursor = %d, %d, want: %d, %d", row, col, tt.wantRow, tt.wantCol)
+			}
+		})
+	}
+}
+
+func TestSimTerminal_Events(t *testing.T) {
+	s := NewSimTerminal(10, 2)
+	s.Type([]byte("i"))
+	s.Resize(20, 5)
+	if ev := <-s.Events(); ev.Type != EventInput || string(ev.Data) != "i" {
+		t.Errorf("got: %+v, want: input i", ev)
+	}
+	if ev := <-s.Events(); ev.Type != EventResize {
+		t.Errorf("got: %+v, want: resize", ev)
+	}
+	if size, _ := s.Size(); size != (Size{Row: 5, Column: 20}) {
+		t.Errorf("got: %+v, want: %+v", size, Size{Row: 5, Column: 20})
+	}
+}
+
+func TestTTY_readInput(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	tty := NewTTY(r, nil)
+	go tty.readInput()
+
+	w.Write([]byte("a"))
+	first := <-tty.Events()
+	w.Write([]byte("b"))
+	second := <-tty.Events()
+	w.Close()
+	if string(first.Data) != "a" || string(second.Data) != "b" {
+		t.Errorf("got: %q, %q, want: \"a\", \"b\"", first.Data, second.Data)
+	}
+	if ev := <-tty.Events(); ev.Type != EventError {
+		t.Errorf("got: %+v, want: an error after the input is closed", ev)
+	}
+}
+
+func TestDetectColorMode(t *testing.T) {
+	tests := []struct {
+		name string
+		caps *terminfo.Terminfo
+		env  map[string]string
+		want syntax.ColorMode
+	}{
+		{name: "256 colours in terminfo", caps: terminfo.Xterm(), env: map[string]string{"TERM": "xterm"}, want: syntax.Color256},
+		{name: "8 colours", caps: &terminfo.Terminfo{Numbers: map[string]int{"colors": 8}}, env: map[string]string{"TERM": "linux"}, want: syntax.Color16},
+		{name: "direct colour", caps: &terminfo.Terminfo{Numbers: map[string]int{"colors": 1 << 24}}, env: map[string]string{}, want: syntax.TrueColor},
+		{name: "RGB flag", caps: &terminfo.Terminfo{Bools: map[string]bool{"RGB": true}}, env: map[string]string{}, want: syntax.TrueColor},
+		{name: "COLORTERM", caps: terminfo.Xterm(), env: map[string]string{"COLORTERM": "truecolor"}, want: syntax.TrueColor},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectColorMode(tt.caps, func(k string) string { return tt.env[k] }); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
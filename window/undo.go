@@ -0,0 +1,75 @@
+package window
+
+// undoState is the buffer at one point of the history. Lines are never changed in place,
+// see edit.go, so a state only copies the slice of lines.
+// The marks a-z are kept with the lines, undo puts them back where they were, ex) on a line deleted.
+type undoState struct {
+	lines    [][]byte
+	position Position
+	marks    map[byte]Position
+}
+
+func (w *Window) snapshot() undoState {
+	s := undoState{lines: append([][]byte(nil), w.FileContents...), position: w.position}
+	for name, p := range w.marks {
+		if isLocalMark(name) {
+			if s.marks == nil {
+				s.marks = make(map[byte]Position)
+			}
+			s.marks[name] = p
+		}
+	}
+	return s
+}
+
+// saveUndo records the buffer before a change, ex) before the first character typed in insert mode.
+// The keys of a macro save it once, see startExecuting.
+// It starts a change, which sets the marks [ and ] again.
+func (w *Window) saveUndo() {
+	w.changeMarked = false
+	if w.undoGrouped {
+		return
+	}
+	w.modified = true
+	w.undoStack = append(w.undoStack, w.snapshot())
+	w.redoStack = nil
+	w.undoGrouped = w.executing > 0
+}
+
+// Undo reverts the last change, ex) u
+func (w *Window) Undo() {
+	if len(w.undoStack) == 0 {
+		w.showMessage("Already at oldest change", "")
+		return
+	}
+	w.redoStack = append(w.redoStack, w.snapshot())
+	w.restore(w.undoStack[len(w.undoStack)-1])
+	w.undoStack = w.undoStack[:len(w.undoStack)-1]
+}
+
+// Redo applies the change reverted by Undo again, ex) Ctrl-R
+func (w *Window) Redo() {
+	if len(w.redoStack) == 0 {
+		w.showMessage("Already at newest change", "")
+		return
+	}
+	w.undoStack = append(w.undoStack, w.snapshot())
+	w.restore(w.redoStack[len(w.redoStack)-1])
+	w.redoStack = w.redoStack[:len(w.redoStack)-1]
+}
+
+func (w *Window) restore(s undoState) {
+	w.FileContents = append([][]byte(nil), s.lines...)
+	w.position = s.position
+	// a mark set since the state was saved is left where it is
+	for name, p := range s.marks {
+		w.putMark(name, p)
+	}
+	w.modified = true
+	if w.highlighter != nil {
+		w.highlighter.Reset()
+	}
+	if w.indentCache != nil {
+		w.indentCache.Reset()
+	}
+}
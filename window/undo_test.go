@@ -0,0 +1,53 @@
+package window
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWindow_Undo(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        []string
+		wantLines    []string
+		wantPosition Position
+		wantMessage  string
+	}{
+		{name: "an insert is one change", input: []string{"iab", "\033", "u"}, wantLines: []string{"123"}, wantPosition: Position{X: 1, Y: 1}},
+		{name: "redo", input: []string{"iab", "\033", "u", "\x12"}, wantLines: []string{"ab123"}, wantPosition: Position{X: 2, Y: 1}},
+		{
+			name:         "paste is one change of its own",
+			input:        []string{"ia", "\033[200~x\ry\033[201~", "b", "\033", "u"},
+			wantLines:    []string{"ax", "y123"},
+			wantPosition: Position{X: 2, Y: 2},
+		},
+		{
+			name:         "undo the paste",
+			input:        []string{"ia", "\033[200~x\ry\033[201~", "b", "\033", "uu"},
+			wantLines:    []string{"a123"},
+			wantPosition: Position{X: 2, Y: 1},
+		},
+		{name: "nothing to undo", input: []string{"u"}, wantLines: []string{"123"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "Already at oldest change"},
+		{name: "nothing to redo", input: []string{"\x12"}, wantLines: []string{"123"}, wantPosition: Position{X: 1, Y: 1}, wantMessage: "Already at newest change"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(20, 4)
+			w := NewWindow(term)
+			w.FileContents = [][]byte{[]byte("123")}
+			for _, in := range tt.input {
+				term.Type([]byte(in))
+			}
+			runTyped(t, term, w)
+			if got := linesOf(w.FileContents); !reflect.DeepEqual(got, tt.wantLines) {
+				t.Errorf("got: %q, want: %q", got, tt.wantLines)
+			}
+			if w.position != tt.wantPosition {
+				t.Errorf("got: %+v, want: %+v", w.position, tt.wantPosition)
+			}
+			if w.message != tt.wantMessage {
+				t.Errorf("got: message %q, want: %q", w.message, tt.wantMessage)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package window
+
+import (
+	"strconv"
+	"unicode/utf8"
+)
+
+// textRows returns the number of rows that show the text, the last row is the command line.
+func (w *Window) textRows() int {
+	if w.Row < 2 {
+		return 1
+	}
+	return w.Row - 1
+}
+
+// gutterWidth returns the width of the line numbers shown before the text with the number option,
+// 0 without it.
+func (w *Window) gutterWidth() int {
+	if !w.boolOption("number") {
+		return 0
+	}
+	n := len(strconv.Itoa(len(w.FileContents)))
+	if n < 3 {
+		n = 3
+	}
+	return n + 1
+}
+
+// textColumns returns the number of columns that show the text, after the line numbers.
+func (w *Window) textColumns() int {
+	if n := w.Column - w.gutterWidth(); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// lineRows returns the number of rows the i-th line (0-indexed) takes on the screen, more than one
+// for a long line with the wrap option.
+func (w *Window) lineRows(i int) int {
+	if !w.boolOption("wrap") {
+		return 1
+	}
+	width := w.displayColumn(i, len(w.FileContents[i])+1)
+	if i == w.position.Y-1 {
+		// the cursor may be after the end of the line, ex) in insert mode
+		if c := w.displayColumn(i, w.position.X) + 1; c > width {
+			width = c
+		}
+	}
+	if width == 0 {
+		return 1
+	}
+	return (width + w.textColumns() - 1) / w.textColumns()
+}
+
+// cursorCell returns the row and the column of the screen the cursor is shown on.
+func (w *Window) cursorCell() (row, col int) {
+	y := w.position.Y - 1
+	for i := w.top; i < y; i++ {
+		row += w.lineRows(i)
+	}
+	col = w.displayColumn(y, w.position.X)
+	if w.boolOption("wrap") {
+		row += col / w.textColumns()
+		col %= w.textColumns()
+	} else {
+		col -= w.leftCol
+	}
+	return row, w.gutterWidth() + col
+}
+
+// scrollToCursor changes the top line, and the first column when long lines are not wrapped,
+// so the cursor is shown.
+func (w *Window) scrollToCursor() {
+	y := w.position.Y - 1
+	if y < w.top {
+		w.top = y
+	}
+	if y >= w.top+w.textRows() {
+		w.top = y - w.textRows() + 1
+	}
+	if w.top < 0 {
+		w.top = 0
+	}
+	// the lines above the cursor take more than one row each
+	for w.top < y {
+		if row, _ := w.cursorCell(); row < w.textRows() {
+			break
+		}
+		w.top++
+	}
+	if w.boolOption("wrap") || y < 0 || y >= len(w.FileContents) {
+		return
+	}
+	col := w.displayColumn(y, w.position.X)
+	if col < w.leftCol {
+		w.leftCol = col
+	}
+	if col >= w.leftCol+w.textColumns() {
+		w.leftCol = col - w.textColumns() + 1
+	}
+}
+
+// lastLine returns the last line (0-indexed) shown whole on the screen, or the top line.
+func (w *Window) lastLine() int {
+	last, rows := w.top, 0
+	for i := w.top; i < len(w.FileContents); i++ {
+		rows += w.lineRows(i)
+		if rows > w.textRows() {
+			break
+		}
+		last = i
+	}
+	return last
+}
+
+// scroll moves the text n lines up, or down when n is negative, keeping the cursor on the screen.
+func (w *Window) scroll(n int) {
+	w.top += n
+	if w.top > len(w.FileContents)-1 {
+		w.top = len(w.FileContents) - 1
+	}
+	if w.top < 0 {
+		w.top = 0
+	}
+	y := w.position.Y - 1
+	switch last := w.lastLine(); {
+	case y < w.top:
+		w.moveToLine(w.top)
+	case y > last:
+		w.moveToLine(last)
+	}
+}
+
+// moveToLine moves the cursor to the i-th line (0-indexed), keeping the column when the line is long enough.
+func (w *Window) moveToLine(i int) {
+	w.position.Y = i + 1
+	line := w.FileContents[i]
+	switch {
+	case w.IsInsertMode() && w.position.X > len(line)+1:
+		w.position.X = len(line) + 1
+	case !w.IsInsertMode() && w.position.X > len(line):
+		// on the first byte of the last character
+		_, size := utf8.DecodeLastRune(line)
+		w.position.X = len(line) - size + 1
+	}
+}
@@ -1,15 +1,15 @@
 package window
 
 import (
-	"bufio"
-	"bytes"
+	"context"
 	"fmt"
-	"io"
-	"os"
+	"strings"
+	"unicode/utf8"
 
-	prompt "github.com/c-bata/go-prompt"
+	"gim/syntax"
+	"gim/terminfo"
 
-	"golang.org/x/crypto/ssh/terminal"
+	prompt "github.com/c-bata/go-prompt"
 )
 
 type Size struct {
@@ -17,42 +17,101 @@ type Size struct {
 	Column int
 }
 
-var asciiSequences = []*prompt.ASCIICode{
-	{Key: prompt.Escape, ASCIICode: []byte{0x1b}},
-	{Key: prompt.Up, ASCIICode: []byte{0x1b, 0x5b, 0x41}},
-	{Key: prompt.Down, ASCIICode: []byte{0x1b, 0x5b, 0x42}},
-	{Key: prompt.Right, ASCIICode: []byte{0x1b, 0x5b, 0x43}},
-	{Key: prompt.Left, ASCIICode: []byte{0x1b, 0x5b, 0x44}},
-	{Key: prompt.ControlC, ASCIICode: []byte{0x3}},
-	{Key: prompt.Delete, ASCIICode: []byte{0x1b, 0x5b, 0x33, 0x7e}},
-	{Key: prompt.Backspace, ASCIICode: []byte{0x7f}},
-	{Key: prompt.Enter, ASCIICode: []byte{0xd}},
-}
-
 const (
 	normalMode = iota
 	insertMode
 	commandMode
+	visualMode
 )
 
 type Window struct {
 	Size
-	Input        *os.File  // Adopts os.File to use Fd () , ex) Stdin
-	Output       io.Writer // ex) Stdout
+	Terminal     Terminal // ex) a TTY on Stdin and Stdout
 	FileContents [][]byte
 	position     Position
 	mode         int // ex) insert mode
 	command      []byte
-}
-
-func NewWindow(input *os.File, output io.Writer) *Window {
+	commandType  byte // the character the command line was started with, ex) : or /
+	commandRight int  // the bytes of the command line after the cursor
+	fileName     string
+	fileType     string // ex) go, markdown
+	highlighter  *syntax.Highlighter
+	indentCache  *syntax.IndentCache // the scanners of the indenter of the file type, made when a line is indented
+	theme        *syntax.Theme
+	message      string // shown on the last line, ex) an error
+	messageGroup string // highlight group of message
+	hitEnter     bool   // a long message is shown until a key is typed
+	decoder      *KeyDecoder
+	top          int      // the first line shown (0-indexed)
+	visualStart  Position // the other end of the selection in visual mode
+	leftCol      int      // the first column shown when long lines are not wrapped
+	undoStack    []undoState
+	redoStack    []undoState
+	// changing is true after the first change of an insert, the rest of it is the same undo step
+	changing bool
+	count    int    // the count typed in normal mode, ex) 3 of 3x
+	pending  string // the keys of a command typed so far, ex) g or r
+	operator string // the operator waiting for its motion, ex) d
+	// operatorCount is the count typed before the operator, ex) 2 of 2d3w
+	operatorCount int
+	insertion     *insertion // the insert started by a command, nil otherwise
+	lastChange    change
+	register      byte // the register typed before a command, ex) a of "ayy
+	registers     map[byte]register
+	recording     byte       // the register keys are recorded in, 0 when they are not
+	recorded      []KeyEvent // the keys typed while recording
+	// lastExecuted is the register executed last with @, for @@
+	lastExecuted    byte
+	lastCommandLine string             // the command line executed last, for @:
+	executing       int                // how deep macros and :normal are executing keys
+	undoGrouped     bool               // the keys executed have saved the buffer for undo
+	failed          bool               // a command failed, the keys executed are stopped
+	marks           map[byte]Position  // the marks a-z and the marks set by the editor, see mark.go
+	fileMarks       map[byte]fileMark  // the marks A-Z
+	jumps           []Position         // the positions before jumps, oldest first
+	jumpIndex       int                // the entry of jumps Ctrl-O and Ctrl-I are on
+	changeMarked    bool               // the change being made has set the marks [ and ]
+	lastFind        findKeys           // the last character searched for in the line, for ; and ,
+	history         map[byte][]string  // the command lines typed for : and for / and ?, oldest first
+	historyIndex    int                // the line of the history shown, its length for the line typed
+	historyPrefix   string             // the text typed before Up, the lines of the history shown start with it
+	commandRegister bool               // Ctrl-R was typed on the command line, a register is inserted
+	cmdwin          *cmdwin            // the command-line window, when it is open
+	lastSearch      string             // the last pattern searched for, for n
+	searchBackward  bool               // the last search was ?, n searches backward
+	searchCount     int                // the count typed before / or ?
+	bufferNumber    int                // the number of the buffer edited, see :ls
+	lastBuffer      int                // the number of the buffer opened last
+	buffers         []*buffer          // the buffers not shown, by number
+	modified        bool               // the buffer has changed since it was read or written
+	fileStamp       fileStamp          // the file when it was read or written, see write.go
+	completion      *completion        // the candidates of Tab on the command line, see complete.go
+	options         optionValues       // the global values of the options set, see options.go
+	bufferOptions   optionValues       // the values of the options set for the buffer
+	windowOptions   optionValues       // the values of the options set for the window
+	mappings        []*mapping         // the key mappings, see map.go
+	typeahead       []KeyEvent         // the keys typed that may be the start of a mapping
+	abbreviations   []*mapping         // the abbreviations of insert and command-line modes, see abbrev.go
+	mapping         *mapping           // the mapping whose keys are being handled
+	sourcing        string             // the file and the line of the command being sourced, ex) .gimrc line 3
+	callbacks       chan func(*Window) // timers and the results of jobs, see Run
+	done            chan struct{}      // closed when Run returns
+	quit            context.CancelFunc
+	quitCode        *int
+}
+
+func NewWindow(term Terminal) *Window {
 	return &Window{
-		Input:        input,
-		Output:       output,
+		Terminal:     term,
 		FileContents: nil,
 		position:     Position{X: 1, Y: 1},
 		mode:         normalMode,
 		command:      []byte{},
+		bufferNumber: 1,
+		lastBuffer:   1,
+		theme:        syntax.NewTheme(),
+		callbacks:    make(chan func(*Window), 16),
+		done:         make(chan struct{}),
 	}
 }
 
@@ -86,37 +145,60 @@ func (w *Window) IsCommandNotTyped() bool {
 
 func (w *Window) SetInsertMode() {
 	w.mode = insertMode
+	w.changing = false
 }
 
 func (w *Window) SetNormalMode() {
+	if w.mode == visualMode {
+		start, end := w.visualStart, w.position
+		if before(end, start) {
+			start, end = end, start
+		}
+		w.putMark('<', start)
+		w.putMark('>', end)
+	}
 	w.mode = normalMode
+	w.insertion = nil
+}
+
+// setVisualMode starts selecting text from the cursor.
+func (w *Window) setVisualMode() {
+	w.mode = visualMode
+	w.visualStart = w.position
 }
 
 func (w *Window) SetCommandMode() {
 	w.mode = commandMode
+	w.message = ""
 }
 
+// AddCommand inserts b in the command line at the cursor.
 func (w *Window) AddCommand(b []byte) {
-	w.command = append(w.command, b...)
+	at := len(w.command) - w.commandRight
+	w.command = concat(w.command[:at], b, w.command[at:])
+	w.editedCommand()
 }
 
+// RemoveCommand deletes the character before the cursor in the command line.
 func (w *Window) RemoveCommand() {
-	if len(w.command) > 0 {
-		w.command = w.command[:len(w.command)-1]
+	at := len(w.command) - w.commandRight
+	if at > 0 {
+		w.command = concat(w.command[:runesBefore(w.command, at, 1)], w.command[at:])
+		w.editedCommand()
 	}
 }
 
 func (w *Window) ResetCommand() {
 	w.command = []byte{}
+	w.commandRight = 0
+	w.commandRegister = false
+	w.completion = nil
 }
 
 func (w *Window) TypedCommand() string {
 	return string(w.command)
 }
 
-func (w *Window) ExecuteCommand() {
-}
-
 func (w *Window) InputtedUp() {
 	// if cursor is top, don't move
 	if w.position.Y == 1 {
@@ -138,8 +220,6 @@ func (w *Window) InputtedUp() {
 		}
 	}
 	w.position.MoveUp(1)
-	fmt.Fprintf(w.Output, "\033[%d;%dH> X: %d, Y: %d, Up    ", w.Row, 0, w.position.X, w.position.Y)
-	w.MoveCursorToCurrentPosition()
 }
 
 func (w *Window) InputtedDown() {
@@ -160,14 +240,10 @@ func (w *Window) InputtedDown() {
 		}
 	}
 	w.position.MoveDown(1)
-	fmt.Fprintf(w.Output, "\033[%d;%dH> X: %d, Y: %d, Down  ", w.Row, 0, w.position.X, w.position.Y)
-	w.MoveCursorToCurrentPosition()
 }
 
 func (w *Window) InputtedLeft() {
 	w.position.MoveLeft(1)
-	fmt.Fprintf(w.Output, "\033[%d;%dH> X: %d, Y: %d, Left  ", w.Row, 0, w.position.X, w.position.Y)
-	w.MoveCursorToCurrentPosition()
 }
 
 func (w *Window) InputtedRight() {
@@ -178,101 +254,327 @@ func (w *Window) InputtedRight() {
 		limitX = len(w.FileContents[w.position.Y-1])
 	}
 	if limitX <= w.position.X {
-		w.MoveCursorToCurrentPosition()
 		return
 	}
 	w.position.MoveRight(1)
-	fmt.Fprintf(w.Output, "\033[%d;%dH> X: %d, Y: %d, Right", w.Row, 0, w.position.X, w.position.Y)
-	w.MoveCursorToCurrentPosition()
 }
 
 func (w *Window) InputtedOther(b []byte) {
 	switch w.mode {
 	case normalMode:
-		if string(b) == "i" {
-			w.SetInsertMode()
-			return
-		}
-		if string(b) == ":" {
-			w.SetCommandMode()
-			fmt.Fprintf(w.Output, "\033[%d;%dH:", w.Size.Row, 0)
-			return
-		}
-		fmt.Fprintf(w.Output, "\033[%d;%dH> X: %d, Y: %d, input: %s     ", w.Row, 0, w.position.X, w.position.Y, string(b))
-		w.MoveCursorToCurrentPosition()
+		w.typeNormal(b)
+	case visualMode:
+		w.typeVisual(b)
 	case insertMode:
-		be := w.FileContents[w.position.Y-1][:w.position.X-1]
-		af := w.FileContents[w.position.Y-1][w.position.X-1:]
-		w.FileContents[w.position.Y-1] = []byte(string(be) + string(b) + string(af))
-		w.position.MoveRight(1)
-		fmt.Fprintf(w.Output, "\033[%d;%dH%s", w.position.Y, 0, string(w.FileContents[w.position.Y-1]))
-		w.MoveCursorToCurrentPosition()
+		k := KeyEvent{Key: prompt.NotDefined, Data: b}
+		w.recordInsert(k)
+		w.insertKey(k)
 	}
 }
 
+// GetKey returns the key of b, which holds exactly one key.
 func (w *Window) GetKey(b []byte) prompt.Key {
-	for _, k := range asciiSequences {
-		if bytes.Equal(k.ASCIICode, b) {
-			return k.Key
+	d := &KeyDecoder{keys: w.KeyDecoder().keys}
+	events := append(d.Feed(b), d.Flush()...)
+	if len(events) != 1 {
+		return prompt.NotDefined
+	}
+	return events[0].Key
+}
+
+// KeyDecoder returns the decoder of the keys of the terminal.
+func (w *Window) KeyDecoder() *KeyDecoder {
+	if w.decoder == nil {
+		var ti *terminfo.Terminfo
+		if w.Terminal != nil {
+			ti = w.Terminal.Terminfo()
 		}
+		w.decoder = NewKeyDecoder(ti)
 	}
-	return prompt.NotDefined
+	return w.decoder
 }
 
 func (w *Window) SetSize() error {
-	var err error
-	w.Column, w.Row, err = terminal.GetSize(int(w.Input.Fd()))
+	size, err := w.Terminal.Size()
 	if err != nil {
 		return err
 	}
+	w.Size = size
 	return nil
 }
 
-// PrintFileContents outputs the contents passed by fc (usually the contents of the read file)
-// to the location specified by Output.
-// The file contents are not printed  on the last line.
+// PrintFileContents draws the file contents (usually the contents of the read file),
+// and the command line or a message on the last line, then brings the terminal up to date
+// by writing only what changed since the last call.
 func (w *Window) PrintFileContents() {
-	fmt.Fprint(w.Output, "\033[H\033[2J")
-	for i := 0; i < w.Row-1; i++ {
-		if len(w.FileContents) <= i {
-			fmt.Fprintln(w.Output, "")
+	t := w.Terminal
+	normal := w.style("Normal")
+	t.Clear(normal)
+	w.scrollToCursor()
+	parens := w.matchParen()
+	for row, i := 0, w.top; row < w.Row-1 && i < len(w.FileContents); i++ {
+		row += w.drawLine(row, i, normal, parens)
+	}
+	row, col := w.cursorCell()
+	if w.IsCommandMode() {
+		w.drawWildMenu()
+	}
+	if r, c, ok := w.drawMessage(normal); ok {
+		row, col = r, c
+	}
+	t.ShowCursor(row, col)
+	t.Flush()
+}
+
+// style returns how the highlight group is drawn, ex) Comment
+func (w *Window) style(group string) syntax.Style {
+	if w.theme == nil || w.Terminal == nil {
+		return syntax.Style{}
+	}
+	return w.theme.Style(group, w.Terminal.Colors())
+}
+
+// drawLine draws the i-th line (0-indexed) of the file contents from the screen row, and returns
+// the number of rows it takes. parens are the brackets highlighted, see matchParen.
+func (w *Window) drawLine(row, i int, normal syntax.Style, parens []Position) int {
+	line := w.FileContents[i]
+	var tokens []syntax.Token
+	if w.highlighter != nil {
+		tokens = w.highlighter.Tokens(w.FileContents, i)
+	}
+	gutter, width, wrap := w.gutterWidth(), w.textColumns(), w.boolOption("wrap")
+	if gutter > 0 {
+		w.drawString(row, 0, fmt.Sprintf("%*d ", gutter-1, i+1), w.style("LineNr").Over(normal))
+	}
+	// setCell draws a character at the column col of the line, on the row it is wrapped to
+	setCell := func(col int, c Cell) bool {
+		r := row
+		if wrap {
+			r, col = row+col/width, col%width
 		} else {
-			fmt.Fprintf(w.Output, "%s\n", w.FileContents[i])
+			col -= w.leftCol
 		}
+		if r >= w.textRows() || col >= width {
+			return false
+		}
+		if col >= 0 {
+			w.Terminal.SetCell(r, gutter+col, c)
+		}
+		return true
 	}
-	w.MoveCursorToCurrentPosition()
+	from, to := w.selection(i)
+	visual := w.style("Visual")
+	col := 0
+chars:
+	for b := 0; b < len(line); {
+		r, size := utf8.DecodeRune(line[b:])
+		for len(tokens) > 0 && tokens[0].End <= b {
+			tokens = tokens[1:]
+		}
+		style := normal
+		if len(tokens) > 0 && tokens[0].Start <= b {
+			style = w.style(tokens[0].Group).Over(normal)
+		}
+		if from <= b && b < to {
+			style = visual.Over(style)
+		}
+		for _, p := range parens {
+			if p == (Position{X: b + 1, Y: i + 1}) {
+				style = w.style("MatchParen").Over(style)
+			}
+		}
+		if r == '\t' {
+			for n := w.tabWidth(col); n > 0; n-- {
+				if !setCell(col, Cell{Ch: ' ', Style: style}) {
+					break chars
+				}
+				col++
+			}
+		} else if s := escapedChar(r, size, line[b]); s != "" {
+			special := w.style("SpecialKey").Over(style)
+			for _, c := range s {
+				if !setCell(col, Cell{Ch: c, Style: special}) {
+					break chars
+				}
+				col++
+			}
+		} else {
+			if !setCell(col, Cell{Ch: r, Style: style}) {
+				break
+			}
+			col += cellWidth(r)
+		}
+		b += size
+	}
+	rows := w.lineRows(i)
+	if row+rows > w.textRows() {
+		rows = w.textRows() - row
+	}
+	return rows
 }
 
-func (w *Window) MoveCursorToCurrentPosition() {
-	fmt.Fprintf(w.Output, "\033[%d;%dH", w.position.Y, w.position.X)
+// selection returns the bytes [from, to) of the i-th line (0-indexed) selected in visual mode.
+func (w *Window) selection(i int) (from, to int) {
+	if w.mode != visualMode {
+		return 0, 0
+	}
+	start, end := w.visualStart, w.position
+	if end.Y < start.Y || end.Y == start.Y && end.X < start.X {
+		start, end = end, start
+	}
+	y := i + 1
+	if y < start.Y || y > end.Y {
+		return 0, 0
+	}
+	line := w.FileContents[i]
+	from, to = 0, len(line)
+	if y == start.Y {
+		from = start.X - 1
+	}
+	if y == end.Y && end.X <= len(line) {
+		// the character under the cursor is selected too
+		_, size := utf8.DecodeRune(line[end.X-1:])
+		to = end.X - 1 + size
+	}
+	return from, to
+}
+
+// tabWidth returns the width of a tab at the screen column col, up to the next multiple of tabstop.
+func (w *Window) tabWidth(col int) int {
+	ts := w.numberOption("tabstop")
+	return ts - col%ts
+}
+
+// escapedChar returns how the character r of size bytes starting with the byte first is shown
+// when it cannot be shown as it is: a control character as ^@, and a byte that is not UTF-8
+// or a control character of Latin-1 as <xx>, ex) <ff>
+// It returns an empty string for the other characters.
+func escapedChar(r rune, size int, first byte) string {
+	switch {
+	case r == utf8.RuneError && size == 1:
+		return fmt.Sprintf("<%02x>", first)
+	case r < ' ':
+		return "^" + string(r+'@')
+	case r == 0x7f:
+		return "^?"
+	case r >= 0x80 && r < 0xa0:
+		return fmt.Sprintf("<%02x>", r)
+	}
+	return ""
+}
+
+// charWidth returns the columns the character r of size bytes starting with the byte first takes
+// at the screen column col.
+func (w *Window) charWidth(r rune, size int, first byte, col int) int {
+	if r == '\t' {
+		return w.tabWidth(col)
+	}
+	if s := escapedChar(r, size, first); s != "" {
+		return len(s)
+	}
+	return cellWidth(r)
 }
 
-func (w *Window) ReadBuffer(bufCh chan []byte) {
-	buf := make([]byte, 1024)
+// displayColumn returns the screen column (0-indexed) of the x-th byte (1-indexed) of the i-th line.
+func (w *Window) displayColumn(i, x int) int {
+	if i < 0 || i >= len(w.FileContents) {
+		return x - 1
+	}
+	line := w.FileContents[i]
+	col := 0
+	for b := 0; b < len(line) && b < x-1; {
+		r, size := utf8.DecodeRune(line[b:])
+		col += w.charWidth(r, size, line[b], col)
+		b += size
+	}
+	if x-1 > len(line) {
+		// past the end of the line, ex) in insert mode
+		col += x - 1 - len(line)
+	}
+	return col
+}
 
-	reader := bufio.NewReader(w.Input)
-	for {
-		if n, err := reader.Read(buf); err == nil {
-			bufCh <- buf[:n]
+// drawString draws text from row, col and returns the column after it.
+func (w *Window) drawString(row, col int, text string, style syntax.Style) int {
+	for _, r := range text {
+		w.Terminal.SetCell(row, col, Cell{Ch: r, Style: style})
+		col += cellWidth(r)
+	}
+	return col
+}
+
+// drawMessage draws the command line or the message on the last line.
+// It returns where the cursor goes when it is not in the text.
+func (w *Window) drawMessage(normal syntax.Style) (row, col int, ok bool) {
+	last := w.Row - 1
+	if w.IsCommandMode() {
+		at := len(w.command) - w.commandRight
+		col := w.drawString(last, 0, string(w.commandPrompt())+printable(w.command[:at], false), normal)
+		w.drawString(last, col, printable(w.command[at:], false), normal)
+		return last, col, true
+	}
+	if w.message == "" {
+		msg := w.recordingMessage()
+		if msg == "" && w.cmdwin != nil {
+			msg = "[Command Line]"
 		}
+		w.drawString(last, 0, msg, normal)
+		return 0, 0, false
+	}
+	style := normal
+	if w.messageGroup != "" {
+		style = w.style(w.messageGroup)
+	}
+	lines := strings.Split(w.message, "\n")
+	if !w.hitEnter {
+		w.drawString(last, 0, lines[len(lines)-1], style)
+		return 0, 0, false
+	}
+	lines = append(lines, hitEnterPrompt)
+	if len(lines) > w.Row {
+		lines = lines[len(lines)-w.Row:]
 	}
+	top := w.Row - len(lines)
+	for i, l := range lines {
+		w.drawString(top+i, 0, strings.Repeat(" ", w.Column), normal)
+		if i == len(lines)-1 {
+			col = w.drawString(top+i, 0, l, w.style("MoreMsg"))
+		} else {
+			w.drawString(top+i, 0, l, style)
+		}
+	}
+	return last, col, true
 }
 
+// SetFileContents reads the file fileName into the buffer. A file that does not exist is a new file.
 func (w *Window) SetFileContents(fileName string) error {
-	file, err := os.Open(fileName)
+	lines, f, newFile, err := openLines(fileName)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
+	w.FileContents = lines
+	w.fileName = fileName
+	w.fileStamp = statFile(fileName)
+	w.setFileFormat(f)
+	if newFile {
+		w.showMessage(newFileInfo(fileName), "")
+	}
+	w.detectFileType()
+	return nil
+}
 
-	sc := bufio.NewScanner(file)
-	for sc.Scan() {
-		w.FileContents = append(w.FileContents, []byte(sc.Text()))
+// detectFileType sets the file type of the buffer from its name and first line, and highlights it.
+func (w *Window) detectFileType() {
+	var firstLine []byte
+	if len(w.FileContents) > 0 {
+		firstLine = w.FileContents[0]
 	}
-	if err := sc.Err(); err != nil {
-		return err
+	w.fileType = syntax.Detect(w.fileName, firstLine)
+	w.highlighter = nil
+	w.indentCache = nil
+	if g := syntax.Lookup(w.fileType); g != nil {
+		w.highlighter = syntax.NewHighlighter(g)
 	}
-	return nil
 }
 
 type Position struct {
@@ -2,11 +2,12 @@ package window
 
 import (
 	"bytes"
-	"io"
-	"os"
 	"reflect"
 	"testing"
 
+	"gim/syntax"
+	"gim/terminfo"
+
 	prompt "github.com/c-bata/go-prompt"
 )
 
@@ -21,7 +22,7 @@ func TestNewWindow(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := NewWindow(os.Stdin, os.Stdout)
+			got := NewWindow(NewSimTerminal(80, 24))
 			if got.position.X != tt.wantX || got.position.Y != tt.wantY {
 				t.Errorf(
 					"got: X=%d, Y=%d, want: X=%d, Y=%d", got.position.X, got.position.Y, tt.wantX, tt.wantY)
@@ -293,11 +294,11 @@ func TestWindow_InputtedUp(t *testing.T) {
 		mode         int
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		wantX   int
-		wantY   int
-		wantOut []byte
+		name        string
+		fields      fields
+		wantX       int
+		wantY       int
+		wantMessage string
 	}{
 		{
 			name: "Y=1",
@@ -310,9 +311,9 @@ func TestWindow_InputtedUp(t *testing.T) {
 				position:     Position{X: 1, Y: 1},
 				mode:         normalMode,
 			},
-			wantX:   1,
-			wantY:   1,
-			wantOut: []byte(""),
+			wantX:       1,
+			wantY:       1,
+			wantMessage: "",
 		},
 		{
 			name: "Upper character length is greater than current X",
@@ -325,9 +326,9 @@ func TestWindow_InputtedUp(t *testing.T) {
 				position:     Position{X: 7, Y: 3},
 				mode:         normalMode,
 			},
-			wantX:   7,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 7, Y: 2, Up    \033[2;7H"),
+			wantX:       7,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Upper character length is equal current X",
@@ -340,9 +341,9 @@ func TestWindow_InputtedUp(t *testing.T) {
 				position:     Position{X: 8, Y: 3},
 				mode:         normalMode,
 			},
-			wantX:   8,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 8, Y: 2, Up    \033[2;8H"),
+			wantX:       8,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Upper character length is less than current X (not zero), normal mode",
@@ -355,9 +356,9 @@ func TestWindow_InputtedUp(t *testing.T) {
 				position:     Position{X: 10, Y: 3},
 				mode:         normalMode,
 			},
-			wantX:   8,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 8, Y: 2, Up    \033[2;8H"),
+			wantX:       8,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Upper character length is less than current X (not zero), insert mode",
@@ -370,9 +371,9 @@ func TestWindow_InputtedUp(t *testing.T) {
 				position:     Position{X: 10, Y: 3},
 				mode:         insertMode,
 			},
-			wantX:   9,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 9, Y: 2, Up    \033[2;9H"),
+			wantX:       9,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Upper character length is less than current X (zero)",
@@ -385,17 +386,15 @@ func TestWindow_InputtedUp(t *testing.T) {
 				position:     Position{X: 10, Y: 3},
 				mode:         normalMode,
 			},
-			wantX:   1,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 1, Y: 2, Up    \033[2;1H"),
+			wantX:       1,
+			wantY:       2,
+			wantMessage: "",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out := new(bytes.Buffer)
 			w := &Window{
 				Size:         tt.fields.Size,
-				Output:       out,
 				FileContents: tt.fields.FileContents,
 				position:     tt.fields.position,
 				mode:         tt.fields.mode,
@@ -404,8 +403,8 @@ func TestWindow_InputtedUp(t *testing.T) {
 			if tt.wantX != w.position.X || tt.wantY != w.position.Y {
 				t.Errorf("got: X= %d, Y=%d  want: X=%d, Y=%d", w.position.X, w.position.Y, tt.wantX, tt.wantY)
 			}
-			if out.String() != string(tt.wantOut) {
-				t.Errorf("got: %v, want:  %v", out.Bytes(), tt.wantOut)
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
 			}
 		})
 	}
@@ -419,11 +418,11 @@ func TestWindow_InputtedDown(t *testing.T) {
 		mode         int
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		wantX   int
-		wantY   int
-		wantOut []byte
+		name        string
+		fields      fields
+		wantX       int
+		wantY       int
+		wantMessage string
 	}{
 		{
 			name: "Y= File lines",
@@ -436,9 +435,9 @@ func TestWindow_InputtedDown(t *testing.T) {
 				position:     Position{X: 1, Y: 2},
 				mode:         normalMode,
 			},
-			wantX:   1,
-			wantY:   2,
-			wantOut: []byte(""),
+			wantX:       1,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Lower character length is greater than current X",
@@ -451,9 +450,9 @@ func TestWindow_InputtedDown(t *testing.T) {
 				position:     Position{X: 7, Y: 1},
 				mode:         normalMode,
 			},
-			wantX:   7,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 7, Y: 2, Down  \033[2;7H"),
+			wantX:       7,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Lower character length is equal current X",
@@ -466,9 +465,9 @@ func TestWindow_InputtedDown(t *testing.T) {
 				position:     Position{X: 8, Y: 1},
 				mode:         normalMode,
 			},
-			wantX:   8,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 8, Y: 2, Down  \033[2;8H"),
+			wantX:       8,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Lower character length is less than current X (not zero), normal mode",
@@ -481,9 +480,9 @@ func TestWindow_InputtedDown(t *testing.T) {
 				position:     Position{X: 10, Y: 1},
 				mode:         normalMode,
 			},
-			wantX:   8,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 8, Y: 2, Down  \033[2;8H"),
+			wantX:       8,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Lower character length is less than current X (not zero), insert mode",
@@ -496,9 +495,9 @@ func TestWindow_InputtedDown(t *testing.T) {
 				position:     Position{X: 10, Y: 1},
 				mode:         insertMode,
 			},
-			wantX:   9,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 9, Y: 2, Down  \033[2;9H"),
+			wantX:       9,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "Upper character length is less than current X (zero)",
@@ -511,17 +510,15 @@ func TestWindow_InputtedDown(t *testing.T) {
 				position:     Position{X: 10, Y: 1},
 				mode:         normalMode,
 			},
-			wantX:   1,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 1, Y: 2, Down  \033[2;1H"),
+			wantX:       1,
+			wantY:       2,
+			wantMessage: "",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out := new(bytes.Buffer)
 			w := &Window{
 				Size:         tt.fields.Size,
-				Output:       out,
 				FileContents: tt.fields.FileContents,
 				position:     tt.fields.position,
 				mode:         tt.fields.mode,
@@ -530,8 +527,8 @@ func TestWindow_InputtedDown(t *testing.T) {
 			if tt.wantX != w.position.X || tt.wantY != w.position.Y {
 				t.Errorf("got: X= %d, Y=%d  want: X=%d, Y=%d", w.position.X, w.position.Y, tt.wantX, tt.wantY)
 			}
-			if out.String() != string(tt.wantOut) {
-				t.Errorf("got: %v, want:  %v", out.Bytes(), tt.wantOut)
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
 			}
 		})
 	}
@@ -544,11 +541,11 @@ func TestWindow_InputtedLeft(t *testing.T) {
 		position     Position
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		wantX   int
-		wantY   int
-		wantOut []byte
+		name        string
+		fields      fields
+		wantX       int
+		wantY       int
+		wantMessage string
 	}{
 		{
 			name: "X=1",
@@ -560,9 +557,9 @@ func TestWindow_InputtedLeft(t *testing.T) {
 				FileContents: [][]byte{[]byte("Hello World!"), []byte("I am bob"), []byte("OK ?")},
 				position:     Position{X: 1, Y: 3},
 			},
-			wantX:   1,
-			wantY:   3,
-			wantOut: []byte("\033[100;0H> X: 1, Y: 3, Left  \033[3;1H"),
+			wantX:       1,
+			wantY:       3,
+			wantMessage: "",
 		},
 		{
 			name: "X>1",
@@ -574,17 +571,15 @@ func TestWindow_InputtedLeft(t *testing.T) {
 				FileContents: [][]byte{[]byte("Hello World!"), []byte("I am bob"), []byte("OK ?")},
 				position:     Position{X: 3, Y: 3},
 			},
-			wantX:   2,
-			wantY:   3,
-			wantOut: []byte("\033[100;0H> X: 2, Y: 3, Left  \033[3;2H"),
+			wantX:       2,
+			wantY:       3,
+			wantMessage: "",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out := new(bytes.Buffer)
 			w := &Window{
 				Size:         tt.fields.Size,
-				Output:       out,
 				FileContents: tt.fields.FileContents,
 				position:     tt.fields.position,
 			}
@@ -592,8 +587,8 @@ func TestWindow_InputtedLeft(t *testing.T) {
 			if tt.wantX != w.position.X || tt.wantY != w.position.Y {
 				t.Errorf("got: X= %d, Y=%d  want: X=%d, Y=%d", w.position.X, w.position.Y, tt.wantX, tt.wantY)
 			}
-			if out.String() != string(tt.wantOut) {
-				t.Errorf("got: %v, want:  %v", out.Bytes(), tt.wantOut)
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
 			}
 		})
 	}
@@ -607,11 +602,11 @@ func TestWindow_InputtedRight(t *testing.T) {
 		mode         int
 	}
 	tests := []struct {
-		name    string
-		fields  fields
-		wantX   int
-		wantY   int
-		wantOut []byte
+		name        string
+		fields      fields
+		wantX       int
+		wantY       int
+		wantMessage string
 	}{
 		{
 			name: "X=character length, normal mode",
@@ -624,9 +619,9 @@ func TestWindow_InputtedRight(t *testing.T) {
 				position:     Position{X: 8, Y: 2},
 				mode:         normalMode,
 			},
-			wantX:   8,
-			wantY:   2,
-			wantOut: []byte("\033[2;8H"),
+			wantX:       8,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "X=character length, insert mode",
@@ -639,9 +634,9 @@ func TestWindow_InputtedRight(t *testing.T) {
 				position:     Position{X: 8, Y: 2},
 				mode:         insertMode,
 			},
-			wantX:   9,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 9, Y: 2, Right\033[2;9H"),
+			wantX:       9,
+			wantY:       2,
+			wantMessage: "",
 		},
 		{
 			name: "X<character length",
@@ -654,17 +649,15 @@ func TestWindow_InputtedRight(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         normalMode,
 			},
-			wantX:   4,
-			wantY:   2,
-			wantOut: []byte("\033[100;0H> X: 4, Y: 2, Right\033[2;4H"),
+			wantX:       4,
+			wantY:       2,
+			wantMessage: "",
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out := new(bytes.Buffer)
 			w := &Window{
 				Size:         tt.fields.Size,
-				Output:       out,
 				FileContents: tt.fields.FileContents,
 				position:     tt.fields.position,
 				mode:         tt.fields.mode,
@@ -673,8 +666,8 @@ func TestWindow_InputtedRight(t *testing.T) {
 			if tt.wantX != w.position.X || tt.wantY != w.position.Y {
 				t.Errorf("got: X= %d, Y=%d  want: X=%d, Y=%d", w.position.X, w.position.Y, tt.wantX, tt.wantY)
 			}
-			if out.String() != string(tt.wantOut) {
-				t.Errorf("got: %v, want:  %v", out.Bytes(), tt.wantOut)
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
 			}
 		})
 	}
@@ -688,13 +681,14 @@ func TestWindow_InputtedOther(t *testing.T) {
 		mode         int
 	}
 	tests := []struct {
-		name     string
-		fields   fields
-		input    []byte
-		wantX    int
-		wantY    int
-		wantOut  []byte
-		wantMode int
+		name        string
+		fields      fields
+		input       []byte
+		wantX       int
+		wantY       int
+		wantMessage string
+		wantLine    string
+		wantMode    int
 	}{
 		{
 			name: "inputted i and not insert mode",
@@ -707,11 +701,12 @@ func TestWindow_InputtedOther(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         normalMode,
 			},
-			input:    []byte("i"),
-			wantX:    3,
-			wantY:    2,
-			wantOut:  []byte(""),
-			wantMode: insertMode,
+			input:       []byte("i"),
+			wantX:       3,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I am bob",
+			wantMode:    insertMode,
 		},
 		{
 			name: "inputted i and insert mode",
@@ -724,11 +719,12 @@ func TestWindow_InputtedOther(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         insertMode,
 			},
-			input:    []byte("i"),
-			wantX:    4,
-			wantY:    2,
-			wantOut:  []byte("\033[2;0HI iam bob\033[2;4H"),
-			wantMode: insertMode,
+			input:       []byte("i"),
+			wantX:       4,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I iam bob",
+			wantMode:    insertMode,
 		},
 		{
 			name: "inputted : and not insert mode",
@@ -741,11 +737,12 @@ func TestWindow_InputtedOther(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         normalMode,
 			},
-			input:    []byte(":"),
-			wantX:    3,
-			wantY:    2,
-			wantOut:  []byte("\033[100;0H:"),
-			wantMode: commandMode,
+			input:       []byte(":"),
+			wantX:       3,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I am bob",
+			wantMode:    commandMode,
 		},
 		{
 			name: "inputted : and insert mode",
@@ -758,11 +755,12 @@ func TestWindow_InputtedOther(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         insertMode,
 			},
-			input:    []byte(":"),
-			wantX:    4,
-			wantY:    2,
-			wantOut:  []byte("\033[2;0HI :am bob\033[2;4H"),
-			wantMode: insertMode,
+			input:       []byte(":"),
+			wantX:       4,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I :am bob",
+			wantMode:    insertMode,
 		},
 		{
 			name: "inputted not i and insert mode",
@@ -775,11 +773,30 @@ func TestWindow_InputtedOther(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         insertMode,
 			},
-			input:    []byte("A"),
-			wantX:    4,
-			wantY:    2,
-			wantOut:  []byte("\033[2;0HI Aam bob\033[2;4H"),
-			wantMode: insertMode,
+			input:       []byte("A"),
+			wantX:       4,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I Aam bob",
+			wantMode:    insertMode,
+		},
+		{
+			name: "inputted multibyte character and insert mode",
+			fields: fields{
+				Size: Size{
+					Row:    100,
+					Column: 150,
+				},
+				FileContents: [][]byte{[]byte("Hello World!"), []byte("I am bob")},
+				position:     Position{X: 3, Y: 2},
+				mode:         insertMode,
+			},
+			input:       []byte("あ"),
+			wantX:       6,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I あam bob",
+			wantMode:    insertMode,
 		},
 		{
 			name: "inputted not i and not insert mode",
@@ -792,19 +809,18 @@ func TestWindow_InputtedOther(t *testing.T) {
 				position:     Position{X: 3, Y: 2},
 				mode:         normalMode,
 			},
-			input:    []byte("A"),
-			wantX:    3,
-			wantY:    2,
-			wantOut:  []byte("\033[100;0H> X: 3, Y: 2, input: A     \033[2;3H"),
-			wantMode: normalMode,
+			input:       []byte("Q"),
+			wantX:       3,
+			wantY:       2,
+			wantMessage: "",
+			wantLine:    "I am bob",
+			wantMode:    normalMode,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out := new(bytes.Buffer)
 			w := &Window{
 				Size:         tt.fields.Size,
-				Output:       out,
 				FileContents: tt.fields.FileContents,
 				position:     tt.fields.position,
 				mode:         tt.fields.mode,
@@ -813,8 +829,11 @@ func TestWindow_InputtedOther(t *testing.T) {
 			if tt.wantX != w.position.X || tt.wantY != w.position.Y {
 				t.Errorf("got: X= %d, Y=%d  want: X=%d, Y=%d", w.position.X, w.position.Y, tt.wantX, tt.wantY)
 			}
-			if out.String() != string(tt.wantOut) {
-				t.Errorf("got: %v, want:  %v", out.Bytes(), tt.wantOut)
+			if w.message != tt.wantMessage {
+				t.Errorf("got: %q, want: %q", w.message, tt.wantMessage)
+			}
+			if got := string(w.FileContents[w.position.Y-1]); got != tt.wantLine {
+				t.Errorf("got: %q, want: %q", got, tt.wantLine)
 			}
 			if tt.wantMode != w.mode {
 				t.Errorf("got: mode=%d, want: mode=%d", w.mode, tt.wantMode)
@@ -826,14 +845,15 @@ func TestWindow_InputtedOther(t *testing.T) {
 func TestWindow_PrintFileContents(t *testing.T) {
 	type fields struct {
 		Size         Size
-		Output       io.Writer
 		FileContents [][]byte
 		position     Position
 	}
 	tests := []struct {
-		name   string
-		fields fields
-		want   []byte
+		name     string
+		fields   fields
+		wantRows []string
+		wantRow  int
+		wantCol  int
 	}{
 		{
 			name: "file row + 2 == window row",
@@ -845,7 +865,9 @@ func TestWindow_PrintFileContents(t *testing.T) {
 					Y: 3,
 				},
 			},
-			want: []byte("\033[H\033[2JHello World!\nI am bob\n\n\033[3;2H"),
+			wantRows: []string{"Hello World!", "I am bob", "", ""},
+			wantRow:  2,
+			wantCol:  1,
 		},
 		{
 			name: "file row + 1 == window row",
@@ -857,10 +879,12 @@ func TestWindow_PrintFileContents(t *testing.T) {
 					Y: 2,
 				},
 			},
-			want: []byte("\033[H\033[2JHello World!\nI am bob\n\033[2;1H"),
+			wantRows: []string{"Hello World!", "I am bob", ""},
+			wantRow:  1,
+			wantCol:  0,
 		},
 		{
-			name: "file row  == window row",
+			name: "file row  == window row, scrolled to the cursor",
 			fields: fields{
 				Size:         Size{Row: 2, Column: 100},
 				FileContents: [][]byte{[]byte("Hello World!"), []byte("I am bob")},
@@ -869,21 +893,56 @@ func TestWindow_PrintFileContents(t *testing.T) {
 					Y: 2,
 				},
 			},
-			want: []byte("\033[H\033[2JHello World!\n\033[2;3H"),
+			wantRows: []string{"I am bob", ""},
+			wantRow:  0,
+			wantCol:  2,
+		},
+		{
+			name: "tab and wide characters",
+			fields: fields{
+				Size:         Size{Row: 2, Column: 100},
+				FileContents: [][]byte{[]byte("\tこんにちは x")},
+				position: Position{
+					X: 5,
+					Y: 1,
+				},
+			},
+			wantRows: []string{"        こんにちは x", ""},
+			wantRow:  0,
+			wantCol:  10,
+		},
+		{
+			name: "control characters and bytes not UTF-8",
+			fields: fields{
+				Size:         Size{Row: 2, Column: 100},
+				FileContents: [][]byte{[]byte("a\x00b\x7f\xffc\u0085d")},
+				position: Position{
+					X: 6,
+					Y: 1,
+				},
+			},
+			wantRows: []string{"a^@b^?<ff>c<85>d", ""},
+			wantRow:  0,
+			wantCol:  10,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			out := new(bytes.Buffer)
+			term := NewSimTerminal(tt.fields.Size.Column, tt.fields.Size.Row)
 			w := &Window{
 				Size:         tt.fields.Size,
-				Output:       out,
+				Terminal:     term,
 				FileContents: tt.fields.FileContents,
 				position:     tt.fields.position,
 			}
-
-			if w.PrintFileContents(); out.String() != string(tt.want) {
-				t.Errorf("got: %v, want:  %v", out.Bytes(), tt.want)
+			w.PrintFileContents()
+			for i, want := range tt.wantRows {
+				if got := term.Row(i); got != want {
+					t.Errorf("got: row %d = %q, want: %q", i, got, want)
+				}
+			}
+			if row, col := term.Cursor(); row != tt.wantRow || col != tt.wantCol {
+				t.Errorf("got: cursor = %d, %d, want: %d, %d", row, col, tt.wantRow, tt.wantCol)
 			}
 		})
 	}
@@ -928,7 +987,8 @@ func TestWindow_SetFileContentsFileExist(t *testing.T) {
 		wantErr bool
 	}{
 		{name: "file exists", args: args{fileName: "../testdata/test.txt"}, wantErr: false},
-		{name: "file not exists", args: args{fileName: "../testdata/non_test.txt"}, wantErr: true},
+		{name: "file not exists", args: args{fileName: "../testdata/non_test.txt"}, wantErr: false},
+		{name: "directory", args: args{fileName: "../testdata"}, wantErr: true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -968,6 +1028,39 @@ func TestGetKey(t *testing.T) {
 	}
 }
 
+func TestWindow_GetKeyTerminfo(t *testing.T) {
+	linux := &terminfo.Terminfo{Strings: map[string]string{
+		"kf1":   "\033[[A",
+		"khome": "\033[1~",
+		"knp":   "\033[6~",
+		"kcuu1": "\033[A",
+	}}
+	tests := []struct {
+		name string
+		caps *terminfo.Terminfo
+		b    []byte
+		want prompt.Key
+	}{
+		{name: "function key of the terminal", caps: linux, b: []byte("\033[[A"), want: prompt.F1},
+		{name: "page down", caps: linux, b: []byte("\033[6~"), want: prompt.PageDown},
+		{name: "home", caps: linux, b: []byte("\033[1~"), want: prompt.Home},
+		{name: "application cursor key", caps: linux, b: []byte("\033OA"), want: prompt.Up},
+		{name: "xterm function key", caps: terminfo.Xterm(), b: []byte("\033OP"), want: prompt.F1},
+		{name: "xterm shifted arrow", caps: terminfo.Xterm(), b: []byte("\033[1;2D"), want: prompt.ShiftLeft},
+		{name: "unknown sequence", caps: linux, b: []byte("\033[99~"), want: prompt.Ignore},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(10, 2)
+			term.Caps = tt.caps
+			w := NewWindow(term)
+			if got := w.GetKey(tt.b); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestPosition_MoveDown(t *testing.T) {
 	type fields struct {
 		X int
@@ -1085,3 +1178,153 @@ func TestPosition_MoveLeft(t *testing.T) {
 		})
 	}
 }
+
+func TestWindow_SetFileContentsFileType(t *testing.T) {
+	tests := []struct {
+		name            string
+		fileName        string
+		wantFileType    string
+		wantHighlighter bool
+	}{
+		{name: "go file", fileName: "../testdata/test.go", wantFileType: "go", wantHighlighter: true},
+		{name: "text file", fileName: "../testdata/test.txt", wantFileType: "", wantHighlighter: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Window{}
+			if err := w.SetFileContents(tt.fileName); err != nil {
+				t.Fatal(err)
+			}
+			if w.fileType != tt.wantFileType {
+				t.Errorf("fileType = %q, want %q", w.fileType, tt.wantFileType)
+			}
+			if (w.highlighter != nil) != tt.wantHighlighter {
+				t.Errorf("highlighter = %v, want highlighter: %v", w.highlighter, tt.wantHighlighter)
+			}
+		})
+	}
+}
+
+func TestWindow_PrintFileContentsHighlighted(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorMode syntax.ColorMode
+		want      string
+	}{
+		{
+			name:      "256 colours",
+			colorMode: syntax.Color256,
+			want:      "\033[0m\033[H\033[2J\033[0;1;38;5;68mpackage \033[0mmain\033[3;1H\033[0;38;5;244m// main says hello\033[0m\033[1;1H",
+		},
+		{
+			name:      "true colour",
+			colorMode: syntax.TrueColor,
+			want:      "\033[0m\033[H\033[2J\033[0;1;38;2;95;135;215mpackage \033[0mmain\033[3;1H\033[0;38;2;128;128;128m// main says hello\033[0m\033[1;1H",
+		},
+		{
+			name:      "16 colours",
+			colorMode: syntax.Color16,
+			want:      "\033[0m\033[H\033[2J\033[0;1;94mpackage \033[0mmain\033[3;1H\033[0;90m// main says hello\033[0m\033[1;1H",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(100, 4)
+			term.ColorMode = tt.colorMode
+			w := NewWindow(term)
+			w.SetSize()
+			if err := w.SetFileContents("../testdata/test.go"); err != nil {
+				t.Fatal(err)
+			}
+			if w.PrintFileContents(); term.Out.String() != tt.want {
+				t.Errorf("got: %q, want: %q", term.Out.String(), tt.want)
+			}
+			if got, want := term.Cell(0, 0).Style, w.style("Keyword"); got != want {
+				t.Errorf("got: style of package = %+v, want: %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestWindow_PrintFileContentsDifference(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(w *Window)
+		want   string
+	}{
+		{
+			name:   "nothing changed",
+			update: func(w *Window) {},
+			want:   "",
+		},
+		{
+			name:   "cursor moved",
+			update: func(w *Window) { w.InputtedDown() },
+			want:   "\033[2;1H",
+		},
+		{
+			name: "character inserted",
+			update: func(w *Window) {
+				w.SetInsertMode()
+				w.InputtedOther([]byte("x"))
+			},
+			want: "x\033[1;6H1\033[1;2H",
+		},
+		{
+			name: "command typed",
+			update: func(w *Window) {
+				w.SetCommandMode()
+				w.AddCommand([]byte("hi"))
+			},
+			want: "\033[4;1H:hi",
+		},
+		{
+			name: "resized",
+			update: func(w *Window) {
+				w.Terminal.(*SimTerminal).Resize(20, 3)
+				w.SetSize()
+			},
+			want: "\033[0m\033[H\033[2J11111\033[2;1H2222\033[1;1H",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			term := NewSimTerminal(30, 4)
+			w := NewWindow(term)
+			w.SetSize()
+			if err := w.SetFileContents("../testdata/test.txt"); err != nil {
+				t.Fatal(err)
+			}
+			w.PrintFileContents()
+			term.Out.Reset()
+			tt.update(w)
+			if w.PrintFileContents(); term.Out.String() != tt.want {
+				t.Errorf("got: %q, want: %q", term.Out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow_displayColumn(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		x    int
+		want int
+	}{
+		{name: "first column", line: "abc", x: 1, want: 0},
+		{name: "ascii", line: "abc", x: 3, want: 2},
+		{name: "after a tab", line: "\tabc", x: 2, want: 8},
+		{name: "tab in the middle", line: "ab\tc", x: 4, want: 8},
+		{name: "wide characters", line: "日本語", x: 4, want: 2},
+		{name: "past the end", line: "ab", x: 4, want: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &Window{FileContents: [][]byte{[]byte(tt.line)}}
+			if got := w.displayColumn(0, tt.x); got != tt.want {
+				t.Errorf("displayColumn() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package window
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// A file is written to a temporary file in its directory which then replaces it, so a crash
+// or a full disk while writing leaves the file as it was. A new file is written directly. The new file keeps the permissions
+// and the owner of the old one, and a symbolic link is written through to the file it points to.
+// A file with more than one link, or whose owner cannot be kept, is written in place instead,
+// after it is copied to a backup as a failed write would lose it.
+// With writebackup the file is copied to a backup before it is written, ex) a.go~ in the first
+// directory of backupdir it can be made in, and with backup the backup is kept.
+
+var (
+	errBackup = errors.New("E510: Can't make backup file (add ! to override)")
+	errOwner  = errors.New("the owner of the file cannot be kept")
+	// errInPlace is returned by replaceFile when the file is to be written in place
+	errInPlace = errors.New("the file cannot be replaced")
+)
+
+// fileStamp is what the buffer knows of its file to tell when another program changed it.
+type fileStamp struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// statFile returns the stamp of the file name as it is now.
+func statFile(name string) fileStamp {
+	info, err := os.Stat(name)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{exists: true, modTime: info.ModTime(), size: info.Size()}
+}
+
+// changedOnDisk reports whether the file of the buffer changed since it was read or written.
+func (w *Window) changedOnDisk() bool {
+	now := statFile(w.fileName)
+	return now.exists != w.fileStamp.exists || now.size != w.fileStamp.size || !now.modTime.Equal(w.fileStamp.modTime)
+}
+
+// linkTarget returns the file name points to through symbolic links, or name when it is not a link.
+func linkTarget(name string) string {
+	// as many links as the system follows
+	for i := 0; i < 40; i++ {
+		link, err := os.Readlink(name)
+		if err != nil {
+			return name
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(name), link)
+		}
+		name = link
+	}
+	return name
+}
+
+// writeFile writes data to the file name. With force the file is written when no backup can be made.
+func (w *Window) writeFile(name string, data []byte, force bool) error {
+	target := linkTarget(name)
+	info, err := os.Stat(target)
+	if err != nil {
+		info = nil
+	} else if info.IsDir() {
+		return &os.PathError{Op: "write", Path: name, Err: syscall.EISDIR}
+	}
+	backup := ""
+	if info != nil && (w.boolOption("backup") || w.boolOption("writebackup")) {
+		if backup, err = w.makeBackup(target, info); err != nil && !force {
+			return err
+		}
+	}
+	err = replaceFile(target, data, info)
+	if err == errInPlace {
+		if backup == "" {
+			if backup, err = w.makeBackup(target, info); err != nil && !force {
+				return err
+			}
+		}
+		err = writeInPlace(target, data)
+	}
+	if err != nil {
+		return err
+	}
+	if backup != "" && !w.boolOption("backup") {
+		os.Remove(backup)
+	}
+	return nil
+}
+
+// makeBackup copies the file target to the first directory of backupdir it can be copied to,
+// and returns the name of the copy. . is the directory of the file.
+func (w *Window) makeBackup(target string, info os.FileInfo) (string, error) {
+	data, err := ioutil.ReadFile(target)
+	if err != nil {
+		return "", errBackup
+	}
+	for _, dir := range strings.Split(w.stringOption("backupdir"), ",") {
+		if dir == "" {
+			continue
+		}
+		if dir == "." || strings.HasPrefix(dir, "./") {
+			dir = filepath.Join(filepath.Dir(target), dir)
+		}
+		backup := filepath.Join(expandHome(dir), filepath.Base(target)+"~")
+		if err := writeBackup(backup, data, info.Mode().Perm()); err == nil {
+			return backup, nil
+		}
+	}
+	return "", errBackup
+}
+
+// writeBackup writes data to the file backup and syncs it, so the backup is on the disk
+// before the file it is of is replaced.
+func writeBackup(backup string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(backup))
+	return nil
+}
+
+// replaceFile replaces the file target with one of data, which keeps the permissions and
+// the owner of info. info is nil for a new file. It returns errInPlace when the file is to be
+// written in place instead, see writeInPlace.
+func replaceFile(target string, data []byte, info os.FileInfo) error {
+	if info == nil {
+		return writeNewFile(target, data)
+	}
+	mode := info.Mode() & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)
+	st, _ := info.Sys().(*syscall.Stat_t)
+	if st != nil && st.Nlink > 1 {
+		// a new file would not be the file of the other links
+		return errInPlace
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(target), "."+filepath.Base(target)+".*")
+	if err != nil {
+		// ex) the file can be written but not its directory
+		return errInPlace
+	}
+	err = writeTemp(tmp, data, mode, st)
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		if err == errOwner {
+			return errInPlace
+		}
+		return err
+	}
+	syncDir(filepath.Dir(target))
+	return nil
+}
+
+// writeNewFile writes data to target, a file that does not exist yet. It is made with the permissions
+// of the umask, as os.Create makes a file. There is no old file to keep, so no temporary file is needed.
+func writeNewFile(target string, data []byte) error {
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(target)
+		return err
+	}
+	syncDir(filepath.Dir(target))
+	return nil
+}
+
+// writeTemp writes data to the temporary file tmp with mode and the owner of st, and closes it.
+func writeTemp(tmp *os.File, data []byte, mode os.FileMode, st *syscall.Stat_t) error {
+	_, err := tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err == nil && st != nil && (int(st.Uid) != os.Getuid() || int(st.Gid) != os.Getgid()) {
+		if tmp.Chown(int(st.Uid), int(st.Gid)) != nil {
+			err = errOwner
+		}
+	}
+	if err == nil {
+		err = tmp.Chmod(mode)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeInPlace writes data over the file target. The file is cut to the length of data only
+// after data is written, so a full disk does not leave it empty.
+func writeInPlace(target string, data []byte) error {
+	f, err := os.OpenFile(target, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Truncate(int64(len(data)))
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// syncDir makes the new name of a file in dir last, it is not needed for the file to be written.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
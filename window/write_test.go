@@ -0,0 +1,251 @@
+package window
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestWindow_writeFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		// prepare makes the files of dir, the file edited is a.txt
+		prepare func(t *testing.T, dir string)
+		// changed is written to a.txt after it is read, when it is not empty
+		changed   string
+		wantErr   string
+		wantFiles map[string]string // the files of dir after the commands
+	}{
+		{
+			name:      "replaced",
+			commands:  []string{"normal x", "w"},
+			wantFiles: map[string]string{"a.txt": "bc\n"},
+		},
+		{
+			name:     "symbolic link",
+			commands: []string{"normal x", "w"},
+			prepare: func(t *testing.T, dir string) {
+				os.Rename(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt"))
+				if err := os.Symlink("b.txt", filepath.Join(dir, "a.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "-> b.txt", "b.txt": "bc\n"},
+		},
+		{
+			name:     "hard link",
+			commands: []string{"normal x", "w"},
+			prepare: func(t *testing.T, dir string) {
+				if err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "bc\n", "b.txt": "bc\n"},
+		},
+		{
+			name:     "hard link without writebackup",
+			commands: []string{"set nowb", "normal x", "w"},
+			prepare: func(t *testing.T, dir string) {
+				if err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "bc\n", "b.txt": "bc\n"},
+		},
+		{
+			name:     "hard link without a backup",
+			commands: []string{"set nowb bdir=./none", "normal x", "w"},
+			prepare: func(t *testing.T, dir string) {
+				if err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantErr:   "E510: Can't make backup file (add ! to override)",
+			wantFiles: map[string]string{"a.txt": "abc\n", "b.txt": "abc\n"},
+		},
+		{
+			name:     "hard link written without a backup",
+			commands: []string{"set nowb bdir=./none", "normal x", "w!"},
+			prepare: func(t *testing.T, dir string) {
+				if err := os.Link(filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "bc\n", "b.txt": "bc\n"},
+		},
+		{
+			name:      "backup",
+			commands:  []string{"set bk", "normal x", "w"},
+			wantFiles: map[string]string{"a.txt": "bc\n", "a.txt~": "abc\n"},
+		},
+		{
+			name:     "backupdir",
+			commands: []string{"set bk bdir=./none,./backups", "normal x", "w"},
+			prepare: func(t *testing.T, dir string) {
+				if err := os.Mkdir(filepath.Join(dir, "backups"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantFiles: map[string]string{"a.txt": "bc\n", "backups": "", "backups/a.txt~": "abc\n"},
+		},
+		{
+			name:      "no backup can be made",
+			commands:  []string{"set bdir=./none", "normal x", "w"},
+			wantErr:   "E510: Can't make backup file (add ! to override)",
+			wantFiles: map[string]string{"a.txt": "abc\n"},
+		},
+		{
+			name:      "written without a backup",
+			commands:  []string{"set bdir=./none", "normal x", "w!"},
+			wantFiles: map[string]string{"a.txt": "bc\n"},
+		},
+		{
+			name:      "changed on disk",
+			commands:  []string{"normal x", "w"},
+			changed:   "changed\n",
+			wantErr:   "W11: Warning: File \"a.txt\" has changed since editing started (add ! to override)",
+			wantFiles: map[string]string{"a.txt": "changed\n"},
+		},
+		{
+			name:      "changed on disk and written with !",
+			commands:  []string{"normal x", "w!"},
+			changed:   "changed\n",
+			wantFiles: map[string]string{"a.txt": "bc\n"},
+		},
+		{
+			name:      "written twice",
+			commands:  []string{"normal x", "w", "normal x", "w"},
+			wantFiles: map[string]string{"a.txt": "c\n"},
+		},
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "gim")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile("a.txt", []byte("abc\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			w := NewWindow(NewSimTerminal(80, 24))
+			if tt.prepare != nil {
+				tt.prepare(t, dir)
+			}
+			if err := w.SetFileContents("a.txt"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.changed != "" {
+				if err := ioutil.WriteFile("a.txt", []byte(tt.changed), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, c := range tt.commands {
+				if err = w.ExecuteLine(c); err != nil {
+					break
+				}
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("got: %v, want: %s", err, tt.wantErr)
+			}
+			if got := dirFiles(t, dir); !reflect.DeepEqual(got, tt.wantFiles) {
+				t.Errorf("got: %q, want: %q", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+// dirFiles returns the files under dir by name: the contents of a file, "-> target" for
+// a symbolic link and "" for a directory.
+func dirFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	files := make(map[string]string)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		name, _ := filepath.Rel(dir, path)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			files[name] = "-> " + link
+			return err
+		case info.IsDir():
+			files[name] = ""
+		default:
+			b, err := ioutil.ReadFile(path)
+			files[name] = string(b)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestWindow_writeFileMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "a.sh")
+	if err := ioutil.WriteFile(path, []byte("echo\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(path, 0751); err != nil {
+		t.Fatal(err)
+	}
+	w := NewWindow(NewSimTerminal(80, 24))
+	if err := w.SetFileContents(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.ExecuteLine("w"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0751 {
+		t.Errorf("got: %v, want: %v", got, os.FileMode(0751))
+	}
+}
+
+func TestWindow_writeNewFileMode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gim")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer syscall.Umask(syscall.Umask(077))
+	path := filepath.Join(dir, "new.txt")
+	w := NewWindow(NewSimTerminal(80, 24))
+	if err := w.SetFileContents(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.ExecuteLine("w"); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0600 {
+		t.Errorf("got: %v, want: %v", got, os.FileMode(0600))
+	}
+}